@@ -1,19 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
 	"log/slog"
 	"os"
 	"path/filepath"
 
-	"github.com/joho/godotenv"
+	"github.com/pardnchiu/go-podrun/internal/config"
 	"github.com/pardnchiu/go-podrun/internal/database"
 	"github.com/pardnchiu/go-podrun/internal/handler"
+	"github.com/pardnchiu/go-podrun/internal/reconcile"
 )
 
 func init() {
-	if err := godotenv.Load(); err != nil {
-		slog.Warn("failed to load .env",
+	if err := config.Apply(""); err != nil {
+		slog.Warn("failed to load config",
 			slog.String("error", err.Error()))
 	}
 }
@@ -21,18 +23,26 @@ func init() {
 func main() {
 	dbPath := os.Getenv("DB_PATH")
 	if dbPath == "" {
-		if _, err := os.Stat("/.dockerenv"); err == nil {
-			dbPath = "/data/database.db"
-		} else {
-			home, _ := os.UserHomeDir()
-			dbPath = filepath.Join(home, ".podrun", "database.db")
-			// 確保目錄存在
-			dir := filepath.Dir(dbPath)
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				log.Fatalf("Failed to create directory %s: %v", dir, err)
-			}
+		dbPath = database.DefaultPath()
+		// 確保目錄存在
+		dir := filepath.Dir(dbPath)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Fatalf("Failed to create directory %s: %v", dir, err)
 		}
 	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(dbPath, os.Args[2:]); err != nil {
+			log.Fatalf("[x] %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		if err := token(dbPath, os.Args[2:]); err != nil {
+			log.Fatalf("[x] %v", err)
+		}
+		return
+	}
+
 	db, err := database.NewSQLite(dbPath)
 	if err != nil {
 		log.Fatalf("[x] failed to create database: %v", err)
@@ -58,6 +68,14 @@ func main() {
 	// 	}
 	// }
 
+	reconciler, err := reconcile.FromEnv(db)
+	if err != nil {
+		log.Fatalf("[x] failed to create reconciler: %v", err)
+	}
+	if reconciler != nil {
+		go reconciler.Run(context.Background())
+	}
+
 	if err := handler.NewRoutes(db); err != nil {
 		log.Fatalf("[x] failed to initialize http: %v", err)
 	}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/database"
+)
+
+// * podrun-api migrate status|up
+func migrate(dbPath string, args []string) error {
+	action := "status"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch action {
+	case "status":
+		migrations, err := db.Migrations(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[*] %s\n", dbPath)
+		pending := 0
+		for _, m := range migrations {
+			state := "pending"
+			if m.Applied() {
+				state = m.AppliedAt.Local().Format(time.DateTime)
+			} else {
+				pending++
+			}
+			fmt.Printf("%04d  %-24s %s\n", m.Version, m.Name, state)
+		}
+		fmt.Printf("[*] %d pending\n", pending)
+		return nil
+
+	case "up":
+		applied, err := db.Migrate(ctx)
+		for _, m := range applied {
+			fmt.Printf("[+] applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("[-] schema is up to date")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported migrate command: %s", action)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/database"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+// * podrun-api token create <user> [--name <label>] [--email <email>]
+// * podrun-api token list
+// * podrun-api token revoke <id|prefix|user>
+func token(dbPath string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("podrun-api token <create|list|revoke>")
+	}
+
+	db, err := database.NewSQLite(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "create":
+		user := &model.User{}
+		name := ""
+		rest := args[1:]
+		for i := 0; i < len(rest); i++ {
+			switch arg := rest[i]; {
+			case arg == "--name" && i+1 < len(rest):
+				name = rest[i+1]
+				i++
+			case arg == "--email" && i+1 < len(rest):
+				user.Email = rest[i+1]
+				i++
+			case user.Name == "" && arg != "" && arg[0] != '-':
+				user.Name = arg
+			default:
+				return fmt.Errorf("unexpected argument: %s", arg)
+			}
+		}
+		if user.Name == "" {
+			return fmt.Errorf("podrun-api token create <user> [--name <label>] [--email <email>]")
+		}
+
+		value, t, err := db.CreateToken(ctx, user, name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[+] created token #%d (%s) for %s\n", t.ID, t.Prefix, t.User)
+		fmt.Println("[!] copy it now, it will not be shown again:")
+		fmt.Println(value)
+		return nil
+
+	case "list":
+		tokens, err := db.ListTokens(ctx)
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			fmt.Println("[-] no tokens")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSER\tNAME\tPREFIX\tCREATED\tLAST USED\tREVOKED")
+		for _, e := range tokens {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				e.ID, e.User, e.Name, e.Prefix,
+				e.CreatedAt.Local().Format(time.DateTime),
+				formatTime(e.LastUsedAt),
+				formatTime(e.RevokedAt))
+		}
+		return w.Flush()
+
+	case "revoke":
+		if len(args) < 2 {
+			return fmt.Errorf("podrun-api token revoke <id|prefix|user>")
+		}
+		n, err := db.RevokeToken(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("no active token matches %s", args[1])
+		}
+		fmt.Printf("[-] revoked %d token(s)\n", n)
+		return nil
+	}
+
+	return fmt.Errorf("unsupported token command: %s", args[0])
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
@@ -1,51 +1,146 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"log/slog"
+	"os"
 
-	"github.com/joho/godotenv"
 	"github.com/pardnchiu/go-podrun/internal/command"
+	"github.com/pardnchiu/go-podrun/internal/config"
 	"github.com/pardnchiu/go-podrun/internal/utils"
 )
 
 func init() {
-	if err := godotenv.Load(); err != nil {
-		slog.Warn("failed to load .env",
+	if err := config.Apply(""); err != nil {
+		slog.Warn("failed to load config",
 			slog.String("error", err.Error()))
 	}
 }
 
 func main() {
-	if err := utils.CheckRelyPackages(); err != nil {
-		log.Fatalf("missing required packages: %s", err)
+	// * rsync -e 內部呼叫，stdout 為 rsync 協定，不可輸出其他內容
+	if len(os.Args) > 1 && os.Args[1] == "__rsh" {
+		code, err := utils.RshRun(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[x] %s\n", err)
+		}
+		os.Exit(code)
 	}
 
-	if _, err := utils.CheckENV(); err != nil {
-		log.Fatalf("missing required environment: %s", err)
+	if len(os.Args) > 1 && os.Args[1] == "hosts" {
+		if err := command.Hosts(os.Args[2:]); err != nil {
+			exit(err, utils.ExitFailure)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "registry" {
+		if err := command.Registry(os.Args[2:]); err != nil {
+			exit(err, utils.ExitRegistry)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := command.Config(os.Args[2:]); err != nil {
+			exit(err, utils.ExitUsage)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "server" {
+		if err := command.Server(os.Args[2:]); err != nil {
+			exit(err, utils.ExitUsage)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "ls" {
+		if err := command.List(os.Args[2:]); err != nil {
+			exit(err, utils.ExitRegistry)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "clone" {
+		if err := command.Clone(os.Args[2:]); err != nil {
+			exit(err, utils.ExitFailure)
+		}
+		return
+	}
+
+	if err := command.CheckRegistry(); err != nil {
+		exit(err, utils.ExitRegistry)
 	}
 
 	cmd, err := command.New()
 	if err != nil {
-		log.Fatalf("failed to create command: %s", err)
+		exit(fmt.Errorf("failed to create command: %w", err), utils.ExitUsage)
+	}
+
+	// * 僅在本機執行，不需要連線遠端
+	switch cmd.RemoteArgs[0] {
+	case "export":
+		if err := cmd.Export(); err != nil {
+			exit(err, utils.ExitFailure)
+		}
+		return
+	case "history":
+		if err := cmd.History(); err != nil {
+			exit(err, utils.ExitRegistry)
+		}
+		return
+	case "domain":
+		if err := cmd.Domain(); err != nil {
+			exit(err, utils.ExitRegistry)
+		}
+		return
+	case "ports":
+		if err := cmd.Ports(); err != nil {
+			exit(err, utils.ExitRegistry)
+		}
+		return
+	case "sync":
+		if err := cmd.SyncList(); err != nil {
+			exit(err, utils.ExitUsage)
+		}
+		return
+	}
+
+	if err := utils.CheckRelyPackages(); err != nil {
+		exit(fmt.Errorf("missing required packages: %w", err), utils.ExitUsage)
+	}
+
+	if _, err := utils.CheckENV(); err != nil {
+		exit(fmt.Errorf("missing required environment: %w", err), utils.ExitUsage)
 	}
 
 	if err := utils.SSHTest(); err != nil {
-		log.Fatalf("failed to connect to remote server: %s", err)
+		exit(fmt.Errorf("failed to connect to remote server: %w", err), utils.ExitAuth)
 	}
 
 	switch cmd.RemoteArgs[0] {
-	case "domain":
 	case "deploy":
+	case "info":
+		if err := cmd.Info(); err != nil {
+			exit(err, utils.ExitFailure)
+		}
 	default:
 		result, err := cmd.ComposeCMD()
 		if err != nil {
-			slog.Error("failed to connect to remote server",
-				"err", err)
+			// * 未分類的錯誤來自遠端的 compose / kubectl
+			exit(err, utils.ExitCompose)
 		}
 		slog.Info("", "result", result)
 		// case "rm":
-		// case "ports":
-		// case "export":
 	}
+	utils.SSHClose()
+}
+
+// * 依錯誤類別結束，見 utils.Exit*；os.Exit 不會執行 defer，先關閉連線
+func exit(err error, fallback int) {
+	utils.SSHClose()
+	log.Print(err)
+	os.Exit(utils.ExitStatus(err, fallback))
 }
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultURL     = "http://localhost:8080/api"
+	defaultTimeout = 5 * time.Second
+	defaultRetries = 2
+	defaultBackoff = 300 * time.Millisecond
+)
+
+type Client struct {
+	baseURL string
+	http    *http.Client
+	retries int
+	backoff time.Duration
+	token   string
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) { cl.http = c }
+}
+
+func WithTimeout(d time.Duration) Option {
+	return func(cl *Client) { cl.http.Timeout = d }
+}
+
+// * 以 Authorization: Bearer 送出
+func WithToken(token string) Option {
+	return func(cl *Client) { cl.token = token }
+}
+
+// * 重試次數不含第一次請求
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(cl *Client) {
+		cl.retries = n
+		cl.backoff = backoff
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: defaultTimeout},
+		retries: defaultRetries,
+		backoff: defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// * PODRUN_API 未設定時使用本機的 API server；PODRUN_TOKEN 為 API token
+func FromEnv(opts ...Option) *Client {
+	baseURL := os.Getenv("PODRUN_API")
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	if token := os.Getenv("PODRUN_TOKEN"); token != "" {
+		opts = append([]Option{WithToken(token)}, opts...)
+	}
+	return New(baseURL, opts...)
+}
+
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// * 非 200 回應
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized) || hasStatus(err, http.StatusForbidden)
+}
+
+func hasStatus(err error, code int) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == code
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+func (c *Client) post(ctx context.Context, path string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, path, body, out)
+}
+
+// * 冪等的請求（GET）在連線失敗與 502 / 503 / 504 時重試；
+// * 其他請求只在尚未建立連線時重試，避免 server 已處理的寫入被重送
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out any) error {
+	var lastErr error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.backoff * time.Duration(attempt)):
+			}
+		}
+
+		retry, connected, err := c.once(ctx, method, path, body, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry || (connected && !idempotent(method)) {
+			break
+		}
+	}
+	return lastErr
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// * connected 表示已取得連線，請求可能已送達 server
+func (c *Client) once(ctx context.Context, method, path string, body []byte, out any) (retry, connected bool, err error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { connected = true },
+	})
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return false, false, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, connected, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return true, connected, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode == http.StatusBadGateway ||
+			resp.StatusCode == http.StatusServiceUnavailable ||
+			resp.StatusCode == http.StatusGatewayTimeout
+		return retry, connected, &StatusError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(data)),
+		}
+	}
+
+	if out == nil {
+		return false, connected, nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, connected, fmt.Errorf("%s %s: decode response: %w", method, path, err)
+	}
+	return false, connected, nil
+}
@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pardnchiu/go-podrun/internal/database"
+	"github.com/pardnchiu/go-podrun/internal/handler"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+// * 以實際的 gin 路由與暫存 SQLite 啟動 API server，回傳帶 token 的 client
+func newTestServer(t *testing.T) *Client {
+	t.Helper()
+
+	db, err := database.NewSQLite(filepath.Join(t.TempDir(), "podrun.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	token, _, err := db.CreateToken(context.Background(), &model.User{Name: "alice"}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prevDB, prevAuth := handler.DB, handler.AuthMode
+	handler.DB, handler.AuthMode = db, handler.AuthRequired
+	t.Cleanup(func() { handler.DB, handler.AuthMode = prevDB, prevAuth })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	handler.Register(r)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	return New(server.URL+"/api", WithToken(token), WithRetries(0, 0))
+}
+
+func TestClientRoutes(t *testing.T) {
+	c := newTestServer(t)
+	ctx := context.Background()
+
+	if err := c.Health(ctx); err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+
+	me, err := c.Me(ctx)
+	if err != nil || me.Name != "alice" {
+		t.Fatalf("Me = %+v, %v", me, err)
+	}
+
+	// * Server
+	if err := c.UpsertServer(ctx, &model.Server{Name: "prod", Host: "10.0.0.1", Port: 22, User: "deploy"}); err != nil {
+		t.Fatalf("UpsertServer: %v", err)
+	}
+	servers, err := c.ListServers(ctx)
+	if err != nil || len(servers) != 1 || servers[0].Host != "10.0.0.1" {
+		t.Fatalf("ListServers = %+v, %v", servers, err)
+	}
+
+	// * Pod
+	pod := &model.Pod{
+		UID:       "uid-1",
+		PodName:   "web",
+		LocalDir:  "/src/web",
+		RemoteDir: "/srv/web",
+		File:      "docker-compose.yml",
+		Target:    "default",
+		Status:    model.StatusStarting,
+		Server:    "prod",
+		Project:   "shop",
+	}
+	if err := c.UpsertPod(ctx, pod); err != nil {
+		t.Fatalf("UpsertPod: %v", err)
+	}
+	got, err := c.GetPod(ctx, "uid-1")
+	if err != nil || got.PodName != "web" || got.Owner != "alice" || got.Server != "prod" || got.Project != "shop" {
+		t.Fatalf("GetPod = %+v, %v", got, err)
+	}
+	if _, err := c.GetPod(ctx, "missing"); !IsNotFound(err) {
+		t.Fatalf("GetPod(missing) = %v, want not found", err)
+	}
+
+	if err := c.UpdatePod(ctx, "uid-1", &model.Pod{Status: model.StatusRunning}); err != nil {
+		t.Fatalf("UpdatePod: %v", err)
+	}
+	// * 不合法的狀態轉換回傳 409
+	if err := c.UpsertPod(ctx, &model.Pod{UID: "uid-2", Status: model.StatusRunning}); !IsConflict(err) {
+		t.Fatalf("UpsertPod(new, running) = %v, want conflict", err)
+	}
+	pods, err := c.ListPods(ctx, PodQuery{Owner: "alice", Server: "prod"})
+	if err != nil || len(pods) != 1 || pods[0].Status != model.StatusRunning {
+		t.Fatalf("ListPods = %+v, %v", pods, err)
+	}
+	if pods, err := c.ListPods(ctx, PodQuery{Owner: "bob"}); err != nil || len(pods) != 0 {
+		t.Fatalf("ListPods(bob) = %+v, %v", pods, err)
+	}
+
+	// * Record
+	if err := c.InsertRecord(ctx, &model.Record{UID: "uid-1", Content: "up", Command: "up"}); err != nil {
+		t.Fatalf("InsertRecord: %v", err)
+	}
+	records, total, err := c.ListRecords(ctx, "uid-1", RecordQuery{Limit: 10, User: "alice"})
+	if err != nil || total != 1 || len(records) != 1 || records[0].Command != "up" {
+		t.Fatalf("ListRecords = %+v (%d), %v", records, total, err)
+	}
+
+	// * Port
+	ports, err := c.AllocatePorts(ctx, "uid-1", []model.Port{{Service: "web", ContainerPort: 80, Protocol: "tcp"}})
+	if err != nil || len(ports) != 1 || ports[0].HostPort == 0 {
+		t.Fatalf("AllocatePorts = %+v, %v", ports, err)
+	}
+	again, err := c.AllocatePorts(ctx, "uid-1", []model.Port{{Service: "web", ContainerPort: 80, Protocol: "tcp"}})
+	if err != nil || len(again) != 1 || again[0].HostPort != ports[0].HostPort {
+		t.Fatalf("AllocatePorts is not stable: %+v, %v", again, err)
+	}
+	if listed, err := c.ListPorts(ctx, "uid-1"); err != nil || len(listed) != 1 {
+		t.Fatalf("ListPorts = %+v, %v", listed, err)
+	}
+	if err := c.ReleasePorts(ctx, "uid-1"); err != nil {
+		t.Fatalf("ReleasePorts: %v", err)
+	}
+	if listed, err := c.ListPorts(ctx, "uid-1"); err != nil || len(listed) != 0 {
+		t.Fatalf("ListPorts after release = %+v, %v", listed, err)
+	}
+
+	// * Domain
+	domain := &model.Domain{UID: "uid-1", ContainerName: "web", Domain: "web.example.com"}
+	if err := c.UpsertDomain(ctx, domain); err != nil {
+		t.Fatalf("UpsertDomain: %v", err)
+	}
+	domains, err := c.ListDomains(ctx, "uid-1")
+	if err != nil || len(domains) != 1 || domains[0].Domain != "web.example.com" {
+		t.Fatalf("ListDomains = %+v, %v", domains, err)
+	}
+	if err := c.RemoveDomain(ctx, domain); err != nil {
+		t.Fatalf("RemoveDomain: %v", err)
+	}
+	if domains, err := c.ListDomains(ctx, "uid-1"); err != nil || len(domains) != 0 {
+		t.Fatalf("ListDomains after remove = %+v, %v", domains, err)
+	}
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	c := newTestServer(t)
+	c.token = ""
+
+	if _, err := c.ListPods(context.Background(), PodQuery{}); !IsUnauthorized(err) {
+		t.Fatalf("got %v, want unauthorized", err)
+	}
+	// * health 不需要 token
+	if err := c.Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// * GET 在 503 時重試；POST 已送達 server 時不重送
+func TestClientRetry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithRetries(2, time.Millisecond))
+
+	calls.Store(0)
+	if err := c.get(context.Background(), "/x", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("GET attempts = %d, want 3", n)
+	}
+
+	calls.Store(0)
+	if err := c.post(context.Background(), "/x", map[string]string{}, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("POST attempts = %d, want 1", n)
+	}
+}
+
+// * 連線未建立時 POST 仍會重試
+func TestClientRetryBeforeConnect(t *testing.T) {
+	var dials atomic.Int32
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (conn net.Conn, err error) {
+			dials.Add(1)
+			return nil, errors.New("connection refused")
+		},
+	}
+	c := New("http://podrun.invalid", WithHTTPClient(&http.Client{Transport: transport}), WithRetries(2, time.Millisecond))
+
+	if err := c.post(context.Background(), "/x", map[string]string{}, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := dials.Load(); n != 3 {
+		t.Fatalf("dial attempts = %d, want 3", n)
+	}
+}
+
+// * 同一個 container port 的多個對應各自分配；host port 只在同一台主機上不重複
+func TestAllocatePortsPerServer(t *testing.T) {
+	c := newTestServer(t)
+	ctx := context.Background()
+
+	for i, server := range []string{"prod", "staging"} {
+		if err := c.UpsertServer(ctx, &model.Server{Name: server, Host: "10.0.0." + strconv.Itoa(i+1)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pod := range []*model.Pod{
+		{UID: "a", PodID: "a", Status: model.StatusStarting, Server: "prod"},
+		{UID: "b", PodID: "b", Status: model.StatusStarting, Server: "prod"},
+		{UID: "c", PodID: "c", Status: model.StatusStarting, Server: "staging"},
+	} {
+		if err := c.UpsertPod(ctx, pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reqs := []model.Port{
+		{Service: "web", ContainerPort: 80, Protocol: "tcp", Seq: 0},
+		{Service: "web", ContainerPort: 80, Protocol: "tcp", Seq: 1},
+	}
+	a, err := c.AllocatePorts(ctx, "a", reqs)
+	if err != nil || len(a) != 2 || a[0].HostPort == a[1].HostPort {
+		t.Fatalf("AllocatePorts(a) = %+v, %v", a, err)
+	}
+	b, err := c.AllocatePorts(ctx, "b", reqs[:1])
+	if err != nil || len(b) != 1 || b[0].HostPort == a[0].HostPort || b[0].HostPort == a[1].HostPort {
+		t.Fatalf("AllocatePorts(b) = %+v, %v, same server as %+v", b, err, a)
+	}
+	cp, err := c.AllocatePorts(ctx, "c", reqs[:1])
+	if err != nil || len(cp) != 1 || cp[0].HostPort != a[0].HostPort {
+		t.Fatalf("AllocatePorts(c) = %+v, %v, want host port %d on another server", cp, err, a[0].HostPort)
+	}
+
+	// * 移除一個對應後只保留其餘的分配
+	again, err := c.AllocatePorts(ctx, "a", reqs[1:])
+	if err != nil || len(again) != 1 || again[0].HostPort != a[1].HostPort {
+		t.Fatalf("AllocatePorts(a, seq 1) = %+v, %v", again, err)
+	}
+	if listed, err := c.ListPorts(ctx, "a"); err != nil || len(listed) != 1 || listed[0].Seq != 1 {
+		t.Fatalf("ListPorts(a) = %+v, %v", listed, err)
+	}
+}
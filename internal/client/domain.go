@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func (c *Client) ListDomains(ctx context.Context, uid string) ([]model.Domain, error) {
+	var resp struct {
+		Data []model.Domain `json:"data"`
+	}
+	if err := c.get(ctx, "/pod/domain/list/"+url.PathEscape(uid), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (c *Client) UpsertDomain(ctx context.Context, d *model.Domain) error {
+	return c.post(ctx, "/pod/domain/upsert", d, nil)
+}
+
+func (c *Client) RemoveDomain(ctx context.Context, d *model.Domain) error {
+	return c.post(ctx, "/pod/domain/remove", d, nil)
+}
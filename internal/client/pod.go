@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+type PodQuery struct {
+	Owner  string
+	Server string
+}
+
+type RecordQuery struct {
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+	Offset int
+	User   string
+}
+
+func (c *Client) Health(ctx context.Context) error {
+	return c.get(ctx, "/health", nil)
+}
+
+func (c *Client) ListPods(ctx context.Context, q PodQuery) ([]model.Pod, error) {
+	query := url.Values{}
+	if q.Owner != "" {
+		query.Set("owner", q.Owner)
+	}
+	if q.Server != "" {
+		query.Set("server", q.Server)
+	}
+	path := "/pod/list"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp struct {
+		Data []model.Pod `json:"data"`
+	}
+	if err := c.get(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (c *Client) GetPod(ctx context.Context, uid string) (*model.Pod, error) {
+	var resp struct {
+		Data model.Pod `json:"data"`
+	}
+	if err := c.get(ctx, "/pod/"+url.PathEscape(uid), &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (c *Client) UpsertPod(ctx context.Context, d *model.Pod) error {
+	return c.post(ctx, "/pod/upsert", d, nil)
+}
+
+// * 更新 status，dismiss 由 server 依狀態決定
+func (c *Client) UpdatePod(ctx context.Context, uid string, d *model.Pod) error {
+	return c.post(ctx, "/pod/update/"+url.PathEscape(uid), d, nil)
+}
+
+func (c *Client) InsertRecord(ctx context.Context, r *model.Record) error {
+	return c.post(ctx, "/pod/record/insert", r, nil)
+}
+
+// * 回傳該頁紀錄與符合條件的總筆數
+func (c *Client) ListRecords(ctx context.Context, uid string, q RecordQuery) ([]model.Record, int, error) {
+	query := url.Values{}
+	if !q.Since.IsZero() {
+		query.Set("since", q.Since.Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		query.Set("until", q.Until.Format(time.RFC3339))
+	}
+	if q.Limit > 0 {
+		query.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		query.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.User != "" {
+		query.Set("user", q.User)
+	}
+
+	path := "/pod/" + url.PathEscape(uid) + "/records"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp struct {
+		Data  []model.Record `json:"data"`
+		Total int            `json:"total"`
+	}
+	if err := c.get(ctx, path, &resp); err != nil {
+		return nil, 0, err
+	}
+	return resp.Data, resp.Total, nil
+}
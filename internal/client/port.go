@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func (c *Client) ListPorts(ctx context.Context, uid string) ([]model.Port, error) {
+	var resp struct {
+		Data []model.Port `json:"data"`
+	}
+	if err := c.get(ctx, "/pod/port/list/"+url.PathEscape(uid), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (c *Client) AllocatePorts(ctx context.Context, uid string, reqs []model.Port) ([]model.Port, error) {
+	var resp struct {
+		Data []model.Port `json:"data"`
+	}
+	if err := c.post(ctx, "/pod/port/allocate/"+url.PathEscape(uid), reqs, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (c *Client) ReleasePorts(ctx context.Context, uid string) error {
+	return c.post(ctx, "/pod/port/release/"+url.PathEscape(uid), nil, nil)
+}
@@ -0,0 +1,21 @@
+package client
+
+import (
+	"context"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func (c *Client) ListServers(ctx context.Context) ([]model.Server, error) {
+	var resp struct {
+		Data []model.Server `json:"data"`
+	}
+	if err := c.get(ctx, "/server/list", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (c *Client) UpsertServer(ctx context.Context, d *model.Server) error {
+	return c.post(ctx, "/server/upsert", d, nil)
+}
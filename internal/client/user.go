@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+// * token 對應的使用者；API 未啟用驗證時為 nil
+func (c *Client) Me(ctx context.Context) (*model.User, error) {
+	var resp struct {
+		Data *model.User `json:"data"`
+	}
+	if err := c.get(ctx, "/user/me", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
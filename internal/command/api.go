@@ -0,0 +1,264 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/journal"
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/registry"
+	"github.com/pardnchiu/go-podrun/internal/utils"
+)
+
+var (
+	apiRegistry registry.Registry
+	apiOnce     sync.Once
+
+	userName string
+	userOnce sync.Once
+
+	commitCache = map[string]string{}
+	commitMu    sync.Mutex
+)
+
+// * 延遲建立，確保 .env 已載入 PODRUN_REGISTRY / PODRUN_API / DB_PATH
+func api() registry.Registry {
+	apiOnce.Do(func() {
+		apiRegistry = registry.FromEnv()
+	})
+	return apiRegistry
+}
+
+// * 啟動時檢查 registry 是否可用；離線交給 journal，只有缺少 cgo 時直接失敗
+func CheckRegistry() error {
+	e, ok := api().(*registry.Embedded)
+	if !ok {
+		return nil
+	}
+	if err := e.Check(); registry.IsRequiresCgo(err) {
+		return utils.Classify(utils.ExitRegistry, err)
+	}
+	return nil
+}
+
+// * 目前的使用者：API 有驗證時為 token 的使用者，否則為 PODRUN_USER 或本機使用者
+// * 結果快取於本次執行，避免每次寫入紀錄都呼叫一次 Me()
+func currentUser() string {
+	userOnce.Do(func() {
+		userName = utils.GetUserName()
+		if h, ok := api().(*registry.HTTP); ok {
+			if user, err := h.Me(context.Background()); err == nil && user != nil {
+				userName = user.Name
+			}
+		}
+	})
+	return userName
+}
+
+// * 所有 registry 異動先寫入 journal 再送出，API 離線時保留待下次重送
+// * 本次異動被 registry 拒絕（例如不合法的狀態轉換）或缺少 cgo 時回傳錯誤
+func commit(e journal.Entry) error {
+	j := journal.New(journal.DefaultPath())
+	if err := j.Append(e); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	result := flushJournal(j, e.ID)
+	for _, d := range result.Dropped {
+		if d.Entry.ID == e.ID {
+			return d.Err
+		}
+	}
+	if registry.IsRequiresCgo(result.Err) {
+		return result.Err
+	}
+	return nil
+}
+
+// * own 為呼叫端自己的異動，被捨棄時由呼叫端回報
+func flushJournal(j *journal.Journal, own string) journal.Result {
+	result, err := j.Replay(context.Background(), api())
+	if err != nil {
+		fmt.Printf(Warn+"[!] failed to replay journal: %v"+Reset+"\n", err)
+		return result
+	}
+	for _, d := range result.Dropped {
+		if d.Entry.ID == own {
+			continue
+		}
+		fmt.Printf(Warn+"[!] dropped %s for %s: %v"+Reset+"\n", d.Entry.Op, d.Entry.UID, d.Err)
+	}
+	switch {
+	case result.Pending > 0 && registry.IsRequiresCgo(result.Err):
+		fmt.Printf(Warn+"[!] %v, %d change(s) queued in %s"+Reset+"\n", result.Err, result.Pending, j.Path())
+	case result.Pending > 0 && registry.IsUnauthorized(result.Err):
+		fmt.Printf(Warn+"[!] registry rejected the token (check PODRUN_TOKEN), %d change(s) queued in %s"+Reset+"\n", result.Pending, j.Path())
+	case result.Pending > 0:
+		fmt.Printf(Warn+"[!] registry unreachable, %d change(s) queued in %s"+Reset+"\n", result.Pending, j.Path())
+	}
+	return result
+}
+
+func upsertPod(d *model.Pod) error {
+	fmt.Println("[*] syncing pod info to database")
+	if d.Server != "" {
+		registerServer()
+	}
+	e := journal.NewEntry(journal.OpUpsertPod, d.UID)
+	pod := *d
+	e.Pod = &pod
+	return utils.Classify(utils.ExitRegistry, commit(e))
+}
+
+// * 只更新狀態，其餘欄位沿用 registry 的資料
+func setStatus(uid, status string) {
+	e := journal.NewEntry(journal.OpUpdatePod, uid)
+	e.Pod = &model.Pod{Status: status}
+	if status == model.StatusRemoved {
+		e.Pod.Dismiss = 1
+	}
+	if err := commit(e); err != nil {
+		fmt.Printf(Warn+"[!] failed to update registry: %v"+Reset+"\n", err)
+	}
+}
+
+// * 停在 starting 的部署（例如中途中斷）也可直接移除，registry 離線時不需先查詢目前狀態
+func removePod(uid string) {
+	setStatus(uid, model.StatusRemoved)
+}
+
+// * 標記為 failed 並記錄錯誤，回傳原本的錯誤
+func failPod(d *model.Pod, content string, cause error) error {
+	d.Status = model.StatusFailed
+	setStatus(d.UID, model.StatusFailed)
+	recordPod(d, content, nil, cause)
+	return cause
+}
+
+// * 登錄 pod 之前發生的事件（例如首次部署的 sync），登錄後再寫入
+type pendingRecord struct {
+	content string
+	payload any
+	err     error
+}
+
+func (p *PodmanArg) flushPending(d *model.Pod) {
+	for _, e := range p.pending {
+		recordPod(d, e.content, e.payload, e.err)
+	}
+	p.pending = nil
+}
+
+// * 記錄目前主機的連線資訊（不含憑證），供 reconciler 與其他成員辨識
+func registerServer() {
+	env, err := utils.CheckENV()
+	if err != nil {
+		return
+	}
+	server := &model.Server{
+		Name:    env.Name,
+		Host:    env.Host,
+		User:    env.Username,
+		BaseDir: env.BaseDir,
+	}
+	if _, port, err := net.SplitHostPort(env.Server); err == nil {
+		server.Port, _ = strconv.Atoi(port)
+	}
+	e := journal.NewEntry(journal.OpUpsertServer, "")
+	e.Server = server
+	if err := commit(e); err != nil {
+		fmt.Printf(Warn+"[!] failed to register server: %v"+Reset+"\n", err)
+	}
+}
+
+func releasePorts(uid string) {
+	if err := commit(journal.NewEntry(journal.OpReleasePorts, uid)); err != nil {
+		fmt.Printf(Warn+"[!] failed to release ports: %v"+Reset+"\n", err)
+	}
+}
+
+// * sync 的 payload
+type syncPayload struct {
+	Files []string `json:"files"`
+}
+
+// * up 的 payload
+type upPayload struct {
+	Containers []ContainerState `json:"containers"`
+}
+
+// * payload 為 nil 時不寫入；cause 不為 nil 時記錄 exit code 與錯誤訊息
+func recordPod(d *model.Pod, content string, payload any, cause error) {
+	fmt.Println("[*] add record to database")
+	e := journal.NewEntry(journal.OpInsertRecord, d.UID)
+	r := &model.Record{
+		UID:        d.UID,
+		Content:    content,
+		Hostname:   d.Hostname,
+		IP:         d.IP,
+		User:       currentUser(),
+		CreatedAt:  e.CreatedAt,
+		EventID:    e.ID,
+		Command:    invocation(),
+		ExitCode:   utils.ExitCode(cause),
+		DurationMs: time.Since(startedAt).Milliseconds(),
+		GitCommit:  gitCommit(d.LocalDir),
+	}
+	if cause != nil {
+		r.Error = cause.Error()
+	}
+	if payload != nil {
+		if data, err := json.Marshal(payload); err == nil {
+			r.Payload = data
+		}
+	}
+	e.Record = r
+	if err := commit(e); err != nil {
+		fmt.Printf(Warn+"[!] failed to add record: %v"+Reset+"\n", err)
+	}
+}
+
+// * CLI 啟動時間，紀錄的 duration 由此起算
+var startedAt = time.Now()
+
+func invocation() string {
+	return strings.TrimSpace(filepath.Base(os.Args[0]) + " " + shellJoin(os.Args[1:]))
+}
+
+// * 非 git 專案或未安裝 git 時為空字串；有未提交的異動時加上 -dirty
+// * 結果依資料夾快取於本次執行，同一指令的多筆紀錄只呼叫一次 git
+func gitCommit(dir string) string {
+	if dir == "" {
+		return ""
+	}
+
+	commitMu.Lock()
+	defer commitMu.Unlock()
+	if commit, ok := commitCache[dir]; ok {
+		return commit
+	}
+	commit := readGitCommit(dir)
+	commitCache[dir] = commit
+	return commit
+}
+
+func readGitCommit(dir string) string {
+	head, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	commit := strings.TrimSpace(string(head))
+	if status, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output(); err == nil && len(bytes.TrimSpace(status)) > 0 {
+		commit += "-dirty"
+	}
+	return commit
+}
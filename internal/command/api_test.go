@@ -0,0 +1,75 @@
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pardnchiu/go-podrun/internal/client"
+	"github.com/pardnchiu/go-podrun/internal/registry"
+)
+
+// * currentUser 只呼叫一次 Me()，後續使用快取
+func TestCurrentUserCached(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/user/me" {
+			http.NotFound(w, r)
+			return
+		}
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"id":1,"name":"alice"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	apiRegistry = registry.NewHTTP(client.New(server.URL+"/api", client.WithRetries(0, 0)))
+	apiOnce.Do(func() {})
+	t.Cleanup(func() {
+		apiRegistry, apiOnce = nil, sync.Once{}
+		userName, userOnce = "", sync.Once{}
+	})
+
+	for range 3 {
+		if got := currentUser(); got != "alice" {
+			t.Fatalf("currentUser = %q, want alice", got)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("Me called %d times, want 1", n)
+	}
+}
+
+// * gitCommit 同一資料夾只讀取一次，之後的 commit 不影響本次執行的紀錄
+func TestGitCommitCached(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	t.Cleanup(func() { commitCache = map[string]string{} })
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "first")
+
+	first := gitCommit(dir)
+	if len(first) != 40 {
+		t.Fatalf("gitCommit = %q, want a commit hash", first)
+	}
+	git("commit", "-q", "--allow-empty", "-m", "second")
+	if got := gitCommit(dir); got != first {
+		t.Fatalf("gitCommit = %q after a new commit, want cached %q", got, first)
+	}
+	if got := gitCommit(t.TempDir()); got != "" {
+		t.Fatalf("gitCommit outside a repository = %q, want empty", got)
+	}
+}
@@ -0,0 +1,152 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pardnchiu/go-podrun/internal/filesync"
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/registry"
+	"github.com/pardnchiu/go-podrun/internal/utils"
+)
+
+// * podrun clone <uid|pod_name> [dest] [--sync=rsync|native] [--server=<name>] [--no-input]
+func Clone(args []string) error {
+	var key, dest, sync, serverName string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--sync="):
+			sync = strings.TrimPrefix(arg, "--sync=")
+		case arg == "--sync" && i+1 < len(args):
+			sync = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--server="):
+			serverName = strings.TrimPrefix(arg, "--server=")
+		case arg == "--server" && i+1 < len(args):
+			serverName = args[i+1]
+			i++
+		case arg == "--no-input":
+			os.Setenv("PODRUN_NO_INPUT", "true")
+		case key == "":
+			key = arg
+		case dest == "":
+			dest = arg
+		default:
+			return utils.Classify(utils.ExitUsage, fmt.Errorf("unexpected argument: %s", arg))
+		}
+	}
+	if key == "" {
+		return utils.Classify(utils.ExitUsage, fmt.Errorf("usage: podrun clone <uid|pod_name> [dest]"))
+	}
+
+	pod, err := findPod(key)
+	if err != nil {
+		return utils.Classify(utils.ExitRegistry, err)
+	}
+
+	if dest == "" {
+		dest = filepath.Base(pod.LocalDir)
+	}
+	dest, err = filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+	if entries, err := os.ReadDir(dest); err == nil && len(entries) > 0 {
+		return fmt.Errorf("destination is not empty: %s", dest)
+	}
+
+	// * 從部署所在的主機拉回
+	if _, err := selectServer(pod, serverName); err != nil {
+		return utils.Classify(utils.ExitUsage, err)
+	}
+	env, err := utils.CheckENV()
+	if err != nil {
+		return utils.Classify(utils.ExitUsage, fmt.Errorf("missing required environment: %w", err))
+	}
+	if err := utils.SSHTest(); err != nil {
+		return fmt.Errorf("failed to connect to remote server: %w", err)
+	}
+	defer utils.SSHClose()
+
+	fmt.Printf("[*] cloning %s:%s\n", env.Host, pod.RemoteDir)
+	fmt.Printf("    into %s\n", dest)
+	fmt.Println(Hint + "──────────────────────────────────────────────────")
+	if err := pull(env.Remote, pod.RemoteDir, dest, sync); err != nil {
+		return utils.Classify(utils.ExitSync, err)
+	}
+	fmt.Println("──────────────────────────────────────────────────" + Reset)
+
+	if err := writeLink(dest, pod); err != nil {
+		return err
+	}
+
+	// * 同一個部署改由新的本機路徑管理
+	pod.LocalDir = dest
+	pod.Hostname = utils.GetHostName()
+	if ip, err := utils.GetLocalIP(); err == nil {
+		pod.IP = ip
+	}
+	if err := upsertPod(pod); err != nil {
+		return fmt.Errorf("failed to register %s: %w", dest, err)
+	}
+	recordPod(pod, "clone", nil, nil)
+
+	fmt.Printf("[*] cloned %s (%s)\n", pod.PodName, pod.UID)
+	return nil
+}
+
+// * 依 uid、pod_name 或 pod_id 尋找 registry 中的部署
+func findPod(key string) (*model.Pod, error) {
+	pods, err := api().ListPods(context.Background(), registry.PodQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var matches []model.Pod
+	for _, e := range pods {
+		if e.UID == key || e.PodName == key || e.PodID == key {
+			matches = append(matches, e)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("deployment not found: %s", key)
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, fmt.Errorf("%s matches %d deployments, use the uid instead", key, len(matches))
+}
+
+func pull(remote, remoteDir, dest, mode string) error {
+	ignore := filesync.NewIgnore(generatedFiles...)
+
+	if utils.SyncMode(mode) == "native" {
+		client, err := utils.SSHClient()
+		if err != nil {
+			return err
+		}
+		count, err := filesync.Pull(client, remoteDir, dest, ignore)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d files received\n", count)
+		return nil
+	}
+
+	rsh, err := utils.RshCommand()
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"-avz"}, ignore.RsyncFilters()...)
+	args = append(args,
+		"-e", rsh,
+		fmt.Sprintf("%s:%s/", remote, remoteDir),
+		dest+"/",
+	)
+	return utils.RsyncRun(args...)
+}
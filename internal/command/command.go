@@ -1,15 +1,27 @@
 package command
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 
+	"github.com/pardnchiu/go-podrun/internal/config"
+	"github.com/pardnchiu/go-podrun/internal/inventory"
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/registry"
 	"github.com/pardnchiu/go-podrun/internal/utils"
 )
 
+// * 記錄本機資料夾對應的部署，由 clone 建立
+const linkFile = ".podrun"
+
 func New() (*PodmanArg, error) {
 	if len(os.Args) < 2 {
 		return nil, fmt.Errorf("[x] podrun <command> [args...]")
@@ -17,16 +29,8 @@ func New() (*PodmanArg, error) {
 
 	command := os.Args[1]
 	switch command {
-	case "info":
-		fmt.Println("show project info")
-	case "export":
-		fmt.Println("export project to pod manifest")
 	case "deploy":
 		fmt.Println("deploy project to kubernetes")
-	case "clone":
-		fmt.Println("clone project to local")
-	case "domain":
-		fmt.Println("set domain to pod")
 	}
 
 	args, err := parseArgs(os.Args[1:])
@@ -34,30 +38,155 @@ func New() (*PodmanArg, error) {
 		return nil, fmt.Errorf("[x] %v", err)
 	}
 
+	if args.Target != "podman" && args.Target != "k3s" {
+		return nil, fmt.Errorf("[x] unsupported type: %s (podman or k3s)", args.Target)
+	}
+
 	if len(args.RemoteArgs) == 0 {
 		return nil, fmt.Errorf("[x] please ensure docker compose <command> [args...] is valid first before running podrun")
 	}
 
-	localDir, err := getLocalDir(args.LocalDir)
+	// * 以 -u 指定其他部署時（info、ps、logs 等），不需要目前資料夾有 compose 檔
+	explicit := args.UID != ""
+	localDir, err := getLocalDir(args.LocalDir, !explicit || usesLocalProject(args.RemoteArgs[0]))
 	if err != nil {
 		return nil, fmt.Errorf("[x] %v", err)
 	}
 	args.LocalDir = localDir
 
-	uid, remoteDir, err := setRemoteDir(localDir)
+	// * --folder / -f 指向其他專案時改用該專案的 podrun.yaml
+	if err := config.Apply(localDir); err != nil {
+		return nil, fmt.Errorf("[x] %v", err)
+	}
+	// * 寫入環境變數，讓憑證提示與 rsync 的 __rsh 子程序也不詢問
+	if args.NoInput {
+		os.Setenv("PODRUN_NO_INPUT", "true")
+	}
+	if _, err := utils.OverwritePolicy(args.Overwrite); err != nil {
+		return nil, fmt.Errorf("[x] %v", err)
+	}
+
+	// * clone 下來的資料夾沿用原部署的 uid 與遠端資料夾；-u 指定時不套用
+	var link *link
+	if !explicit {
+		args.UID = projectUID(localDir)
+		if link, err = readLink(localDir); err == nil {
+			args.UID = link.UID
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("[x] %v", err)
+		}
+	}
+
+	registered, err := args.lookup()
+	if err != nil {
+		return nil, err
+	}
+	server, err := selectServer(registered, args.Server)
 	if err != nil {
 		return nil, fmt.Errorf("[x] %v", err)
 	}
-	args.RemoteDir = remoteDir
 
-	if args.UID == "" {
-		args.UID = uid
+	// * 未設定任何主機時（export、history）沿用預設根目錄，連線時才回報缺少設定
+	base := inventory.DefaultBaseDir
+	if server != nil {
+		args.Server = server.Name
+		base = server.RemoteBase()
+	}
+	switch {
+	case link != nil:
+		args.RemoteDir = link.RemoteDir
+	case registered != nil && registered.RemoteDir != "":
+		args.RemoteDir = registered.RemoteDir
+	default:
+		args.RemoteDir = remoteDir(base, localDir)
 	}
 
 	return args, nil
 }
 
-func getLocalDir(folder string) (string, error) {
+// * 查詢部署在 registry 的紀錄；不存在時回傳 nil
+// * export、sync --list 只在本機執行，不查詢 registry
+// * registry 無法連線時改用 PODRUN_SERVER、inventory 的 default 或唯一的主機，讓 journal 保留異動待重送
+func (p *PodmanArg) lookup() (*model.Pod, error) {
+	command := p.RemoteArgs[0]
+	if command == "export" || (command == "sync" && slices.Contains(p.RemoteArgs[1:], "--list")) {
+		return nil, nil
+	}
+
+	registered, err := api().GetPod(context.Background(), p.UID)
+	switch {
+	case err == nil:
+		return registered, nil
+	case registry.IsNotFound(err):
+		return nil, nil
+	case p.Server != "":
+		fmt.Printf(Warn+"[!] failed to look up deployment in registry, using --server %s: %v"+Reset+"\n", p.Server, err)
+		return nil, nil
+	case command == "history" || command == "info":
+		// * 之後讀取 registry 時回報錯誤
+		return nil, nil
+	case registry.IsUnavailable(err):
+		server, ferr := fallbackServer()
+		if ferr != nil {
+			return nil, utils.Classify(utils.ExitRegistry, fmt.Errorf("[x] registry is unreachable and %v: %w", ferr, err))
+		}
+		if server != nil {
+			p.Server = server.Name
+			fmt.Printf(Warn+"[!] registry is unreachable, using server %s: %v"+Reset+"\n", server.Name, err)
+		}
+		return nil, nil
+	}
+	return nil, utils.Classify(utils.ExitRegistry, fmt.Errorf("[x] failed to look up deployment %s in registry (pass --server to continue): %w", p.UID, err))
+}
+
+// * 依序為 PODRUN_SERVER、inventory 的 default、inventory 中唯一的主機；有多台可選時需指定 --server
+func fallbackServer() (*inventory.Server, error) {
+	server, err := utils.ResolveServer("")
+	if !errors.Is(err, utils.ErrNoServer) {
+		return server, err
+	}
+	inv, err := inventory.Load(inventory.DefaultPath())
+	if err != nil {
+		return nil, err
+	}
+	switch names := inv.Names(); len(names) {
+	case 0:
+		return nil, nil
+	case 1:
+		return utils.ResolveServer(names[0])
+	default:
+		return nil, fmt.Errorf("%d servers are configured (pass --server to choose one)", len(names))
+	}
+}
+
+// * 已登錄的部署固定使用 registry 記錄的主機，避免 down / info 連到其他主機
+// * 其次為 --server，再來是 PODRUN_SERVER 與 inventory 的 default
+func selectServer(registered *model.Pod, flag string) (*inventory.Server, error) {
+	name := flag
+	if registered != nil && registered.Server != "" {
+		if flag != "" && flag != registered.Server {
+			return nil, fmt.Errorf("deployment %s is registered on server %s, not %s (run podrun clear first to move it)", registered.UID, registered.Server, flag)
+		}
+		name = registered.Server
+	}
+
+	server, err := utils.ResolveServer(name)
+	if errors.Is(err, utils.ErrNoServer) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	utils.UseServer(server)
+	return server, nil
+}
+
+// * 需要讀取本機 compose 檔或同步本機檔案的指令
+func usesLocalProject(command string) bool {
+	return command == "up" || command == "export" || command == "sync"
+}
+
+func getLocalDir(folder string, requireCompose bool) (string, error) {
 	var err error
 	newFolder := folder
 	if newFolder == "" {
@@ -73,21 +202,53 @@ func getLocalDir(folder string) (string, error) {
 	if !utils.IsDir(absPath) {
 		return "", fmt.Errorf("folder does not exist: %s", absPath)
 	}
-	if !utils.FileExist(filepath.Join(absPath, "docker-compose.yml")) &&
+	if requireCompose &&
+		!utils.FileExist(filepath.Join(absPath, "docker-compose.yml")) &&
 		!utils.FileExist(filepath.Join(absPath, "docker-compose.yaml")) {
 		return "", fmt.Errorf("docker-compose.yml not found in folder: %s", absPath)
 	}
 	return absPath, nil
 }
 
-func setRemoteDir(localFolder string) (string, string, error) {
+func projectUID(localFolder string) string {
 	mac, err := utils.GetMAC()
 	if err != nil {
 		mac, _ = os.Hostname()
 	}
 	hash := md5.Sum(fmt.Appendf(nil, "%s@%s", mac, localFolder))
-	return hex.EncodeToString(hash[:]),
-		filepath.Join("/home/podrun",
-			fmt.Sprintf("%s_%s", filepath.Base(localFolder), hex.EncodeToString(hash[:])[:8])),
-		nil
+	return hex.EncodeToString(hash[:])
+}
+
+// * <base_dir>/<folder>_<uid 前 8 碼>
+func remoteDir(base, localFolder string) string {
+	return path.Join(base,
+		fmt.Sprintf("%s_%s", filepath.Base(localFolder), projectUID(localFolder)[:8]))
+}
+
+type link struct {
+	UID       string `json:"uid"`
+	RemoteDir string `json:"remote_dir"`
+}
+
+func readLink(dir string) (*link, error) {
+	data, err := os.ReadFile(filepath.Join(dir, linkFile))
+	if err != nil {
+		return nil, err
+	}
+	var l link
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", linkFile, err)
+	}
+	if l.UID == "" || l.RemoteDir == "" {
+		return nil, fmt.Errorf("invalid %s: uid and remote_dir are required", linkFile)
+	}
+	return &l, nil
+}
+
+func writeLink(dir string, d *model.Pod) error {
+	data, err := json.MarshalIndent(&link{UID: d.UID, RemoteDir: d.RemoteDir}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, linkFile), append(data, '\n'), 0644)
 }
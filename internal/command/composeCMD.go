@@ -1,15 +1,17 @@
 package command
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log/slog"
-	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 
+	"github.com/pardnchiu/go-podrun/internal/compose"
 	"github.com/pardnchiu/go-podrun/internal/model"
 	"github.com/pardnchiu/go-podrun/internal/utils"
 )
@@ -22,6 +24,8 @@ const (
 	Warn  = "\033[33m"
 )
 
+var errCancelled = utils.Classify(utils.ExitCancelled, errors.New("cancelled"))
+
 func (p *PodmanArg) ComposeCMD() (*model.Pod, error) {
 	d := &model.Pod{
 		UID:       p.UID,
@@ -31,12 +35,19 @@ func (p *PodmanArg) ComposeCMD() (*model.Pod, error) {
 		RemoteDir: p.RemoteDir,
 		Target:    p.Target,
 		File:      p.File,
-		Status:    "starting",
+		Status:    model.StatusStarting,
 		Hostname:  p.Hostname,
 		IP:        p.IP,
+		Owner:     p.User,
+		Server:    p.Server,
 		Replicas:  1,
 	}
 
+	if p.Target == "k3s" {
+		return p.k3sCMD(d)
+	}
+	d.Project = p.projectName()
+
 	switch p.Command {
 	case "up":
 		return p.up(d)
@@ -51,21 +62,32 @@ func (p *PodmanArg) ComposeCMD() (*model.Pod, error) {
 func (p *PodmanArg) up(d *model.Pod) (*model.Pod, error) {
 	fmt.Println("[+] create folder if not exist")
 	if err := utils.SSHRun("mkdir", "-p", p.RemoteDir); err != nil {
-		return nil, err
+		return nil, p.failUp(d, err)
 	}
 
 	// * 同步檔案夾資料
 	fmt.Println("[*] syncing files")
 	if err := p.RsyncToRemote(d); err != nil {
-		return nil, err
+		if errors.Is(err, errCancelled) {
+			return nil, err
+		}
+		return nil, p.failUp(d, utils.Classify(utils.ExitSync, err))
 	}
 	fmt.Println("──────────────────────────────────────────────────" + Reset)
 
+	// * 同步完成後登錄為 starting，首次部署的 sync 紀錄在此之後寫入
+	d.Status = model.StatusStarting
+	if err := upsertPod(d); err != nil {
+		return nil, fmt.Errorf("[x] failed to upsert pod: %w", err)
+	}
+	p.flushPending(d)
+
 	// * 調整 docker-compose.yml 內容
-	fmt.Println("[*] modifying compose file (remove ports)")
+	fmt.Println("[*] modifying compose file (assign ports)")
 	if err := p.ModifyComposeFile(); err != nil {
-		return nil, fmt.Errorf("[x] failed to modify compose file: %w", err)
+		return nil, failPod(d, "up", fmt.Errorf("[x] failed to modify compose file: %w", err))
 	}
+	p.flushPending(d)
 
 	// * 關閉舊的容器 (if exists)
 	fmt.Println("[*] cleaning up old containers")
@@ -73,32 +95,38 @@ func (p *PodmanArg) up(d *model.Pod) (*model.Pod, error) {
 		"cd '%s' && podman compose -f docker-compose.podrun.yml down -v >/dev/null 2>&1",
 		p.RemoteDir,
 	))
-	removePod(d.UID)
+	p.checkHostPorts()
 
-	// * 執行動作
-	fmt.Printf("[*] executing: podman compose -f docker-compose.podrun.yml %s\n", strings.Join(p.RemoteArgs, " "))
-	fmt.Println(Hint + "──────────────────────────────────────────────────")
-	remoteCmd := fmt.Sprintf("cd '%s' && podman compose -f docker-compose.podrun.yml %s 2>&1", p.RemoteDir, shellJoin(p.RemoteArgs))
+	// * 一律以 detach 啟動，確認容器運行後才標記為 running
+	upArgs := p.RemoteArgs
 	if !p.Detach {
-		remoteCmd = fmt.Sprintf(`
-				cleanup() {
-					echo "[*] stopping containers"
-					cd '%s' && podman compose -f docker-compose.podrun.yml down
-				}
-				trap cleanup INT TERM
-				%s
-			`, p.RemoteDir, remoteCmd)
+		upArgs = append([]string{upArgs[0], "-d"}, upArgs[1:]...)
 	}
-	if err := utils.SSHRun(remoteCmd); err != nil {
-		return nil, err
+	fmt.Printf("[*] executing: podman compose -f docker-compose.podrun.yml %s\n", strings.Join(upArgs, " "))
+	fmt.Println(Hint + "──────────────────────────────────────────────────")
+	if err := utils.SSHRun(fmt.Sprintf(
+		"cd '%s' && podman compose -f docker-compose.podrun.yml %s 2>&1",
+		p.RemoteDir, shellJoin(upArgs),
+	)); err != nil {
+		return nil, failPod(d, "up", err)
 	}
 	fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
 
+	containers, err := p.podmanContainers(nil)
+	if err != nil {
+		return nil, failPod(d, "up", fmt.Errorf("failed to inspect containers: %w", err))
+	}
+	if len(containers) == 0 {
+		return nil, failPod(d, "up", fmt.Errorf("no containers started"))
+	}
+	if stopped := notRunning(containers); len(stopped) > 0 {
+		return nil, failPod(d, "up", fmt.Errorf("containers not running: %s", strings.Join(stopped, ", ")))
+	}
+
 	// * 取得 Pod 資訊
-	projectName := filepath.Base(p.RemoteDir)
 	podInfo, err := utils.SSEOutput(fmt.Sprintf(
 		"podman pod ps --filter 'name=pod_%s' --format '{{.ID}}\t{{.Name}}'",
-		projectName,
+		d.ProjectName(),
 	))
 	if err == nil && podInfo != "" {
 		parts := strings.Split(strings.TrimSpace(podInfo), "\t")
@@ -109,29 +137,112 @@ func (p *PodmanArg) up(d *model.Pod) (*model.Pod, error) {
 	}
 
 	// * 輸出結果
-	if p.Detach {
-		fmt.Println("[*] service ports:")
-		fmt.Println(Ok + "──────────────────────────────────────────────────")
-		output, _ := utils.SSEOutput(fmt.Sprintf(
-			"cd '%s' && podman ps --filter 'label=io.podman.compose.project=%s' --format 'table {{.Names}}\t{{.Ports}}'",
-			p.RemoteDir,
-			projectName),
-		)
-		fmt.Println(output)
-		fmt.Printf("Pod ID: %s\n", d.PodID)
-		fmt.Printf("Pod Name: %s\n", d.PodName)
-		fmt.Printf("Hostname: %s\n", d.Hostname)
-		fmt.Printf("IP: %s\n", d.IP)
-		fmt.Println("──────────────────────────────────────────────────" + Reset)
-	}
+	fmt.Println("[*] service ports:")
+	fmt.Println(Ok + "──────────────────────────────────────────────────")
+	output, _ := utils.SSEOutput(fmt.Sprintf(
+		"cd '%s' && podman ps --filter 'label=io.podman.compose.project=%s' --format 'table {{.Names}}\t{{.Ports}}'",
+		p.RemoteDir,
+		d.ProjectName()),
+	)
+	fmt.Println(output)
+	fmt.Printf("Pod ID: %s\n", d.PodID)
+	fmt.Printf("Pod Name: %s\n", d.PodName)
+	fmt.Printf("Hostname: %s\n", d.Hostname)
+	fmt.Printf("IP: %s\n", d.IP)
+	fmt.Println("──────────────────────────────────────────────────" + Reset)
 
 	// *  發送 Pod 資訊到 API
+	d.Status = model.StatusRunning
 	if err := upsertPod(d); err != nil {
 		return nil, fmt.Errorf("[x] failed to upsert pod: %w", err)
 	}
-	recordPod(d, "up")
+	recordPod(d, "up", upPayload{Containers: containers}, nil)
 
-	return d, nil
+	if p.Detach {
+		return d, nil
+	}
+
+	// * 非 detach：跟隨 log，中斷後停止容器，與前景執行 podman compose up 相同
+	fmt.Println(Hint + "──────────────────────────────────────────────────")
+	p.follow()
+	fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
+
+	return d, p.settle(d)
+}
+
+// * 有 PTY 時 Ctrl+C 送到遠端由 trap 停止容器；
+// * 沒有 PTY 時 Ctrl+C 只會送到本機，改由本機另開 session 執行 down，logs -f 隨容器停止而結束
+func (p *PodmanArg) follow() {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	// * 等待本機的 down 完成後才回傳，避免 settle 看到尚未停止的容器
+	done, stopped := make(chan struct{}), make(chan struct{})
+	defer func() {
+		close(done)
+		<-stopped
+	}()
+	go func() {
+		defer close(stopped)
+		select {
+		case <-interrupt:
+			fmt.Println("[*] stopping containers")
+			_ = utils.SSHRun(fmt.Sprintf(
+				"cd '%s' && podman compose -f docker-compose.podrun.yml down 2>&1",
+				p.RemoteDir,
+			))
+		case <-done:
+		}
+	}()
+
+	_ = utils.SSHInteractive(fmt.Sprintf(`
+				cleanup() {
+					echo "[*] stopping containers"
+					cd '%s' && podman compose -f docker-compose.podrun.yml down
+					exit 130
+				}
+				trap cleanup INT TERM HUP
+				cd '%s' && podman compose -f docker-compose.podrun.yml logs -f 2>&1
+			`, p.RemoteDir, p.RemoteDir))
+}
+
+// * 同步前（尚未登錄為 starting）的錯誤：依狀態機先登錄為 starting 再標記 failed，讓失敗的部署也留下紀錄
+func (p *PodmanArg) failUp(d *model.Pod, cause error) error {
+	d.Status = model.StatusStarting
+	if err := upsertPod(d); err != nil {
+		fmt.Printf(Warn+"[!] failed to update registry: %v"+Reset+"\n", err)
+	}
+	p.flushPending(d)
+	return failPod(d, "up", cause)
+}
+
+// * 前景模式結束後依實際狀態更新：已移除為 removed，仍有停止的容器為 failed
+func (p *PodmanArg) settle(d *model.Pod) error {
+	containers, err := p.podmanContainers(nil)
+	if err != nil {
+		return nil
+	}
+	if len(containers) == 0 {
+		removePod(d.UID)
+		recordPod(d, "down", nil, nil)
+		return nil
+	}
+	if stopped := notRunning(containers); len(stopped) > 0 {
+		return failPod(d, "up", fmt.Errorf("containers exited: %s", strings.Join(stopped, ", ")))
+	}
+	return nil
+}
+
+// * podman 的 running 與 k3s 的 Running phase
+func notRunning(containers []ContainerState) []string {
+	var stopped []string
+	for _, e := range containers {
+		if e.State != "running" {
+			stopped = append(stopped, fmt.Sprintf("%s (%s)", e.Name, e.State))
+		}
+	}
+	return stopped
 }
 
 func (p *PodmanArg) clear(d *model.Pod) (*model.Pod, error) {
@@ -142,10 +253,10 @@ func (p *PodmanArg) clear(d *model.Pod) (*model.Pod, error) {
 		"cd '%s' && podman compose -f docker-compose.podrun.yml down -v 2>&1 | grep -v 'no container\\|no pod' || true",
 		p.RemoteDir,
 	)
-	removePod(d.UID)
 	if err := utils.SSHRun(downCmd); err != nil {
-		return nil, fmt.Errorf("failed to remove containers: %w", err)
+		return nil, failPod(d, "clear", fmt.Errorf("failed to remove containers: %w", err))
 	}
+	releasePorts(d.UID)
 	fmt.Println("──────────────────────────────────────────────────" + Reset)
 
 	// * 移除映像
@@ -156,7 +267,7 @@ func (p *PodmanArg) clear(d *model.Pod) (*model.Pod, error) {
 		p.RemoteDir,
 	)
 	if err := utils.SSHRun(imageCmd); err != nil {
-		return nil, fmt.Errorf("failed to remove images: %w", err)
+		return nil, failPod(d, "clear", fmt.Errorf("failed to remove images: %w", err))
 	}
 	fmt.Println("──────────────────────────────────────────────────" + Reset)
 
@@ -169,271 +280,207 @@ func (p *PodmanArg) clear(d *model.Pod) (*model.Pod, error) {
 		filepath.Base(p.RemoteDir),
 	)
 	if err := utils.SSHRun(removeCmd); err != nil {
-		return nil, fmt.Errorf("failed to remove folder: %w", err)
+		return nil, failPod(d, "clear", fmt.Errorf("failed to remove folder: %w", err))
 	}
 	fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
 
-	recordPod(d, "clear")
+	// * 全部完成才標記為 removed，中途失敗為 failed
+	removePod(d.UID)
+	recordPod(d, "clear", nil, nil)
 	return d, nil
 }
 
+// * 失敗時將部署標記為 failed 的指令
+var mutating = map[string]bool{
+	"down":    true,
+	"restart": true,
+	"build":   true,
+}
+
+// * 需要接上 stdin 的指令
+var interactive = map[string]bool{
+	"logs": true,
+	"exec": true,
+}
+
 func (p *PodmanArg) runCMD(d *model.Pod) (*model.Pod, error) {
 	fmt.Printf("[*] executing: podman compose -f docker-compose.podrun.yml %s\n", strings.Join(p.RemoteArgs, " "))
 	fmt.Println(Hint + "──────────────────────────────────────────────────")
-	if err := utils.SSHRun(fmt.Sprintf(
+	run := utils.SSHRun
+	if interactive[p.Command] {
+		run = utils.SSHInteractive
+	}
+	err := run(fmt.Sprintf(
 		"cd '%s' && podman compose %s",
 		p.RemoteDir,
 		shellJoin(p.RemoteArgs)),
-	); err != nil {
-		return nil, err
-	}
+	)
 	fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
 
-	if p.Command == "down" {
+	switch {
+	case err == nil && p.Command == "down":
 		removePod(d.UID)
+	case err != nil && mutating[p.Command]:
+		return nil, failPod(d, p.Command, err)
+	}
+	// * ps / logs / exec 的錯誤不代表部署失敗，只寫入紀錄
+	recordPod(d, p.Command, nil, err)
+	if err != nil {
+		return nil, err
 	}
-	recordPod(d, p.Command)
 	return d, nil
 }
 
 func (p *PodmanArg) RsyncToRemote(d *model.Pod) error {
+	switch mode := utils.SyncMode(p.Sync); mode {
+	case "native":
+		return p.nativeSync(d)
+	case "rsync":
+		if _, err := exec.LookPath("rsync"); err != nil {
+			return fmt.Errorf("rsync not found, use --sync=native instead")
+		}
+	}
+
 	env, err := utils.CheckENV()
 	if err != nil {
 		return err
 	}
 
-	checkDirCmd := fmt.Sprintf("[ -d %s ] && [ -n \"$(ls -A %s)\" ] && echo 'not-empty' || echo 'empty'",
-		p.RemoteDir, p.RemoteDir)
-	checkDirArgs := []string{
-		"-p", env.Password,
-		"ssh", "-o", "StrictHostKeyChecking=no",
-		env.Remote,
-		checkDirCmd,
-	}
-	output, err := utils.CMDOutput("sshpass", checkDirArgs...)
+	output, err := utils.SSEOutput(fmt.Sprintf(
+		"[ -d %s ] && [ -n \"$(ls -A %s)\" ] && echo 'not-empty' || echo 'empty'",
+		p.RemoteDir, p.RemoteDir,
+	))
 	if err != nil {
 		return fmt.Errorf("check remote directory failed: %w", err)
 	}
 	isRemoteEmpty := strings.TrimSpace(output) == "empty"
 
-	excludes := []string{
-		"--exclude=node_modules/", "--exclude=vendor/", "--exclude=__pycache__/",
-		"--exclude=*.pyc", "--exclude=.venv/", "--exclude=venv/", "--exclude=env/",
-		"--exclude=.env.local", "--exclude=.git/", "--exclude=.gitignore",
-		"--exclude=*.log", "--exclude=.DS_Store", "--exclude=Thumbs.db",
-		"--exclude=.next/",
-		"--exclude=docker-compose.podrun.yml",
-		"--exclude=app/package-lock.json", "--exclude=app/package-lock.json",
+	ignore, err := syncIgnore(p.LocalDir)
+	if err != nil {
+		return err
+	}
+	// * 預覽與實際同步使用相同的規則
+	filters := ignore.RsyncFilters()
+
+	rsh, err := utils.RshCommand()
+	if err != nil {
+		return err
 	}
 
 	baseArgs := []string{
-		"-e", "ssh -o StrictHostKeyChecking=no",
+		"-e", rsh,
 		p.LocalDir + "/",
 		fmt.Sprintf("%s:%s/", env.Remote, p.RemoteDir),
 	}
 
+	// * 預覽一律執行，異動清單寫入紀錄的 payload
+	checkArgs := []string{
+		"-avni",
+		"--delete",
+	}
+	checkArgs = append(checkArgs, filters...)
+	checkArgs = append(checkArgs, baseArgs...)
+	output, err = utils.RsyncOutput(checkArgs...)
+	if err != nil {
+		return fmt.Errorf("preview failed: %w", err)
+	}
+	files := rsyncChanges(output)
+
+	// * 遠端已有檔案且無異動時不留紀錄
+	action := "sync"
 	if !isRemoteEmpty {
 		fmt.Println("[*] checking changes")
 		fmt.Println(Hint + "──────────────────────────────────────────────────")
-		checkArgs := []string{
-			"-p", env.Password,
-			"rsync",
-			"-avni",
-			"--delete",
-		}
-		checkArgs = append(checkArgs, excludes...)
-		checkArgs = append(checkArgs, baseArgs...)
-		output, err = utils.CMDOutput("sshpass", checkArgs...)
-		if err != nil {
-			return fmt.Errorf("preview failed: %w", err)
-		}
 		fmt.Print(output)
 		fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
 
+		action = ""
 		if changeExist(output) {
-			fmt.Print("[!] confirm sync? (y/N): ")
-			var confirm string
-			fmt.Scanln(&confirm)
-			if confirm != "y" && confirm != "Y" {
-				return fmt.Errorf("cancelled")
+			if err := p.confirmOverwrite(); err != nil {
+				return err
 			}
-			recordPod(d, "overwrite")
+			action = "overwrite"
 		}
-	} else {
-		recordPod(d, "sync")
-	}
-
-	excludes = []string{
-		"--exclude=node_modules/", "--exclude=vendor/", "--exclude=__pycache__/",
-		"--exclude=*.pyc", "--exclude=.venv/", "--exclude=venv/", "--exclude=env/",
-		"--exclude=.env.local", "--exclude=.git/", "--exclude=.gitignore",
-		"--exclude=*.log", "--exclude=.DS_Store", "--exclude=Thumbs.db",
-		"--exclude=.next/", "--exclude=app/package-lock.json",
 	}
 
 	fmt.Println("[*] syncing")
 	fmt.Println(Hint + "──────────────────────────────────────────────────")
 	syncArgs := []string{
-		"-p", env.Password,
-		"rsync",
 		"-avz",
 		"--delete",
 	}
-	syncArgs = append(syncArgs, excludes...)
+	syncArgs = append(syncArgs, filters...)
 	syncArgs = append(syncArgs, baseArgs...)
-	return utils.CMDRun("sshpass", syncArgs...)
-}
-
-func (p *PodmanArg) ModifyComposeFile() error {
-	var composeFile string
-
-	output, _ := utils.SSEOutput(fmt.Sprintf(
-		"test -f '%s/docker-compose.yml' && echo 'yml' || echo 'notfound'",
-		p.RemoteDir,
-	))
-	if strings.TrimSpace(output) == "yml" {
-		composeFile = "docker-compose.yml"
-	} else {
-		output, _ = utils.SSEOutput(fmt.Sprintf(
-			"test -f '%s/docker-compose.yaml' && echo 'yaml' || echo 'notfound'",
-			p.RemoteDir,
-		))
-		if strings.TrimSpace(output) == "yaml" {
-			composeFile = "docker-compose.yaml"
-		} else {
-			return fmt.Errorf("docker-compose.yml or docker-compose.yaml not found")
-		}
-	}
-
-	podrunFile := "docker-compose.podrun.yml"
-	copyCmd := fmt.Sprintf(
-		"cp '%s/%s' '%s/%s'",
-		p.RemoteDir, composeFile,
-		p.RemoteDir, podrunFile,
-	)
-	if err := utils.SSHRun(copyCmd); err != nil {
-		return err
-	}
-
-	// 移除 ports
-	sedCmds := []string{
-		`sed -i -E 's/(["\x27]?)[0-9]+:([0-9]+)(["\x27]?)/\1\2\3/g' '%s/%s'`,
-		`sed -i -E 's/(["\x27]?)\$\{[^}]+\}:([0-9]+)(["\x27]?)/\1\2\3/g' '%s/%s'`,
-		`sed -i -E 's/\$\{[^}]+:[?][^}]+\}://g' '%s/%s'`,
-	}
-
-	for _, cmdTemplate := range sedCmds {
-		cmd := fmt.Sprintf(cmdTemplate, p.RemoteDir, podrunFile)
-		if err := utils.SSHRun(cmd); err != nil {
-			return err
-		}
+	err = utils.RsyncRun(syncArgs...)
+	if action != "" {
+		p.pending = append(p.pending, pendingRecord{action, syncPayload{Files: files}, err})
 	}
-
-	// 強制為所有相對路徑 volume 加入 :z（如果沒有）
-	awkCmd := fmt.Sprintf(`
-		awk '
-		/^\s+- \.\/[^:]+:[^:]+$/ { print $0 ":z"; next }
-		/^\s+- \.\/[^:]+:[^:]+:[^z]*$/ { gsub(/:([^z:]+)$/, ":\\1,z"); print; next }
-		/^\s+- \.\/[^:]+:[^:]+:.*z/ { print; next }
-		{ print }
-		' '%s/%s' > '%s/%s.tmp' && mv '%s/%s.tmp' '%s/%s'
-	`, p.RemoteDir, podrunFile, p.RemoteDir, podrunFile, p.RemoteDir, podrunFile, p.RemoteDir, podrunFile)
-
-	return utils.SSHRun(awkCmd)
+	return err
 }
 
-func shellJoin(args []string) string {
-	escaped := make([]string, len(args))
-	for i, arg := range args {
-		if strings.ContainsAny(arg, " \t\n'\"\\$`") {
-			escaped[i] = "'" + strings.ReplaceAll(arg, "'", "'\"'\"'") + "'"
-		} else {
-			escaped[i] = arg
+// * podman-compose 的 project 名稱：compose 頂層 name:，未設定時為遠端資料夾名稱
+func (p *PodmanArg) projectName() string {
+	if path, err := compose.Find(p.LocalDir, p.File); err == nil {
+		if project, err := compose.Load(path); err == nil && project.DeclaredName() != "" {
+			return project.DeclaredName()
 		}
 	}
-	return strings.Join(escaped, " ")
+	return filepath.Base(p.RemoteDir)
 }
 
-func upsertPod(d *model.Pod) error {
-	fmt.Println("[*] syncing pod info to database")
-	jsonData, err := json.Marshal(d)
+func (p *PodmanArg) ModifyComposeFile() error {
+	path, err := compose.Find(p.LocalDir, p.File)
 	if err != nil {
 		return err
 	}
 
-	resp, err := http.Post(
-		"http://localhost:8080/pod/upsert",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	project, err := compose.Load(path)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("status %d, body: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
-}
-
-func removePod(uid string) {
-	jsonData, err := json.Marshal(&model.Pod{
-		Dismiss: 1,
-	})
-	// * slience, if wrong, just wrong
-	if err != nil {
-		return
-	}
 
-	resp, err := http.Post(
-		"http://localhost:8080/pod/update/"+uid,
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	// * slience, if wrong, just wrong
-	if err != nil {
-		return
+	// * 由 registry 分配固定 host port，未分配的 port 交給 podman 隨機分配；host_ip 保留，避免原本只綁定本機的服務對外開放
+	allocated := p.allocatePorts(project)
+	seq := portSeq{}
+	if err := project.RewritePorts(func(service string, port compose.Port) compose.Port {
+		port.Published = ""
+		if n := port.TargetNumber(); n > 0 && strconv.Itoa(n) == port.Target {
+			if hostPort, ok := allocated[portKey(service, n, port.Protocol, seq.next(service, n, port.Protocol))]; ok {
+				port.Published = strconv.Itoa(hostPort)
+			}
+		}
+		return port
+	}); err != nil {
+		return err
 	}
-	defer resp.Body.Close()
 
-	// * slience, if wrong, just wrong
-	if resp.StatusCode != http.StatusOK {
-		return
+	// * 強制為所有相對路徑 volume 加入 :z（如果沒有）
+	if err := project.RelabelVolumes(); err != nil {
+		return err
 	}
-}
 
-func recordPod(d *model.Pod, content string) error {
-	fmt.Println("[*] add record to database")
-	jsonData, err := json.Marshal(&model.Record{
-		UID:      d.UID,
-		Content:  content,
-		Hostname: d.Hostname,
-		IP:       d.IP,
-	})
-	slog.Info("", "data", jsonData)
-	if err != nil {
+	// * 注入 domain routing labels
+	if err := p.applyDomains(project); err != nil {
 		return err
 	}
 
-	resp, err := http.Post(
-		"http://localhost:8080/pod/record/insert",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	data, err := project.Marshal()
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	return utils.SSHWrite(filepath.Join(p.RemoteDir, compose.PodrunFile), data)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("status %d, body: %s", resp.StatusCode, string(body))
+func shellJoin(args []string) string {
+	escaped := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t\n'\"\\$`") {
+			escaped[i] = "'" + strings.ReplaceAll(arg, "'", "'\"'\"'") + "'"
+		} else {
+			escaped[i] = arg
+		}
 	}
-
-	return nil
+	return strings.Join(escaped, " ")
 }
 
 // * exmaple
@@ -444,6 +491,26 @@ func recordPod(d *model.Pod, content string) error {
 // sent 1,009 bytes  received 25 bytes  2,068.00 bytes/sec
 // total size is 32,245  speedup is 31.18 (DRY RUN)
 // ──────────────────────────────────────────────────
+// * 將 rsync -i 的輸出轉為與 native 相同的 + / ~ / - 清單，略過僅屬性變動的項目
+func rsyncChanges(output string) []string {
+	files := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		if rest, ok := strings.CutPrefix(line, "*deleting"); ok {
+			files = append(files, "- "+strings.TrimSpace(rest))
+			continue
+		}
+		if len(line) < 13 || line[11] != ' ' || !strings.ContainsRune("<>ch", rune(line[0])) {
+			continue
+		}
+		mark := "~"
+		if strings.Contains(line[2:11], "+") {
+			mark = "+"
+		}
+		files = append(files, mark+" "+line[12:])
+	}
+	return files
+}
+
 func changeExist(output string) bool {
 	lines := strings.Split(output, "\n")
 	for _, line := range lines {
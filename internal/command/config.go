@@ -0,0 +1,116 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pardnchiu/go-podrun/internal/config"
+)
+
+// * podrun config show [--origin] | get <key> [--origin] | set <key> <value> [--project]
+func Config(args []string) error {
+	origin, project := false, false
+	var rest []string
+	for _, arg := range args {
+		switch arg {
+		case "--origin":
+			origin = true
+		case "--project":
+			project = true
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	action := "show"
+	if len(rest) > 0 {
+		action, rest = rest[0], rest[1:]
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("[x] %w", err)
+	}
+
+	switch action {
+	case "show":
+		values, err := config.Resolve(dir)
+		if err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if origin {
+			fmt.Fprintln(w, "KEY\tVALUE\tORIGIN")
+		} else {
+			fmt.Fprintln(w, "KEY\tVALUE")
+		}
+		for _, v := range values {
+			value := orDash(v.Value)
+			if v.Key.Secret && v.Value != "" {
+				value = "********"
+			}
+			if origin {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key.Name, value, v.Origin())
+			} else {
+				fmt.Fprintf(w, "%s\t%s\n", v.Key.Name, value)
+			}
+		}
+		return w.Flush()
+
+	case "get":
+		if len(rest) != 1 {
+			return fmt.Errorf("[x] podrun config get <key> [--origin]")
+		}
+		k, ok := config.Lookup(rest[0])
+		if !ok {
+			return fmt.Errorf("[x] unknown key: %s (see podrun config show)", rest[0])
+		}
+		values, err := config.Resolve(dir)
+		if err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		for _, v := range values {
+			if v.Key.Name != k.Name {
+				continue
+			}
+			if origin {
+				fmt.Printf("%s\t%s\n", v.Value, v.Origin())
+			} else {
+				fmt.Println(v.Value)
+			}
+		}
+		return nil
+
+	case "set":
+		if len(rest) != 2 {
+			return fmt.Errorf("[x] podrun config set <key> <value> [--project]")
+		}
+		var path string
+		if project {
+			path, err = config.SetProject(dir, rest[0], rest[1])
+		} else {
+			path, err = config.SetGlobal(rest[0], rest[1])
+		}
+		if err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		k, _ := config.Lookup(rest[0])
+		if rest[1] == "" {
+			fmt.Printf("[-] removed %s from %s\n", k.Name, path)
+			return nil
+		}
+		fmt.Printf("[+] set %s in %s\n", k.Name, path)
+		// * 優先權較高的來源已設定時，提醒新值不會生效
+		if values, err := config.Resolve(dir); err == nil {
+			for _, v := range values {
+				if v.Key.Name == k.Name && v.Source != path && v.Layer != config.LayerDefault {
+					fmt.Printf(Warn+"[!] %s is overridden by %s"+Reset+"\n", k.Name, v.Origin())
+				}
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("[x] unsupported config command: %s (show, get or set)", action)
+}
@@ -0,0 +1,33 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/pardnchiu/go-podrun/internal/utils"
+)
+
+// * 遠端有異動時依覆寫政策決定是否繼續；無法詢問時回傳 errCancelled，不標記部署失敗
+func (p *PodmanArg) confirmOverwrite() error {
+	policy, err := utils.OverwritePolicy(p.Overwrite)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case policy == "allow":
+		fmt.Println(Warn + "[!] overwriting remote changes (overwrite=allow)" + Reset)
+		return nil
+	case policy == "prompt" && p.Yes:
+		fmt.Println(Warn + "[!] overwriting remote changes (--yes)" + Reset)
+		return nil
+	case !utils.Interactive() && policy == "deny":
+		return fmt.Errorf("%w: remote has changes and overwrite=deny requires an interactive confirmation", errCancelled)
+	case !utils.Interactive():
+		return fmt.Errorf("%w: remote has changes, pass --yes or set PODRUN_OVERWRITE=allow to overwrite without input", errCancelled)
+	}
+
+	if !utils.Confirm("confirm sync?") {
+		return errCancelled
+	}
+	return nil
+}
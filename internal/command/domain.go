@@ -0,0 +1,162 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pardnchiu/go-podrun/internal/compose"
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/registry"
+)
+
+var (
+	domainRegex = regexp.MustCompile(`^(\*\.)?([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,63}$`)
+	routerRegex = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// * podrun domain add|rm|ls <service> <domain>
+func (p *PodmanArg) Domain() error {
+	args := p.RemoteArgs[1:]
+	if len(args) == 0 {
+		return fmt.Errorf("[x] podrun domain <add|rm|ls> [service] [domain]")
+	}
+
+	switch args[0] {
+	case "ls", "list":
+		domains, err := listDomains(p.UID)
+		if err != nil {
+			return fmt.Errorf("[x] failed to list domains: %w", err)
+		}
+		if len(domains) == 0 {
+			fmt.Println("[-] no domains")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVICE\tDOMAIN")
+		for _, e := range domains {
+			fmt.Fprintf(w, "%s\t%s\n", e.ContainerName, e.Domain)
+		}
+		return w.Flush()
+
+	case "add":
+		if len(args) != 3 {
+			return fmt.Errorf("[x] podrun domain add <service> <domain>")
+		}
+		service, domain := args[1], strings.ToLower(args[2])
+		if !domainRegex.MatchString(domain) {
+			return fmt.Errorf("[x] invalid domain: %s", domain)
+		}
+
+		path, err := compose.Find(p.LocalDir, p.File)
+		if err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		project, err := compose.Load(path)
+		if err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		if !project.HasService(service) {
+			return fmt.Errorf("[x] service %s not found in %s", service, filepath.Base(path))
+		}
+
+		err = api().UpsertDomain(context.Background(), &model.Domain{
+			UID:           p.UID,
+			ContainerName: service,
+			Domain:        domain,
+		})
+		switch {
+		case registry.IsNotFound(err):
+			// * domain 掛在已登錄的部署上，首次 up 之前無法設定
+			return fmt.Errorf("[x] deployment %s is not registered yet, run podrun up first: %w", p.UID, err)
+		case err != nil:
+			return fmt.Errorf("[x] failed to add domain: %w", err)
+		}
+		fmt.Printf("[+] %s -> %s (applied on next podrun up)\n", domain, service)
+		return nil
+
+	case "rm", "remove":
+		var service, domain string
+		switch len(args) {
+		case 2:
+			domain = args[1]
+		case 3:
+			service, domain = args[1], args[2]
+		default:
+			return fmt.Errorf("[x] podrun domain rm [service] <domain>")
+		}
+
+		if err := api().RemoveDomain(context.Background(), &model.Domain{
+			UID:           p.UID,
+			ContainerName: service,
+			Domain:        strings.ToLower(domain),
+		}); err != nil {
+			return fmt.Errorf("[x] failed to remove domain: %w", err)
+		}
+		fmt.Printf("[+] removed %s (applied on next podrun up)\n", domain)
+		return nil
+	}
+
+	return fmt.Errorf("[x] unsupported domain command: %s", args[0])
+}
+
+func listDomains(uid string) ([]model.Domain, error) {
+	return api().ListDomains(context.Background(), uid)
+}
+
+// * 依 registry 中的 domain 設定注入 Traefik routing labels
+func (p *PodmanArg) applyDomains(project *compose.Project) error {
+	domains, err := listDomains(p.UID)
+	if err != nil {
+		fmt.Printf(Warn+"[!] failed to load domains, skip routing labels: %v"+Reset+"\n", err)
+		return nil
+	}
+
+	hosts := map[string][]string{}
+	var services []string
+	for _, e := range domains {
+		if _, ok := hosts[e.ContainerName]; !ok {
+			services = append(services, e.ContainerName)
+		}
+		hosts[e.ContainerName] = append(hosts[e.ContainerName], fmt.Sprintf("Host(`%s`)", e.Domain))
+	}
+
+	network := os.Getenv("PODRUN_PROXY_NETWORK")
+	for _, service := range services {
+		if !project.HasService(service) {
+			fmt.Printf(Warn+"[!] service %s not found, skip domain routing"+Reset+"\n", service)
+			continue
+		}
+
+		router := routerName(filepath.Base(p.RemoteDir), service)
+		labels := map[string]string{
+			"traefik.enable": "true",
+			"traefik.http.routers." + router + ".rule": strings.Join(hosts[service], " || "),
+		}
+		if ports := project.ContainerPorts(service); len(ports) > 0 {
+			labels["traefik.http.services."+router+".loadbalancer.server.port"] = strconv.Itoa(ports[0])
+			labels["traefik.http.routers."+router+".service"] = router
+		}
+		if network != "" {
+			labels["traefik.docker.network"] = network
+			if err := project.AddExternalNetwork(service, network); err != nil {
+				return err
+			}
+		}
+		if err := project.AddLabels(service, labels); err != nil {
+			return err
+		}
+		fmt.Printf("[+] %s -> %s\n", strings.Join(hosts[service], ", "), service)
+	}
+	return nil
+}
+
+func routerName(project, service string) string {
+	name := strings.ToLower(fmt.Sprintf("podrun-%s-%s", project, service))
+	return routerRegex.ReplaceAllString(name, "-")
+}
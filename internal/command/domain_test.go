@@ -0,0 +1,143 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pardnchiu/go-podrun/internal/compose"
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/registry"
+)
+
+const domainCompose = `services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  db:
+    image: postgres
+`
+
+// * 以暫存 SQLite 作為 registry，回傳含 compose 檔的專案
+func newDomainProject(t *testing.T) *PodmanArg {
+	t.Helper()
+
+	apiRegistry = registry.NewEmbedded(filepath.Join(t.TempDir(), "podrun.db"))
+	apiOnce.Do(func() {})
+	t.Cleanup(func() { apiRegistry, apiOnce = nil, sync.Once{} })
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(domainCompose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &PodmanArg{UID: "uid-web", LocalDir: dir, RemoteDir: "/srv/web_12345678"}
+}
+
+func (p *PodmanArg) domain(args ...string) error {
+	p.RemoteArgs = append([]string{"domain"}, args...)
+	return p.Domain()
+}
+
+func TestDomainCommands(t *testing.T) {
+	p := newDomainProject(t)
+
+	if err := p.domain("add", "web", "web.example.com"); err == nil || !strings.Contains(err.Error(), "podrun up first") {
+		t.Fatalf("add before up: got %v", err)
+	}
+
+	if err := api().UpsertPod(context.Background(), &model.Pod{
+		UID: p.UID, PodID: "web", PodName: "web", RemoteDir: p.RemoteDir, Status: model.StatusStarting,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"add", "web"},
+		{"add", "web", "not a domain"},
+		{"add", "cache", "web.example.com"},
+		{"rm"},
+		{"rename"},
+	} {
+		if err := p.domain(args...); err == nil {
+			t.Errorf("domain %v: expected an error", args)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"add", "web", "Web.Example.com"},
+		{"add", "web", "www.example.com"},
+		{"add", "db", "db.example.com"},
+		{"ls"},
+		{"rm", "db", "db.example.com"},
+	} {
+		if err := p.domain(args...); err != nil {
+			t.Fatalf("domain %v: %v", args, err)
+		}
+	}
+
+	domains, err := listDomains(p.UID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range domains {
+		got = append(got, e.ContainerName+"="+e.Domain)
+	}
+	if strings.Join(got, ",") != "web=web.example.com,web=www.example.com" {
+		t.Fatalf("domains = %v", got)
+	}
+
+	if err := p.domain("rm", "db.example.com"); err == nil {
+		t.Fatal("removing a removed domain should fail")
+	}
+}
+
+// * 同一個服務的 domain 合併為一條 router rule，並指向第一個 container port
+func TestApplyDomains(t *testing.T) {
+	p := newDomainProject(t)
+	t.Setenv("PODRUN_PROXY_NETWORK", "proxy")
+	if err := api().UpsertPod(context.Background(), &model.Pod{
+		UID: p.UID, PodID: "web", PodName: "web", RemoteDir: p.RemoteDir, Status: model.StatusStarting,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, domain := range []string{"web.example.com", "www.example.com"} {
+		if err := p.domain("add", "web", domain); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	project, err := compose.Load(filepath.Join(p.LocalDir, "docker-compose.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.applyDomains(project); err != nil {
+		t.Fatal(err)
+	}
+	data, err := project.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(data)
+	for _, want := range []string{
+		`traefik.enable: "true"`,
+		"traefik.http.routers.podrun-web-12345678-web.rule: \"Host(`web.example.com`) || Host(`www.example.com`)\"",
+		`traefik.http.services.podrun-web-12345678-web.loadbalancer.server.port: "80"`,
+		`traefik.http.routers.podrun-web-12345678-web.service: "podrun-web-12345678-web"`,
+		`traefik.docker.network: "proxy"`,
+		`- "proxy"`,
+		"external: true",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "traefik.enable") != 1 {
+		t.Errorf("labels added to a service without domains:\n%s", out)
+	}
+}
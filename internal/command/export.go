@@ -0,0 +1,56 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pardnchiu/go-podrun/internal/compose"
+	"github.com/pardnchiu/go-podrun/internal/kube"
+)
+
+// * 未指定 --output 時輸出至 stdout，提示訊息一律寫入 stderr 以便導向檔案
+func (p *PodmanArg) Export() error {
+	path, err := compose.Find(p.LocalDir, p.File)
+	if err != nil {
+		return err
+	}
+	project, err := compose.Load(path)
+	if err != nil {
+		return err
+	}
+
+	manifests, warnings, err := kube.Generate(project, kube.Options{})
+	if err != nil {
+		return err
+	}
+	for _, e := range warnings {
+		fmt.Fprintf(os.Stderr, "[!] %s\n", e)
+	}
+
+	if p.Output == "" {
+		data, err := kube.MarshalAll(manifests)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.MkdirAll(p.Output, 0755); err != nil {
+		return err
+	}
+	for _, m := range manifests {
+		data, err := kube.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", m.Kind, m.Name, err)
+		}
+		file := filepath.Join(p.Output, fmt.Sprintf("%s-%s.yaml", m.Name, strings.ToLower(m.Kind)))
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "[*] %d manifests written to %s\n", len(manifests), p.Output)
+	return nil
+}
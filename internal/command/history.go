@@ -0,0 +1,142 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/registry"
+)
+
+const defaultHistoryLimit = 20
+
+// * podrun history [--since=<time>] [--until=<time>] [-n <count>] [--page=<n>] [--mine] [--json]
+// * 時間可為 RFC 3339、2006-01-02 或相對時間（24h）
+func (p *PodmanArg) History() error {
+	var query registry.RecordQuery
+	limit, page := defaultHistoryLimit, 1
+	asJSON := false
+
+	args := p.RemoteArgs[1:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := ""
+		if k, v, ok := strings.Cut(arg, "="); ok {
+			arg, value = k, v
+		} else if i+1 < len(args) && arg != "--json" && arg != "--mine" {
+			value = args[i+1]
+			i++
+		}
+
+		switch arg {
+		case "--json":
+			asJSON = true
+		case "--mine":
+			query.User = currentUser()
+		case "--since", "--until":
+			t, err := parseSince(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %s", arg, value)
+			}
+			if arg == "--since" {
+				query.Since = t
+			} else {
+				query.Until = t
+			}
+		case "-n", "--limit":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid %s: %s", arg, value)
+			}
+			limit = n
+		case "--page":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid %s: %s", arg, value)
+			}
+			page = n
+		default:
+			return fmt.Errorf("unexpected argument: %s", args[i])
+		}
+	}
+	query.Limit = limit
+	query.Offset = (page - 1) * limit
+
+	records, total, err := api().ListRecords(context.Background(), p.UID, query)
+	if registry.IsNotFound(err) {
+		return fmt.Errorf("deployment %s is not registered yet, run podrun up first", p.UID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list records: %w", err)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Data  []model.Record `json:"data"`
+			Total int            `json:"total"`
+		}{records, total})
+	}
+
+	if len(records) == 0 {
+		fmt.Println("no records")
+		return nil
+	}
+	printRecords(records)
+
+	pages := (total + limit - 1) / limit
+	fmt.Printf(Hint+"page %d/%d, %d records"+Reset+"\n", page, pages, total)
+	return nil
+}
+
+func parseSince(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
+}
+
+func printRecords(records []model.Record) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tACTION\tRESULT\tDURATION\tCOMMIT\tUSER\tHOSTNAME\tIP")
+	for _, e := range records {
+		created := "-"
+		if !e.CreatedAt.IsZero() {
+			created = e.CreatedAt.Local().Format(time.DateTime)
+		}
+		result := "ok"
+		if e.ExitCode != 0 || e.Error != "" {
+			result = fmt.Sprintf("exit %d", e.ExitCode)
+		}
+		duration := "-"
+		if e.DurationMs > 0 {
+			duration = (time.Duration(e.DurationMs) * time.Millisecond).Round(100 * time.Millisecond).String()
+		}
+		commit := "-"
+		if e.GitCommit != "" {
+			commit = e.GitCommit
+			if sha, dirty, ok := strings.Cut(commit, "-"); ok && len(sha) > 7 {
+				commit = sha[:7] + "-" + dirty
+			} else if len(commit) > 7 {
+				commit = commit[:7]
+			}
+		}
+		user := "-"
+		if e.User != "" {
+			user = e.User
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			created, e.Content, result, duration, commit, user, e.Hostname, e.IP)
+	}
+	w.Flush()
+}
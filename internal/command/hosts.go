@@ -0,0 +1,86 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pardnchiu/go-podrun/internal/transport"
+	"github.com/pardnchiu/go-podrun/internal/utils"
+)
+
+// * podrun hosts trust|forget|list [host[:port]|server]
+func Hosts(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("[x] podrun hosts <trust|forget|list> [host[:port]]")
+	}
+
+	store := transport.NewHostKeyStore(transport.DefaultKnownHostsPath())
+
+	switch args[0] {
+	case "list", "ls":
+		keys, err := store.List()
+		if err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		if len(keys) == 0 {
+			fmt.Println("[-] no trusted hosts")
+			return nil
+		}
+		for _, e := range keys {
+			fmt.Printf("%-32s %-24s %s\n", strings.Join(e.Hosts, ","), e.Type, e.Fingerprint)
+		}
+		return nil
+
+	case "trust":
+		host, err := hostArg(args[1:])
+		if err != nil {
+			return err
+		}
+		key, addr, err := transport.FetchHostKey(host, 22)
+		if err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		if !utils.HostKeyConfirm(addr, key) {
+			return fmt.Errorf("[x] cancelled")
+		}
+		if _, err := store.Forget(addr); err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		if err := store.Trust(addr, key); err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		fmt.Printf("[+] trusted %s (%s %s)\n", addr, key.Type(), transport.Fingerprint(key))
+		return nil
+
+	case "forget", "rm":
+		host, err := hostArg(args[1:])
+		if err != nil {
+			return err
+		}
+		removed, err := store.Forget(host)
+		if err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		if removed == 0 {
+			fmt.Printf("[-] %s not found in known hosts\n", host)
+			return nil
+		}
+		fmt.Printf("[+] removed %d key(s) for %s\n", removed, host)
+		return nil
+	}
+
+	return fmt.Errorf("[x] unsupported hosts command: %s", args[0])
+}
+
+// * inventory 的名稱會換成對應的位址
+func hostArg(args []string) (string, error) {
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	server, err := utils.ResolveServer(name)
+	if err != nil {
+		return "", fmt.Errorf("[x] host is required: %w", err)
+	}
+	return server.Addr(), nil
+}
@@ -0,0 +1,51 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pardnchiu/go-podrun/internal/filesync"
+)
+
+// * 專案的同步排除規則，語法同 .gitignore
+const ignoreFile = ".podrunignore"
+
+// * 預設排除，.podrunignore 可用 ! 重新納入
+var defaultIgnores = []string{
+	".git/", "node_modules/", "vendor/", "__pycache__/",
+	"*.pyc", ".venv/", "venv/", "env/",
+	".env.local", "*.log", ".DS_Store", "Thumbs.db",
+	".next/",
+}
+
+// * podrun 產生的檔案，放在最後，不可重新納入
+var generatedFiles = []string{
+	"/docker-compose.podrun.yml",
+	"/k8s.podrun.yml",
+	"/" + linkFile,
+}
+
+// * 依序套用預設規則、.gitignore（PODRUN_GITIGNORE=true 時）、.podrunignore，後面的規則優先
+func syncIgnore(dir string) (*filesync.Ignore, error) {
+	ignore := filesync.NewIgnore(defaultIgnores...)
+
+	if value := os.Getenv("PODRUN_GITIGNORE"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PODRUN_GITIGNORE: %s", value)
+		}
+		if enabled {
+			if err := ignore.AddFile(filepath.Join(dir, ".gitignore")); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := ignore.AddFile(filepath.Join(dir, ignoreFile)); err != nil {
+		return nil, err
+	}
+	ignore.Add(generatedFiles...)
+	return ignore, nil
+}
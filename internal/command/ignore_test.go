@@ -0,0 +1,53 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// * 產生的檔案即使在 .gitignore 或 .podrunignore 中以 ! 重新納入，仍一律排除
+func TestSyncIgnoreGeneratedFiles(t *testing.T) {
+	dir := t.TempDir()
+	reinclude := "!*\n!docker-compose.podrun.yml\n!/k8s.podrun.yml\n!.podrun\n!.podrun/\n"
+	for _, name := range []string{".gitignore", ignoreFile} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(reinclude), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PODRUN_GITIGNORE", "true")
+
+	ignore, err := syncIgnore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"docker-compose.podrun.yml", "k8s.podrun.yml", linkFile} {
+		for _, isDir := range []bool{false, true} {
+			if !ignore.Match(name, isDir) {
+				t.Errorf("%s (dir=%v) is not excluded", name, isDir)
+			}
+		}
+	}
+	// * 其他預設規則可被重新納入
+	if ignore.Match("node_modules", true) {
+		t.Error("node_modules should be re-included by !*")
+	}
+
+	// * rsync 採第一個符合的規則，產生的檔案需排在最前面
+	want := []string{
+		"--filter=- /" + linkFile,
+		"--filter=- /k8s.podrun.yml",
+		"--filter=- /docker-compose.podrun.yml",
+	}
+	if got := ignore.RsyncFilters()[:len(want)]; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("first filters = %q, want %q", got, want)
+	}
+}
+
+func TestSyncIgnoreInvalidGitignoreFlag(t *testing.T) {
+	t.Setenv("PODRUN_GITIGNORE", "maybe")
+	if _, err := syncIgnore(t.TempDir()); err == nil {
+		t.Fatal("expected an error for an invalid PODRUN_GITIGNORE")
+	}
+}
@@ -0,0 +1,348 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/kube"
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/registry"
+	"github.com/pardnchiu/go-podrun/internal/utils"
+)
+
+const defaultInfoRecords = 10
+
+type Info struct {
+	UID        string           `json:"uid"`
+	Target     string           `json:"target"`
+	Registry   *model.Pod       `json:"registry"`
+	Records    []model.Record   `json:"records"`
+	Ports      []model.Port     `json:"ports"`
+	Containers []ContainerState `json:"containers"`
+	Warnings   []string         `json:"warnings"`
+}
+
+type ContainerState struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	State     string    `json:"state"`
+	Ports     []string  `json:"ports"`
+	StartedAt time.Time `json:"started_at"`
+	Restarts  int       `json:"restarts"`
+}
+
+// * podrun info [--json] [-n <records>]
+func (p *PodmanArg) Info() error {
+	asJSON := false
+	limit := defaultInfoRecords
+	args := p.RemoteArgs[1:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--json":
+			asJSON = true
+		case arg == "-n" && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return fmt.Errorf("invalid record count: %s", args[i+1])
+			}
+			limit = n
+			i++
+		default:
+			return fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+
+	info, err := p.collectInfo(limit)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	printInfo(info)
+	return nil
+}
+
+func (p *PodmanArg) collectInfo(limit int) (*Info, error) {
+	info := &Info{UID: p.UID, Target: p.Target}
+
+	ctx := context.Background()
+	pod, err := api().GetPod(ctx, p.UID)
+	switch {
+	case err == nil:
+		info.Registry = pod
+		// * 以 registry 記錄的 runtime 為準
+		if pod.Target != "" {
+			info.Target = pod.Target
+		}
+	case !registry.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get pod: %w", err)
+	}
+
+	if info.Registry != nil {
+		if info.Records, _, err = api().ListRecords(ctx, p.UID, registry.RecordQuery{Limit: limit}); err != nil {
+			return nil, fmt.Errorf("failed to list records: %w", err)
+		}
+	}
+
+	if ports, err := api().ListPorts(ctx, p.UID); err == nil {
+		info.Ports = ports
+	}
+
+	if info.Target == "k3s" {
+		info.Containers, err = p.k3sContainers(info.Registry)
+	} else {
+		info.Containers, err = p.podmanContainers(info.Registry)
+	}
+	if err != nil {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("failed to read runtime state: %s", err))
+		return info, nil
+	}
+
+	info.Warnings = append(info.Warnings, compareInfo(info)...)
+	return info, nil
+}
+
+// * registry 與實際執行狀態不一致的項目
+func compareInfo(info *Info) []string {
+	var warnings []string
+
+	running := 0
+	for _, e := range info.Containers {
+		if e.State == "running" {
+			running++
+		} else {
+			warnings = append(warnings, fmt.Sprintf("container %s is %s", e.Name, e.State))
+		}
+	}
+
+	if info.Registry == nil {
+		if len(info.Containers) > 0 {
+			warnings = append(warnings, fmt.Sprintf("not registered, but %d containers exist on the server", len(info.Containers)))
+		}
+		return warnings
+	}
+
+	switch {
+	case len(info.Containers) == 0:
+		warnings = append(warnings, fmt.Sprintf("registry status is %q, but no containers exist on the server", info.Registry.Status))
+	case info.Target == "k3s" && running != info.Registry.Replicas:
+		warnings = append(warnings, fmt.Sprintf("registry expects %d replicas, %d running", info.Registry.Replicas, running))
+	}
+
+	// * 只有 podman 會綁定 registry 分配的 host port
+	if info.Target != "k3s" {
+		published := map[string]bool{}
+		for _, e := range info.Containers {
+			for _, port := range e.Ports {
+				published[port] = true
+			}
+		}
+		for _, e := range info.Ports {
+			key := fmt.Sprintf("%d->%d/%s", e.HostPort, e.ContainerPort, e.Protocol)
+			if len(info.Containers) > 0 && !published[key] {
+				warnings = append(warnings, fmt.Sprintf("allocated port %s (%s) is not published", key, e.Service))
+			}
+		}
+	}
+	return warnings
+}
+
+// * 部署中傳入 nil，依本機 compose 檔決定；info 以 registry 記錄的 project 名稱為準，-u 指定的部署不一定是目前資料夾的專案
+func (p *PodmanArg) podmanContainers(pod *model.Pod) ([]ContainerState, error) {
+	project := p.projectName()
+	if pod != nil && pod.RemoteDir != "" {
+		project = pod.ProjectName()
+	}
+	output, err := utils.SSEOutput(fmt.Sprintf(
+		"podman ps -a --filter 'label=io.podman.compose.project=%s' --format json",
+		project,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var list []struct {
+		ID        string   `json:"Id"`
+		Names     []string `json:"Names"`
+		State     string   `json:"State"`
+		StartedAt int64    `json:"StartedAt"`
+		Restarts  int      `json:"Restarts"`
+		Ports     []struct {
+			HostPort      int    `json:"host_port"`
+			ContainerPort int    `json:"container_port"`
+			Protocol      string `json:"protocol"`
+			Range         int    `json:"range"`
+		} `json:"Ports"`
+	}
+	if strings.TrimSpace(output) != "" {
+		if err := json.Unmarshal([]byte(output), &list); err != nil {
+			return nil, fmt.Errorf("parse podman ps: %w", err)
+		}
+	}
+
+	containers := make([]ContainerState, 0, len(list))
+	for _, e := range list {
+		c := ContainerState{
+			ID:       shortID(e.ID),
+			Name:     strings.Join(e.Names, ","),
+			State:    e.State,
+			Restarts: e.Restarts,
+		}
+		if e.StartedAt > 0 {
+			c.StartedAt = time.Unix(e.StartedAt, 0)
+		}
+		for _, port := range e.Ports {
+			for i := 0; i < max(port.Range, 1); i++ {
+				c.Ports = append(c.Ports, fmt.Sprintf("%d->%d/%s", port.HostPort+i, port.ContainerPort+i, port.Protocol))
+			}
+		}
+		containers = append(containers, c)
+	}
+	return containers, nil
+}
+
+// * 與 podman ps 相同，只保留前 12 碼
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// * 以 registry 記錄的 namespace 為準（info -u 時本機資料夾與部署無關）
+func (p *PodmanArg) k3sNamespace(pod *model.Pod) string {
+	switch {
+	case pod == nil:
+		return p.namespace()
+	case pod.PodID != "":
+		return pod.PodID
+	case pod.RemoteDir != "":
+		return kube.Name("podrun-" + filepath.Base(pod.RemoteDir))
+	}
+	return p.namespace()
+}
+
+func (p *PodmanArg) k3sContainers(pod *model.Pod) ([]ContainerState, error) {
+	kubectl, err := kubectlCommand()
+	if err != nil {
+		return nil, err
+	}
+	output, err := utils.SSEOutput(fmt.Sprintf(
+		"%s -n %s get pods -l %s -o json",
+		kubectl, p.k3sNamespace(pod), k3sSelector,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+				UID  string `json:"uid"`
+			} `json:"metadata"`
+			Spec struct {
+				Containers []struct {
+					Ports []struct {
+						ContainerPort int    `json:"containerPort"`
+						Protocol      string `json:"protocol"`
+					} `json:"ports"`
+				} `json:"containers"`
+			} `json:"spec"`
+			Status struct {
+				Phase             string    `json:"phase"`
+				StartTime         time.Time `json:"startTime"`
+				ContainerStatuses []struct {
+					RestartCount int `json:"restartCount"`
+				} `json:"containerStatuses"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("parse kubectl get pods: %w", err)
+	}
+
+	containers := make([]ContainerState, 0, len(list.Items))
+	for _, e := range list.Items {
+		c := ContainerState{
+			ID:        e.Metadata.UID,
+			Name:      e.Metadata.Name,
+			State:     strings.ToLower(e.Status.Phase),
+			StartedAt: e.Status.StartTime,
+		}
+		for _, status := range e.Status.ContainerStatuses {
+			c.Restarts += status.RestartCount
+		}
+		for _, container := range e.Spec.Containers {
+			for _, port := range container.Ports {
+				c.Ports = append(c.Ports, fmt.Sprintf("%d/%s", port.ContainerPort, strings.ToLower(port.Protocol)))
+			}
+		}
+		containers = append(containers, c)
+	}
+	return containers, nil
+}
+
+func printInfo(info *Info) {
+	fmt.Println("[*] registry")
+	fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
+	if info.Registry == nil {
+		fmt.Printf("UID: %s (not registered)\n", info.UID)
+	} else {
+		d := info.Registry
+		fmt.Printf("UID: %s\n", d.UID)
+		fmt.Printf("Pod: %s (%s)\n", d.PodName, d.PodID)
+		fmt.Printf("Target: %s\n", d.Target)
+		fmt.Printf("Status: %s\n", d.Status)
+		if d.Owner != "" {
+			fmt.Printf("Owner: %s\n", d.Owner)
+		}
+		if d.Server != "" {
+			fmt.Printf("Server: %s\n", d.Server)
+		}
+		fmt.Printf("Replicas: %d\n", d.Replicas)
+		fmt.Printf("Local: %s\n", d.LocalDir)
+		fmt.Printf("Remote: %s\n", d.RemoteDir)
+		fmt.Printf("Host: %s (%s)\n", d.Hostname, d.IP)
+		fmt.Printf("Updated: %s\n", d.UpdatedAt.Local().Format(time.DateTime))
+	}
+
+	fmt.Println()
+	fmt.Println("[*] containers")
+	fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tUPTIME\tRESTARTS\tPORTS")
+	for _, e := range info.Containers {
+		uptime := "-"
+		if e.State == "running" && !e.StartedAt.IsZero() {
+			uptime = time.Since(e.StartedAt).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", e.Name, e.State, uptime, e.Restarts, strings.Join(e.Ports, ", "))
+	}
+	w.Flush()
+
+	if len(info.Records) > 0 {
+		fmt.Println()
+		fmt.Println("[*] records")
+		fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
+		printRecords(info.Records)
+	}
+
+	if len(info.Warnings) > 0 {
+		fmt.Println()
+		for _, e := range info.Warnings {
+			fmt.Printf(Warn+"[!] %s"+Reset+"\n", e)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+// * info -u 時使用 registry 記錄的 namespace，而非目前資料夾推得的名稱
+func TestK3sNamespace(t *testing.T) {
+	p := &PodmanArg{RemoteDir: "/home/podrun/local-abc12345"}
+
+	tests := []struct {
+		name string
+		pod  *model.Pod
+		want string
+	}{
+		{"not registered", nil, "podrun-local-abc12345"},
+		{"registered namespace", &model.Pod{PodID: "podrun-shop-9f8e7d6c", RemoteDir: "/home/podrun/other-11111111"}, "podrun-shop-9f8e7d6c"},
+		{"registered folder only", &model.Pod{RemoteDir: "/home/podrun/shop-9f8e7d6c"}, "podrun-shop-9f8e7d6c"},
+		{"empty record", &model.Pod{}, "podrun-local-abc12345"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.k3sNamespace(tt.pod); got != tt.want {
+				t.Fatalf("k3sNamespace = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/compose"
+	"github.com/pardnchiu/go-podrun/internal/kube"
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/utils"
+)
+
+const (
+	k3sManifestFile = "k8s.podrun.yml"
+	k3sSelector     = "app.kubernetes.io/managed-by=podrun"
+	rolloutTimeout  = "180s"
+)
+
+// * 每個專案使用獨立 namespace，名稱取自遠端資料夾（<project>_<hash>）
+func (p *PodmanArg) namespace() string {
+	return kube.Name("podrun-" + filepath.Base(p.RemoteDir))
+}
+
+func (p *PodmanArg) k3sCMD(d *model.Pod) (*model.Pod, error) {
+	kubectl, err := kubectlCommand()
+	if err != nil {
+		return nil, err
+	}
+	ns := p.namespace()
+	d.PodID = ns
+	d.PodName = ns
+
+	switch p.Command {
+	case "up":
+		return p.k3sUp(d, kubectl)
+	case "down":
+		fmt.Printf("[*] deleting resources in namespace %s\n", ns)
+		fmt.Println(Hint + "──────────────────────────────────────────────────")
+		if err := utils.SSHRun(k3sDownCommand(kubectl, ns)); err != nil {
+			return nil, failPod(d, "down", err)
+		}
+		fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
+		removePod(d.UID)
+		recordPod(d, "down", nil, nil)
+		return d, nil
+	case "clear":
+		return p.k3sClear(d, kubectl)
+	case "build":
+		images, err := p.k3sBuild(kubectl)
+		if err != nil {
+			return nil, failPod(d, "build", err)
+		}
+		recordPod(d, "build", map[string]any{"images": images}, nil)
+		return d, nil
+	case "ps", "logs", "restart", "exec":
+		command, err := p.k3sArgs(kubectl, ns)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("[*] executing: %s\n", command)
+		fmt.Println(Hint + "──────────────────────────────────────────────────")
+		run := utils.SSHRun
+		if interactive[p.Command] {
+			run = utils.SSHInteractive
+		}
+		err = run(command)
+		fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
+		if err != nil && mutating[p.Command] {
+			return nil, failPod(d, p.Command, err)
+		}
+		recordPod(d, p.Command, nil, err)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("unsupported command: %s", p.Command)
+}
+
+func (p *PodmanArg) k3sUp(d *model.Pod, kubectl string) (*model.Pod, error) {
+	ns := p.namespace()
+
+	fmt.Println("[+] create folder if not exist")
+	if err := utils.SSHRun("mkdir", "-p", p.RemoteDir); err != nil {
+		return nil, p.failUp(d, err)
+	}
+
+	// * 同步檔案夾資料，bind mount 與 build 都依賴遠端的專案檔案
+	fmt.Println("[*] syncing files")
+	if err := p.RsyncToRemote(d); err != nil {
+		if errors.Is(err, errCancelled) {
+			return nil, err
+		}
+		return nil, p.failUp(d, utils.Classify(utils.ExitSync, err))
+	}
+	fmt.Println("──────────────────────────────────────────────────" + Reset)
+
+	d.Status = model.StatusStarting
+	if err := upsertPod(d); err != nil {
+		return nil, fmt.Errorf("[x] failed to upsert pod: %w", err)
+	}
+	p.flushPending(d)
+
+	images, err := p.k3sBuild(kubectl)
+	if err != nil {
+		return nil, failPod(d, "up", err)
+	}
+
+	// * 產生 manifest，相對路徑 bind mount 對應至遠端專案資料夾
+	fmt.Println("[*] generating kubernetes manifests")
+	file, err := compose.Find(p.LocalDir, p.File)
+	if err != nil {
+		return nil, failPod(d, "up", err)
+	}
+	project, err := compose.Load(file)
+	if err != nil {
+		return nil, failPod(d, "up", err)
+	}
+	manifests, warnings, err := kube.Generate(project, kube.Options{
+		Namespace:    ns,
+		HostPathRoot: p.RemoteDir,
+		Images:       images,
+	})
+	if err != nil {
+		return nil, failPod(d, "up", err)
+	}
+	for _, e := range warnings {
+		fmt.Printf("[!] %s\n", e)
+	}
+	data, err := kube.MarshalAll(manifests)
+	if err != nil {
+		return nil, failPod(d, "up", err)
+	}
+	manifestPath := filepath.Join(p.RemoteDir, k3sManifestFile)
+	if err := utils.SSHWrite(manifestPath, data); err != nil {
+		return nil, failPod(d, "up", err)
+	}
+
+	fmt.Printf("[*] applying to namespace %s\n", ns)
+	fmt.Println(Hint + "──────────────────────────────────────────────────")
+	if err := utils.SSHRun(fmt.Sprintf("%s apply -f '%s'", kubectl, manifestPath)); err != nil {
+		return nil, failPod(d, "up", err)
+	}
+
+	for _, m := range manifests {
+		if m.Kind != "Deployment" {
+			continue
+		}
+		if err := utils.SSHRun(fmt.Sprintf(
+			"%s -n %s rollout status deployment/%s --timeout=%s",
+			kubectl, ns, m.Name, rolloutTimeout,
+		)); err != nil {
+			return nil, failPod(d, "up", fmt.Errorf("rollout of %s failed: %w", m.Name, err))
+		}
+	}
+	d.Replicas = k3sReplicas(manifests)
+	fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
+
+	// * 取得 Pod 資訊
+	output, err := utils.SSEOutput(fmt.Sprintf(
+		`%s -n %s get pods -l %s -o jsonpath='{range .items[*]}{.metadata.name}{"\n"}{end}'`,
+		kubectl, ns, k3sSelector,
+	))
+	if err == nil {
+		if pods := strings.Fields(output); len(pods) > 0 {
+			d.PodName = strings.Join(pods, ",")
+		}
+	}
+	// * rollout status 已確認所有 Deployment 就緒
+	d.Status = model.StatusRunning
+
+	if p.Detach {
+		fmt.Println("[*] pods:")
+		fmt.Println(Ok + "──────────────────────────────────────────────────")
+		output, _ := utils.SSEOutput(fmt.Sprintf("%s -n %s get pods,services -o wide", kubectl, ns))
+		fmt.Println(output)
+		fmt.Printf("Namespace: %s\n", ns)
+		fmt.Printf("Replicas: %d\n", d.Replicas)
+		fmt.Printf("Hostname: %s\n", d.Hostname)
+		fmt.Printf("IP: %s\n", d.IP)
+		fmt.Println("──────────────────────────────────────────────────" + Reset)
+	}
+
+	if err := upsertPod(d); err != nil {
+		return nil, fmt.Errorf("[x] failed to upsert pod: %w", err)
+	}
+	containers, _ := p.k3sContainers(d)
+	recordPod(d, "up", upPayload{Containers: containers}, nil)
+
+	// * 非 detach 時與 podman 行為一致：跟隨 log，中斷後移除資源
+	if !p.Detach {
+		fmt.Println(Hint + "──────────────────────────────────────────────────")
+		_ = utils.SSHInteractive(fmt.Sprintf(`
+				cleanup() {
+					echo "[*] deleting resources"
+					%s
+				}
+				trap cleanup INT TERM
+				%s -n %s logs -f -l %s --all-containers --prefix --max-log-requests=20
+			`, k3sDownCommand(kubectl, ns), kubectl, ns, k3sSelector))
+		fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
+
+		// * Pod 終止需要時間，以 Deployment 是否存在判斷是否已移除
+		output, err := utils.SSEOutput(fmt.Sprintf("%s -n %s get deployment -l %s -o name", kubectl, ns, k3sSelector))
+		if err == nil && strings.TrimSpace(output) == "" {
+			removePod(d.UID)
+			recordPod(d, "down", nil, nil)
+		}
+	}
+	return d, nil
+}
+
+func (p *PodmanArg) k3sClear(d *model.Pod, kubectl string) (*model.Pod, error) {
+	ns := p.namespace()
+
+	// * namespace 連同 PVC 一併刪除
+	fmt.Printf("[*] delete namespace %s\n", ns)
+	fmt.Println(Hint + "──────────────────────────────────────────────────")
+	cmds := k3sClearCommands(kubectl, ns, p.RemoteDir)
+	if err := utils.SSHRun(cmds.namespace); err != nil {
+		return nil, failPod(d, "clear", fmt.Errorf("failed to delete namespace: %w", err))
+	}
+	fmt.Println("──────────────────────────────────────────────────" + Reset)
+
+	fmt.Println("[*] clean images")
+	_, _ = utils.SSEOutput(cmds.images)
+
+	fmt.Println("[*] remove project folder")
+	fmt.Println(Hint + "──────────────────────────────────────────────────")
+	if err := utils.SSHRun(cmds.folder); err != nil {
+		return nil, failPod(d, "clear", fmt.Errorf("failed to remove folder: %w", err))
+	}
+	fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
+
+	removePod(d.UID)
+	recordPod(d, "clear", nil, nil)
+	return d, nil
+}
+
+// * Deployment 的 replicas 總和（compose 未指定時為 1）
+func k3sReplicas(manifests []kube.Manifest) int {
+	replicas := 0
+	for _, m := range manifests {
+		if deployment, ok := m.Object.(kube.Deployment); ok {
+			replicas += deployment.Spec.Replicas
+		}
+	}
+	return replicas
+}
+
+// * down 只刪除 podrun 建立的資源，PVC 保留
+func k3sDownCommand(kubectl, ns string) string {
+	return fmt.Sprintf(
+		"%s -n %s delete deployment,service,configmap -l %s --ignore-not-found",
+		kubectl, ns, k3sSelector,
+	)
+}
+
+type k3sClearCmds struct {
+	namespace string
+	images    string
+	folder    string
+}
+
+// * clear 依序刪除 namespace（含 PVC）、建置的 image 與遠端資料夾
+func k3sClearCommands(kubectl, ns, remoteDir string) k3sClearCmds {
+	return k3sClearCmds{
+		namespace: fmt.Sprintf("%s delete namespace %s --ignore-not-found", kubectl, ns),
+		images: fmt.Sprintf(
+			"podman rmi -f $(podman images -q --filter 'reference=localhost/%s/*') >/dev/null 2>&1 || true",
+			ns,
+		),
+		folder: fmt.Sprintf(
+			"podman run --rm --privileged -v '%s:/parent' alpine:latest sh -c 'rm -rf /parent/%s'",
+			filepath.Dir(remoteDir),
+			filepath.Base(remoteDir),
+		),
+	}
+}
+
+// * 在遠端以 podman build 後匯入 k3s containerd，回傳服務對應的 image
+func (p *PodmanArg) k3sBuild(kubectl string) (map[string]string, error) {
+	file, err := compose.Find(p.LocalDir, p.File)
+	if err != nil {
+		return nil, err
+	}
+	project, err := compose.Load(file)
+	if err != nil {
+		return nil, err
+	}
+	services, err := project.Services()
+	if err != nil {
+		return nil, err
+	}
+
+	// * 每次建置使用唯一 tag，manifest 的 image 改變才會觸發 rollout（固定 :latest 搭配 IfNotPresent 不會重新部署）
+	tag := time.Now().UTC().Format("20060102150405")
+
+	images := map[string]string{}
+	for _, svc := range services {
+		if svc.Build == nil {
+			continue
+		}
+		image := fmt.Sprintf("localhost/%s/%s:%s", p.namespace(), kube.Name(svc.Name), tag)
+
+		context := path.Join(p.RemoteDir, filepath.ToSlash(svc.Build.Context))
+		build := fmt.Sprintf("podman build -t '%s'", image)
+		// * 保留 compose 指定的 image 名稱，方便在主機上辨識
+		if svc.Image != "" {
+			build += fmt.Sprintf(" -t '%s'", svc.Image)
+		}
+		if svc.Build.Dockerfile != "" {
+			build += fmt.Sprintf(" -f '%s'", path.Join(context, svc.Build.Dockerfile))
+		}
+		build += fmt.Sprintf(" '%s'", context)
+
+		fmt.Printf("[*] building %s\n", image)
+		fmt.Println(Hint + "──────────────────────────────────────────────────")
+		if err := utils.SSHRun(fmt.Sprintf(
+			"%s && podman save '%s' | %s ctr images import -",
+			build, image, k3sPrefix(kubectl),
+		)); err != nil {
+			return nil, fmt.Errorf("build %s failed: %w", svc.Name, err)
+		}
+		fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
+
+		// * 移除 podman 中先前建置的 tag（k3s containerd 內的舊 image 由 kubelet GC 處理）
+		_, _ = utils.SSEOutput(fmt.Sprintf(
+			"podman images --format '{{.Repository}}:{{.Tag}}' --filter 'reference=localhost/%s/%s' | grep -vx '%s' | xargs -r podman rmi >/dev/null 2>&1 || true",
+			p.namespace(), kube.Name(svc.Name), image,
+		))
+		images[svc.Name] = image
+	}
+	return images, nil
+}
+
+// * 將 compose 風格的參數轉為 kubectl：ps / logs [-f] [service] / restart [service] / exec <service> <cmd...>
+func (p *PodmanArg) k3sArgs(kubectl, ns string) (string, error) {
+	var flags, services []string
+	for _, e := range p.RemoteArgs[1:] {
+		if strings.HasPrefix(e, "-") && len(services) == 0 {
+			flags = append(flags, e)
+			continue
+		}
+		services = append(services, e)
+	}
+	base := fmt.Sprintf("%s -n %s", kubectl, ns)
+
+	switch p.Command {
+	case "ps":
+		return base + " get pods -o wide", nil
+	case "logs":
+		selector := k3sSelector
+		if len(services) > 0 {
+			selector += ",app.kubernetes.io/name=" + kube.Name(services[0])
+		}
+		command := fmt.Sprintf("%s logs -l %s --all-containers --prefix --max-log-requests=20", base, selector)
+		for _, e := range flags {
+			if e == "-f" || e == "--follow" {
+				command += " -f"
+			}
+		}
+		return command, nil
+	case "restart":
+		if len(services) == 0 {
+			return base + " rollout restart deployment -l " + k3sSelector, nil
+		}
+		targets := make([]string, len(services))
+		for i, e := range services {
+			targets[i] = "deployment/" + kube.Name(e)
+		}
+		return base + " rollout restart " + strings.Join(targets, " "), nil
+	case "exec":
+		if len(services) < 2 {
+			return "", fmt.Errorf("usage: podrun exec <service> <command...>")
+		}
+		return fmt.Sprintf("%s exec -it deployment/%s -- %s", base, kube.Name(services[0]), shellJoin(services[1:])), nil
+	}
+	return "", fmt.Errorf("unsupported command: %s", p.Command)
+}
+
+// * PODRUN_KUBECTL 可指定指令（例如 sudo k3s kubectl），否則優先使用遠端的 kubectl
+func kubectlCommand() (string, error) {
+	if kubectl := os.Getenv("PODRUN_KUBECTL"); kubectl != "" {
+		return kubectl, nil
+	}
+	output, err := utils.SSEOutput("command -v kubectl >/dev/null 2>&1 && echo kubectl || echo 'k3s kubectl'")
+	if err != nil {
+		return "", fmt.Errorf("detect kubectl failed: %w", err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// * k3s ctr 與 kubectl 使用相同的權限前綴（sudo）
+func k3sPrefix(kubectl string) string {
+	if strings.HasPrefix(kubectl, "sudo ") {
+		return "sudo k3s"
+	}
+	return "k3s"
+}
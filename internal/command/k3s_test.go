@@ -0,0 +1,97 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pardnchiu/go-podrun/internal/compose"
+	"github.com/pardnchiu/go-podrun/internal/kube"
+)
+
+func TestK3sArgs(t *testing.T) {
+	const base = "kubectl -n podrun-shop"
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"ps"}, base + " get pods -o wide"},
+		{[]string{"logs"}, base + " logs -l " + k3sSelector + " --all-containers --prefix --max-log-requests=20"},
+		{[]string{"logs", "-f", "Web_App"}, base + " logs -l " + k3sSelector + ",app.kubernetes.io/name=web-app --all-containers --prefix --max-log-requests=20 -f"},
+		{[]string{"logs", "--follow"}, base + " logs -l " + k3sSelector + " --all-containers --prefix --max-log-requests=20 -f"},
+		{[]string{"restart"}, base + " rollout restart deployment -l " + k3sSelector},
+		{[]string{"restart", "web", "db"}, base + " rollout restart deployment/web deployment/db"},
+		{[]string{"exec", "web", "sh", "-c", "echo hi"}, base + " exec -it deployment/web -- sh -c 'echo hi'"},
+	}
+	for _, tt := range tests {
+		p := &PodmanArg{Command: tt.args[0], RemoteArgs: tt.args}
+		got, err := p.k3sArgs("kubectl", "podrun-shop")
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%v:\n got  %s\n want %s", tt.args, got, tt.want)
+		}
+	}
+
+	for _, args := range [][]string{{"exec"}, {"exec", "web"}, {"up"}} {
+		p := &PodmanArg{Command: args[0], RemoteArgs: args}
+		if _, err := p.k3sArgs("kubectl", "podrun-shop"); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+// * replicas 取自 deploy.replicas，未指定時為 1，只計算 Deployment
+func TestK3sReplicas(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "docker-compose.yml")
+	if err := os.WriteFile(file, []byte(`
+services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+    deploy:
+      replicas: 3
+  worker:
+    image: busybox
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project, err := compose.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifests, _, err := kube.Generate(project, kube.Options{Namespace: "podrun-shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := k3sReplicas(manifests); got != 4 {
+		t.Fatalf("k3sReplicas = %d, want 4", got)
+	}
+	if got := k3sReplicas(nil); got != 0 {
+		t.Fatalf("k3sReplicas(nil) = %d, want 0", got)
+	}
+}
+
+func TestK3sDownClearCommands(t *testing.T) {
+	if got, want := k3sDownCommand("sudo k3s kubectl", "podrun-shop"),
+		"sudo k3s kubectl -n podrun-shop delete deployment,service,configmap -l "+k3sSelector+" --ignore-not-found"; got != want {
+		t.Errorf("down:\n got  %s\n want %s", got, want)
+	}
+
+	cmds := k3sClearCommands("kubectl", "podrun-shop", "/home/podrun/shop-9f8e7d6c")
+	tests := map[string][2]string{
+		"namespace": {cmds.namespace, "kubectl delete namespace podrun-shop --ignore-not-found"},
+		"images":    {cmds.images, "podman rmi -f $(podman images -q --filter 'reference=localhost/podrun-shop/*') >/dev/null 2>&1 || true"},
+		"folder":    {cmds.folder, "podman run --rm --privileged -v '/home/podrun:/parent' alpine:latest sh -c 'rm -rf /parent/shop-9f8e7d6c'"},
+	}
+	for name, e := range tests {
+		if e[0] != e[1] {
+			t.Errorf("%s:\n got  %s\n want %s", name, e[0], e[1])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/registry"
+)
+
+// * podrun ls [--mine] [--owner=<user>] [--server=<name>] [--json]
+func List(args []string) error {
+	var query registry.PodQuery
+	asJSON := false
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--json":
+			asJSON = true
+		case arg == "--mine":
+			query.Owner = currentUser()
+		case arg == "--owner" && i+1 < len(args):
+			query.Owner = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--owner="):
+			query.Owner = strings.TrimPrefix(arg, "--owner=")
+		case arg == "--server" && i+1 < len(args):
+			query.Server = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--server="):
+			query.Server = strings.TrimPrefix(arg, "--server=")
+		default:
+			return fmt.Errorf("[x] unexpected argument: %s", arg)
+		}
+	}
+
+	pods, err := api().ListPods(context.Background(), query)
+	if err != nil {
+		return fmt.Errorf("[x] failed to list pods: %w", err)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].UpdatedAt.After(pods[j].UpdatedAt)
+	})
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(pods)
+	}
+
+	if len(pods) == 0 {
+		fmt.Println("no deployments")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "UID\tNAME\tSERVER\tTARGET\tSTATUS\tOWNER\tUPDATED\tLOCAL")
+	for _, e := range pods {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.UID, e.PodName, orDash(e.Server), e.Target, e.Status, orDash(e.Owner),
+			e.UpdatedAt.Local().Format(time.DateTime), e.LocalDir)
+	}
+	return w.Flush()
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/pardnchiu/go-podrun/internal/filesync"
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/utils"
+)
+
+func (p *PodmanArg) nativeSync(d *model.Pod) error {
+	client, err := utils.SSHClient()
+	if err != nil {
+		return err
+	}
+
+	ignore, err := syncIgnore(p.LocalDir)
+	if err != nil {
+		return err
+	}
+
+	local, err := filesync.BuildLocal(p.LocalDir, ignore)
+	if err != nil {
+		return fmt.Errorf("build local manifest failed: %w", err)
+	}
+
+	remote, err := filesync.FetchRemote(client, p.RemoteDir, ignore)
+	if err != nil {
+		return fmt.Errorf("fetch remote manifest failed: %w", err)
+	}
+
+	changes := filesync.Diff(local, remote)
+
+	// * 遠端已有檔案且無異動時不留紀錄
+	action := "sync"
+	if len(remote) > 0 {
+		fmt.Println("[*] checking changes")
+		fmt.Println(Hint + "──────────────────────────────────────────────────")
+		printChanges(changes)
+		fmt.Println(Hint + "──────────────────────────────────────────────────" + Reset)
+
+		action = ""
+		if len(changes) > 0 {
+			if err := p.confirmOverwrite(); err != nil {
+				return err
+			}
+			action = "overwrite"
+		}
+	}
+
+	fmt.Println("[*] syncing")
+	fmt.Println(Hint + "──────────────────────────────────────────────────")
+	if len(remote) == 0 {
+		printChanges(changes)
+	}
+	err = filesync.Push(client, p.LocalDir, p.RemoteDir, changes)
+	if action != "" {
+		files := make([]string, len(changes))
+		for i, e := range changes {
+			files[i] = changeLine(e)
+		}
+		p.pending = append(p.pending, pendingRecord{action, syncPayload{Files: files}, err})
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d changed, %d total\n", len(changes), len(local))
+	return nil
+}
+
+func printChanges(changes []filesync.Change) {
+	if len(changes) == 0 {
+		fmt.Println("no changes")
+		return
+	}
+	for _, e := range changes {
+		fmt.Println(changeLine(e))
+	}
+}
+
+// * + 新增、~ 修改、- 刪除
+func changeLine(e filesync.Change) string {
+	mark := "~"
+	switch e.Kind {
+	case filesync.Added:
+		mark = "+"
+	case filesync.Deleted:
+		mark = "-"
+	}
+	path := e.Path
+	if e.Type == 'd' {
+		path += "/"
+	}
+	return mark + " " + path
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/pardnchiu/go-podrun/internal/model"
 	"github.com/pardnchiu/go-podrun/internal/utils"
 )
 
@@ -18,9 +19,19 @@ type PodmanArg struct {
 	File       string
 	Hostname   string
 	IP         string
+	User       string
+	Sync       string
+	Output     string
+	Server     string
+	Overwrite  string
+	Yes        bool
+	NoInput    bool
 
 	// state
-	Detach bool
+	Detach  bool
+	pending []pendingRecord
+	// * registry 分配的 host port
+	allocated []model.Port
 }
 
 func parseArgs(args []string) (*PodmanArg, error) {
@@ -28,6 +39,7 @@ func parseArgs(args []string) (*PodmanArg, error) {
 	conposeExist := false
 
 	newArg.Hostname = utils.GetHostName()
+	newArg.User = utils.GetUserName()
 
 	if ip, err := utils.GetLocalIP(); err == nil {
 		newArg.IP = ip
@@ -46,6 +58,18 @@ func parseArgs(args []string) (*PodmanArg, error) {
 			newArg.Detach = true
 			newArg.RemoteArgs = append(newArg.RemoteArgs, arg)
 			i++
+		case arg == "--yes":
+			newArg.Yes = true
+			i++
+		case arg == "--no-input":
+			newArg.NoInput = true
+			i++
+		case strings.HasPrefix(arg, "--overwrite="):
+			newArg.Overwrite = strings.TrimPrefix(arg, "--overwrite=")
+			i++
+		case arg == "--overwrite" && i+1 < len(args):
+			newArg.Overwrite = args[i+1]
+			i += 2
 		case arg == "-u" && i+1 < len(args):
 			newArg.UID = args[i+1]
 			i += 2
@@ -64,14 +88,26 @@ func parseArgs(args []string) (*PodmanArg, error) {
 		case arg == "--type" && i+1 < len(args):
 			newArg.Target = args[i+1]
 			i += 2
+		case strings.HasPrefix(arg, "--sync="):
+			newArg.Sync = strings.TrimPrefix(arg, "--sync=")
+			i++
+		case arg == "--sync" && i+1 < len(args):
+			newArg.Sync = args[i+1]
+			i += 2
+		case strings.HasPrefix(arg, "--server="):
+			newArg.Server = strings.TrimPrefix(arg, "--server=")
+			i++
+		case arg == "--server" && i+1 < len(args):
+			newArg.Server = args[i+1]
+			i += 2
 		case strings.HasPrefix(arg, "--output="):
-			newArg.RemoteDir = strings.TrimPrefix(arg, "--output=")
+			newArg.Output = strings.TrimPrefix(arg, "--output=")
 			i++
 		case arg == "--output" && i+1 < len(args):
-			newArg.RemoteDir = args[i+1]
+			newArg.Output = args[i+1]
 			i += 2
 		case arg == "-o" && i+1 < len(args):
-			newArg.RemoteDir = args[i+1]
+			newArg.Output = args[i+1]
 			i += 2
 		case arg == "-f" && i+1 < len(args):
 			if newArg.Command == "logs" {
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pardnchiu/go-podrun/internal/compose"
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/utils"
+)
+
+func portKey(service string, containerPort int, protocol string, seq int) string {
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	return fmt.Sprintf("%s/%d/%s/%d", service, containerPort, protocol, seq)
+}
+
+// * 同一個 container port 可對應多次（例如 "80" 與 "8080:80"），依出現順序編號，各自分配 host port
+type portSeq map[string]int
+
+func (s portSeq) next(service string, containerPort int, protocol string) int {
+	key := portKey(service, containerPort, protocol, 0)
+	seq := s[key]
+	s[key]++
+	return seq
+}
+
+// * 向 registry 取得固定的 host port，key 為 portKey；registry 無法連線時回傳 nil，改由 podman 隨機分配
+// * 失敗時提示並記錄，host port 本次不固定
+func (p *PodmanArg) allocatePorts(project *compose.Project) map[string]int {
+	var reqs []model.Port
+	seq := portSeq{}
+	if err := project.RewritePorts(func(service string, port compose.Port) compose.Port {
+		if n := port.TargetNumber(); n > 0 && strconv.Itoa(n) == port.Target {
+			reqs = append(reqs, model.Port{
+				Service:       service,
+				ContainerPort: n,
+				Protocol:      port.Protocol,
+				Seq:           seq.next(service, n, port.Protocol),
+			})
+		}
+		return port
+	}); err != nil || len(reqs) == 0 {
+		return nil
+	}
+
+	ports, err := api().AllocatePorts(context.Background(), p.UID, reqs)
+	if err != nil {
+		fmt.Printf(Warn+"[!] failed to allocate ports, host ports are random for this deploy: %v"+Reset+"\n", err)
+		p.pending = append(p.pending, pendingRecord{"allocate ports", nil, err})
+		return nil
+	}
+
+	p.allocated = ports
+	allocated := make(map[string]int, len(ports))
+	for _, e := range ports {
+		allocated[portKey(e.Service, e.ContainerPort, e.Protocol, e.Seq)] = e.HostPort
+	}
+	return allocated
+}
+
+func (p *PodmanArg) Ports() error {
+	ports, err := api().ListPorts(context.Background(), p.UID)
+	if err != nil {
+		return fmt.Errorf("[x] failed to list ports: %w", err)
+	}
+	if len(ports) == 0 {
+		fmt.Println("[-] no ports allocated, run podrun up first")
+		return nil
+	}
+
+	server := ""
+	if env, err := utils.CheckENV(); err == nil {
+		server = env.Host
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tCONTAINER\tHOST\tADDRESS")
+	for _, e := range ports {
+		fmt.Fprintf(w, "%s\t%d/%s\t%d\t%s:%d\n",
+			e.Service, e.ContainerPort, e.Protocol, e.HostPort, server, e.HostPort)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Println(Hint + "[*] only ports allocated by podrun are tracked; ports bound by other processes on the server are checked on podrun up" + Reset)
+	return nil
+}
+
+// * registry 只知道 podrun 分配的 port，分配前無法得知主機上其他程式佔用的 port；
+// * 舊容器停止後再以 ss 檢查，佔用時提示（podman 會因 port 被佔用而啟動失敗）
+func (p *PodmanArg) checkHostPorts() {
+	if len(p.allocated) == 0 {
+		return
+	}
+	output, err := utils.SSEOutput("ss -Htuln 2>/dev/null")
+	if err != nil {
+		return
+	}
+
+	bound := map[string]bool{}
+	for line := range strings.SplitSeq(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		idx := strings.LastIndex(fields[4], ":")
+		if idx < 0 {
+			continue
+		}
+		bound[fields[0]+"/"+fields[4][idx+1:]] = true
+	}
+
+	for _, e := range p.allocated {
+		if bound[fmt.Sprintf("%s/%d", e.Protocol, e.HostPort)] {
+			fmt.Printf(Warn+"[!] host port %d/%s (%s/%d) is already in use on the server by another process"+Reset+"\n",
+				e.HostPort, e.Protocol, e.Service, e.ContainerPort)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/journal"
+	"github.com/pardnchiu/go-podrun/internal/registry"
+)
+
+// * podrun registry sync|status
+func Registry(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("[x] podrun registry <sync|status>")
+	}
+
+	j := journal.New(journal.DefaultPath())
+
+	switch args[0] {
+	case "status":
+		entries, err := j.Entries()
+		if err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		fmt.Printf("[*] registry: %s\n", api())
+		if h, ok := api().(*registry.HTTP); ok {
+			printIdentity(h)
+		}
+		if len(entries) == 0 {
+			fmt.Println("[-] journal is empty")
+			return nil
+		}
+		fmt.Printf("[*] %d change(s) queued in %s\n", len(entries), j.Path())
+		for _, e := range entries {
+			fmt.Printf("%-20s %-14s %s\n", e.CreatedAt.Local().Format(time.DateTime), e.Op, e.UID)
+		}
+		return nil
+
+	case "sync":
+		entries, err := j.Entries()
+		if err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		if len(entries) == 0 {
+			fmt.Println("[-] journal is empty")
+			return nil
+		}
+		result := flushJournal(j, "")
+		fmt.Printf("[*] applied %d, dropped %d, pending %d\n", result.Applied, len(result.Dropped), result.Pending)
+		if result.Pending > 0 {
+			return fmt.Errorf("[x] %s: %w", api(), result.Err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("[x] unsupported registry command: %s", args[0])
+}
+
+// * 驗證 PODRUN_TOKEN 是否有效
+func printIdentity(h *registry.HTTP) {
+	user, err := h.Me(context.Background())
+	switch {
+	case registry.IsUnauthorized(err):
+		fmt.Printf(Warn+"[!] token rejected: %v"+Reset+"\n", err)
+	case err != nil:
+		fmt.Printf(Warn+"[!] %v"+Reset+"\n", err)
+	case user == nil:
+		fmt.Println("[*] user: (auth disabled)")
+	default:
+		fmt.Printf("[*] user: %s\n", user.Name)
+	}
+}
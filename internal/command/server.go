@@ -0,0 +1,184 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pardnchiu/go-podrun/internal/inventory"
+)
+
+// * podrun server list|add|rm|default
+func Server(args []string) error {
+	inv, err := inventory.Load(inventory.DefaultPath())
+	if err != nil {
+		return fmt.Errorf("[x] %w", err)
+	}
+
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "list", "ls":
+		return listServers(inv)
+
+	case "add":
+		server, setDefault, err := parseServer(args[1:])
+		if err != nil {
+			return err
+		}
+		// * 只檢查名稱是否已定義，不會詢問密碼或執行指令
+		if server.Credential != "" {
+			if _, err := inv.Provider(server.Credential, ""); err != nil {
+				return fmt.Errorf("[x] %w", err)
+			}
+		}
+		_, exists := inv.Servers[server.Name]
+		inv.Servers[server.Name] = server
+		if setDefault || inv.Default == "" {
+			inv.Default = server.Name
+		}
+		if err := inv.Save(); err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		verb := "added"
+		if exists {
+			verb = "updated"
+		}
+		fmt.Printf("[+] %s %s (%s) in %s\n", verb, server.Name, server.Addr(), inv.Path())
+		return nil
+
+	case "rm", "remove":
+		if len(args) < 2 {
+			return fmt.Errorf("[x] podrun server rm <name>")
+		}
+		name := args[1]
+		if _, ok := inv.Servers[name]; !ok {
+			return fmt.Errorf("[x] server not found: %s", name)
+		}
+		delete(inv.Servers, name)
+		if inv.Default == name {
+			inv.Default = ""
+		}
+		if err := inv.Save(); err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		fmt.Printf("[-] removed %s\n", name)
+		return nil
+
+	case "default":
+		if len(args) < 2 {
+			return fmt.Errorf("[x] podrun server default <name>")
+		}
+		if _, ok := inv.Servers[args[1]]; !ok {
+			return fmt.Errorf("[x] server not found: %s", args[1])
+		}
+		inv.Default = args[1]
+		if err := inv.Save(); err != nil {
+			return fmt.Errorf("[x] %w", err)
+		}
+		fmt.Printf("[+] default server is %s\n", args[1])
+		return nil
+	}
+
+	return fmt.Errorf("[x] unsupported server command: %s", action)
+}
+
+// * podrun server add <name> <[user@]host[:port]> [--credential=<name>] [--identity=<path>] [--password-env=<VAR>] [--base-dir=<dir>] [--default]
+func parseServer(args []string) (*inventory.Server, bool, error) {
+	usage := fmt.Errorf("[x] podrun server add <name> <[user@]host[:port]> [--credential=<name>] [--identity=<path>] [--password-env=<VAR>] [--base-dir=<dir>] [--default]")
+	server := &inventory.Server{}
+	setDefault := false
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg, value, hasValue := strings.Cut(args[i], "=")
+		if !hasValue && strings.HasPrefix(arg, "--") && arg != "--default" && i+1 < len(args) {
+			value = args[i+1]
+			i++
+		}
+		switch arg {
+		case "--default":
+			setDefault = true
+		case "--credential":
+			server.Credential = value
+		case "--identity":
+			server.IdentityFile = value
+		case "--password-env":
+			server.PasswordEnv = value
+		case "--base-dir":
+			server.BaseDir = value
+		case "--port":
+			port, err := strconv.Atoi(value)
+			if err != nil || port <= 0 {
+				return nil, false, fmt.Errorf("[x] invalid port: %s", value)
+			}
+			server.Port = port
+		default:
+			if strings.HasPrefix(args[i], "-") {
+				return nil, false, fmt.Errorf("[x] unexpected argument: %s", args[i])
+			}
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) != 2 {
+		return nil, false, usage
+	}
+
+	server.Name = positional[0]
+	server.Host = positional[1]
+	if user, host, ok := strings.Cut(server.Host, "@"); ok {
+		server.User, server.Host = user, host
+	}
+	if server.Name == "" || server.Host == "" {
+		return nil, false, usage
+	}
+	return server, setDefault, nil
+}
+
+// * 本機 inventory 與 registry 中其他成員登錄的主機
+func listServers(inv *inventory.Inventory) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tADDRESS\tUSER\tBASE DIR\tAUTH\tSOURCE")
+	for _, name := range inv.Names() {
+		s := inv.Servers[name]
+		if name == inv.Default {
+			name += " *"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			name, s.Addr(), orDash(s.User), s.RemoteBase(), serverAuth(s), "local")
+	}
+
+	if servers, err := api().ListServers(context.Background()); err == nil {
+		for _, e := range servers {
+			if _, ok := inv.Servers[e.Name]; ok {
+				continue
+			}
+			s := &inventory.Server{Host: e.Host, Port: e.Port, BaseDir: e.BaseDir}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				e.Name, s.Addr(), orDash(e.User), s.RemoteBase(), "-", "registry")
+		}
+	}
+	return w.Flush()
+}
+
+func serverAuth(s *inventory.Server) string {
+	if s.Credential != "" {
+		return s.Credential
+	}
+	var auth []string
+	if s.IdentityFile != "" {
+		auth = append(auth, "identity")
+	}
+	if s.PasswordEnv != "" {
+		auth = append(auth, "$"+s.PasswordEnv)
+	}
+	if len(auth) == 0 {
+		return "env"
+	}
+	return strings.Join(auth, ",")
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/pardnchiu/go-podrun/internal/filesync"
+)
+
+// * podrun sync --list：列出套用排除規則後會同步的檔案，只讀取本機
+func (p *PodmanArg) SyncList() error {
+	if !slices.Contains(p.RemoteArgs[1:], "--list") {
+		return fmt.Errorf("[x] podrun sync --list (files are synced by podrun up)")
+	}
+
+	ignore, err := syncIgnore(p.LocalDir)
+	if err != nil {
+		return fmt.Errorf("[x] %w", err)
+	}
+	local, err := filesync.BuildLocal(p.LocalDir, ignore)
+	if err != nil {
+		return fmt.Errorf("[x] build local manifest failed: %w", err)
+	}
+
+	paths := make([]string, 0, len(local))
+	for path := range local {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	var files, dirs int
+	var size int64
+	for _, path := range paths {
+		e := local[path]
+		if e.Type == 'd' {
+			dirs++
+			path += "/"
+		} else {
+			files++
+			size += e.Size
+		}
+		fmt.Println(path)
+	}
+	// * 統計寫到 stderr，stdout 只有路徑，方便接其他指令
+	fmt.Fprintf(os.Stderr, "[*] %d files, %d directories, %d bytes\n", files, dirs, size)
+	return nil
+}
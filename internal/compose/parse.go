@@ -0,0 +1,196 @@
+package compose
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+type Port struct {
+	HostIP    string
+	Published string
+	Target    string
+	Protocol  string
+}
+
+type Volume struct {
+	Type     string
+	Source   string
+	Target   string
+	Mode     string
+	ReadOnly bool
+}
+
+// * 支援 "80"、"8080:80"、"127.0.0.1:8080:80/udp"、"${PORT:-8080}:80"、"[::1]:8080:80"
+func ParsePort(v any) (Port, error) {
+	switch value := v.(type) {
+	case int, int64, uint64, float64:
+		return Port{Target: fmt.Sprint(value)}, nil
+	case string:
+		spec, proto, _ := strings.Cut(value, "/")
+		parts := splitColon(spec)
+		port := Port{
+			Target:   parts[len(parts)-1],
+			Protocol: proto,
+		}
+		switch len(parts) {
+		case 1:
+		case 2:
+			port.Published = parts[0]
+		default:
+			port.HostIP = strings.Join(parts[:len(parts)-2], ":")
+			port.Published = parts[len(parts)-2]
+		}
+		if port.Target == "" {
+			return Port{}, fmt.Errorf("invalid port: %q", value)
+		}
+		return port, nil
+	}
+	if m, ok := asMap(v); ok {
+		return parseLongPort(m)
+	}
+	return Port{}, fmt.Errorf("unsupported port syntax: %v", v)
+}
+
+func parseLongPort(m map[string]any) (Port, error) {
+	port := Port{
+		Target:    scalar(m["target"]),
+		Published: scalar(m["published"]),
+		HostIP:    scalar(m["host_ip"]),
+		Protocol:  scalar(m["protocol"]),
+	}
+	if port.Target == "" {
+		return Port{}, fmt.Errorf("port target is required")
+	}
+	return port, nil
+}
+
+// * 輸出為 short syntax；只有 host_ip 時為 "127.0.0.1::80"，仍只綁定該位址
+func (p Port) String() string {
+	s := p.Target
+	switch {
+	case p.HostIP != "":
+		s = p.HostIP + ":" + p.Published + ":" + s
+	case p.Published != "":
+		s = p.Published + ":" + s
+	}
+	if p.Protocol != "" {
+		s += "/" + p.Protocol
+	}
+	return s
+}
+
+// * 容器 port 若為範圍（8000-8010）則回傳起始值
+func (p Port) TargetNumber() int {
+	start, _, _ := strings.Cut(p.Target, "-")
+	n, _ := strconv.Atoi(start)
+	return n
+}
+
+func ParseVolume(v any) (Volume, error) {
+	switch value := v.(type) {
+	case string:
+		parts := splitColon(value)
+		switch len(parts) {
+		case 1:
+			return Volume{Type: "volume", Target: parts[0]}, nil
+		case 2, 3:
+			vol := Volume{
+				Source: parts[0],
+				Target: parts[1],
+				Type:   volumeType(parts[0]),
+			}
+			if len(parts) == 3 {
+				vol.Mode = parts[2]
+				vol.ReadOnly = hasOption(vol.Mode, "ro")
+			}
+			return vol, nil
+		}
+		return Volume{}, fmt.Errorf("invalid volume: %q", value)
+	}
+	if m, ok := asMap(v); ok {
+		vol := Volume{
+			Type:     scalar(m["type"]),
+			Source:   scalar(m["source"]),
+			Target:   scalar(m["target"]),
+			ReadOnly: scalar(m["read_only"]) == "true",
+		}
+		if vol.Type == "" {
+			vol.Type = volumeType(vol.Source)
+		}
+		return vol, nil
+	}
+	return Volume{}, fmt.Errorf("unsupported volume syntax: %v", v)
+}
+
+// * 相對路徑的 bind mount（./data、../shared、.）
+func (v Volume) IsRelativeBind() bool {
+	return v.Type == "bind" && (v.Source == "." || v.Source == ".." ||
+		strings.HasPrefix(v.Source, "./") || strings.HasPrefix(v.Source, "../"))
+}
+
+func volumeType(source string) string {
+	if source == "" {
+		return "volume"
+	}
+	if strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") ||
+		strings.HasPrefix(source, "~") || strings.HasPrefix(source, "$") {
+		return "bind"
+	}
+	return "volume"
+}
+
+func hasOption(mode, option string) bool {
+	for e := range strings.SplitSeq(mode, ",") {
+		if e == option {
+			return true
+		}
+	}
+	return false
+}
+
+// * 以 : 分割，但略過 ${...} 與 [...] 內的 :
+func splitColon(s string) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth > 0 {
+				depth--
+			}
+		case ':':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func scalar(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func asMap(v any) (map[string]any, bool) {
+	switch value := v.(type) {
+	case map[string]any:
+		return value, true
+	case yaml.MapSlice:
+		m := make(map[string]any, len(value))
+		for _, e := range value {
+			m[fmt.Sprint(e.Key)] = e.Value
+		}
+		return m, true
+	}
+	return nil, false
+}
@@ -0,0 +1,312 @@
+package compose
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+const PodrunFile = "docker-compose.podrun.yml"
+
+type Project struct {
+	Path string
+	doc  yaml.MapSlice
+	// * 原檔的註解，依 YAML path 在輸出時放回
+	comments yaml.CommentMap
+}
+
+// * 指定 -f 時使用該檔案，否則依序尋找 docker-compose.yml / docker-compose.yaml
+func Find(dir, file string) (string, error) {
+	if file != "" {
+		path := filepath.Join(dir, filepath.Base(file))
+		if _, err := os.Stat(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+	for _, name := range []string{"docker-compose.yml", "docker-compose.yaml"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("docker-compose.yml or docker-compose.yaml not found")
+}
+
+func Load(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.MapSlice
+	comments := yaml.CommentMap{}
+	if err := yaml.UnmarshalWithOptions(data, &doc, yaml.UseOrderedMap(), yaml.CommentToMap(comments)); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	return &Project{Path: path, doc: doc, comments: comments}, nil
+}
+
+// * 優先使用頂層 name，否則為 compose 檔所在目錄名稱
+func (p *Project) Name() string {
+	if name := p.DeclaredName(); name != "" {
+		return name
+	}
+	return filepath.Base(filepath.Dir(p.Path))
+}
+
+// * 頂層 name，未設定時為空字串
+func (p *Project) DeclaredName() string {
+	return scalar(get(p.doc, "name"))
+}
+
+func (p *Project) ServiceNames() []string {
+	services, _ := get(p.doc, "services").(yaml.MapSlice)
+	names := make([]string, 0, len(services))
+	for _, e := range services {
+		names = append(names, fmt.Sprint(e.Key))
+	}
+	return names
+}
+
+func (p *Project) service(name string) (yaml.MapSlice, bool) {
+	services, _ := get(p.doc, "services").(yaml.MapSlice)
+	service, ok := get(services, name).(yaml.MapSlice)
+	return service, ok
+}
+
+func (p *Project) HasService(name string) bool {
+	_, ok := p.service(name)
+	return ok
+}
+
+// * 依序回傳 ports 與 expose 中的容器 port
+func (p *Project) ContainerPorts(name string) []int {
+	service, ok := p.service(name)
+	if !ok {
+		return nil
+	}
+
+	var ports []int
+	items, _ := get(service, "ports").([]any)
+	for _, item := range items {
+		if port, err := ParsePort(item); err == nil && port.TargetNumber() > 0 {
+			ports = append(ports, port.TargetNumber())
+		}
+	}
+	exposes, _ := get(service, "expose").([]any)
+	for _, item := range exposes {
+		if port, err := ParsePort(item); err == nil && port.TargetNumber() > 0 {
+			ports = append(ports, port.TargetNumber())
+		}
+	}
+	return ports
+}
+
+// * labels 支援 list（"key=value"）與 map 兩種寫法，同名 label 會被覆蓋
+func (p *Project) AddLabels(name string, labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return p.eachService(func(service string, m yaml.MapSlice) (yaml.MapSlice, error) {
+		if service != name {
+			return m, nil
+		}
+
+		switch existing := get(m, "labels").(type) {
+		case []any:
+			for _, k := range keys {
+				existing = slices.DeleteFunc(existing, func(e any) bool {
+					key, _, _ := strings.Cut(fmt.Sprint(e), "=")
+					return key == k
+				})
+				existing = append(existing, k+"="+labels[k])
+			}
+			return set(m, "labels", existing), nil
+		default:
+			mapped, _ := existing.(yaml.MapSlice)
+			for _, k := range keys {
+				mapped = set(mapped, k, labels[k])
+			}
+			return set(m, "labels", mapped), nil
+		}
+	})
+}
+
+// * 加入外部 network；原本未指定 networks 的服務需保留 default
+func (p *Project) AddExternalNetwork(name, network string) error {
+	networks, _ := get(p.doc, "networks").(yaml.MapSlice)
+	if get(networks, network) == nil {
+		networks = set(networks, network, yaml.MapSlice{{Key: "external", Value: true}})
+	}
+	p.doc = set(p.doc, "networks", networks)
+
+	return p.eachService(func(service string, m yaml.MapSlice) (yaml.MapSlice, error) {
+		if service != name {
+			return m, nil
+		}
+
+		switch existing := get(m, "networks").(type) {
+		case nil:
+			if get(m, "network_mode") == nil {
+				m = set(m, "networks", []any{"default", network})
+			}
+		case []any:
+			if !slices.Contains(existing, any(network)) {
+				m = set(m, "networks", append(existing, network))
+			}
+		case yaml.MapSlice:
+			if get(existing, network) == nil {
+				m = set(m, "networks", append(existing, yaml.MapItem{Key: network, Value: nil}))
+			}
+		}
+		return m, nil
+	})
+}
+
+// * fn 回傳調整後的 Port；long syntax 僅更新 published / host_ip，其餘欄位保留
+func (p *Project) RewritePorts(fn func(service string, port Port) Port) error {
+	return p.eachService(func(name string, service yaml.MapSlice) (yaml.MapSlice, error) {
+		ports, ok := get(service, "ports").([]any)
+		if !ok {
+			return service, nil
+		}
+
+		for i, item := range ports {
+			port, err := ParsePort(item)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", name, err)
+			}
+			port = fn(name, port)
+
+			long, isLong := item.(yaml.MapSlice)
+			if !isLong {
+				ports[i] = port.String()
+				continue
+			}
+			// * 已存在的欄位就地更新，保留原本的順序
+			if port.Published != "" {
+				long = set(long, "published", port.Published)
+			} else {
+				long = unset(long, "published")
+			}
+			if port.HostIP != "" {
+				long = set(long, "host_ip", port.HostIP)
+			} else {
+				long = unset(long, "host_ip")
+			}
+			ports[i] = long
+		}
+		return set(service, "ports", ports), nil
+	})
+}
+
+// * 相對路徑的 bind mount 加上 SELinux :z 標記
+func (p *Project) RelabelVolumes() error {
+	return p.eachService(func(name string, service yaml.MapSlice) (yaml.MapSlice, error) {
+		volumes, ok := get(service, "volumes").([]any)
+		if !ok {
+			return service, nil
+		}
+
+		for i, item := range volumes {
+			vol, err := ParseVolume(item)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", name, err)
+			}
+			if !vol.IsRelativeBind() {
+				continue
+			}
+
+			if long, isLong := item.(yaml.MapSlice); isLong {
+				bind, _ := get(long, "bind").(yaml.MapSlice)
+				if get(bind, "selinux") == nil {
+					volumes[i] = set(long, "bind", set(bind, "selinux", "z"))
+				}
+				continue
+			}
+
+			switch {
+			case vol.Mode == "":
+				volumes[i] = fmt.Sprintf("%s:%s:z", vol.Source, vol.Target)
+			case !hasOption(vol.Mode, "z") && !hasOption(vol.Mode, "Z"):
+				volumes[i] = fmt.Sprintf("%s:%s:%s,z", vol.Source, vol.Target, vol.Mode)
+			}
+		}
+		return set(service, "volumes", volumes), nil
+	})
+}
+
+// * 字串一律加上引號，避免 "22:22" 這類值被 YAML 1.1 解析為 60 進位數字
+func (p *Project) Marshal() ([]byte, error) {
+	body, err := yaml.MarshalWithOptions(p.doc,
+		yaml.IndentSequence(true),
+		yaml.WithComment(p.comments),
+		yaml.CustomMarshaler[string](func(s string) ([]byte, error) {
+			return json.Marshal(s)
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	header := fmt.Sprintf("# generated by podrun from %s, do not edit\n", filepath.Base(p.Path))
+	return append([]byte(header), body...), nil
+}
+
+func (p *Project) eachService(fn func(name string, service yaml.MapSlice) (yaml.MapSlice, error)) error {
+	services, ok := get(p.doc, "services").(yaml.MapSlice)
+	if !ok {
+		return fmt.Errorf("services not found in %s", filepath.Base(p.Path))
+	}
+
+	for i, e := range services {
+		service, ok := e.Value.(yaml.MapSlice)
+		if !ok {
+			continue
+		}
+		updated, err := fn(fmt.Sprint(e.Key), service)
+		if err != nil {
+			return err
+		}
+		services[i].Value = updated
+	}
+	p.doc = set(p.doc, "services", services)
+	return nil
+}
+
+func get(m yaml.MapSlice, key string) any {
+	for _, e := range m {
+		if fmt.Sprint(e.Key) == key {
+			return e.Value
+		}
+	}
+	return nil
+}
+
+func set(m yaml.MapSlice, key string, value any) yaml.MapSlice {
+	for i, e := range m {
+		if fmt.Sprint(e.Key) == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
+
+func unset(m yaml.MapSlice, key string) yaml.MapSlice {
+	for i, e := range m {
+		if fmt.Sprint(e.Key) == key {
+			return append(m[:i], m[i+1:]...)
+		}
+	}
+	return m
+}
@@ -0,0 +1,130 @@
+package compose
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden.yml")
+
+// * 與 podrun up 相同的改寫：清除 published、保留 host_ip，web:80 模擬 registry 依出現順序分配的固定 port
+func rewrite(p *Project) error {
+	next := 20080
+	if err := p.RewritePorts(func(service string, port Port) Port {
+		port.Published = ""
+		if service == "web" && port.Target == "80" {
+			port.Published = strconv.Itoa(next)
+			next++
+		}
+		return port
+	}); err != nil {
+		return err
+	}
+	return p.RelabelVolumes()
+}
+
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, input := range inputs {
+		if strings.HasSuffix(input, ".golden.yml") {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(input), ".yml")
+		t.Run(name, func(t *testing.T) {
+			p, err := Load(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := rewrite(p); err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", name+".golden.yml")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s mismatch (run go test -update to regenerate)\n--- got\n%s\n--- want\n%s", golden, got, want)
+			}
+
+			// * 輸出需可再次解析，且改寫結果不變
+			path := filepath.Join(t.TempDir(), "docker-compose.yml")
+			if err := os.WriteFile(path, got, 0644); err != nil {
+				t.Fatal(err)
+			}
+			again, err := Load(path)
+			if err != nil {
+				t.Fatalf("generated file does not parse: %v", err)
+			}
+			if err := rewrite(again); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := again.Marshal(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		in   any
+		want Port
+	}{
+		{"80", Port{Target: "80"}},
+		{8080, Port{Target: "8080"}},
+		{"8080:80", Port{Published: "8080", Target: "80"}},
+		{"127.0.0.1:5432:5432", Port{HostIP: "127.0.0.1", Published: "5432", Target: "5432"}},
+		{"127.0.0.1::80", Port{HostIP: "127.0.0.1", Target: "80"}},
+		{"[::1]:6432:6432", Port{HostIP: "[::1]", Published: "6432", Target: "6432"}},
+		{"${WEB_PORT:-8080}:80", Port{Published: "${WEB_PORT:-8080}", Target: "80"}},
+		{"53:53/udp", Port{Published: "53", Target: "53", Protocol: "udp"}},
+		{"8000-8010:8000-8010", Port{Published: "8000-8010", Target: "8000-8010"}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePort(tt.in)
+		if err != nil {
+			t.Errorf("ParsePort(%v): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePort(%v) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPortString(t *testing.T) {
+	tests := []struct {
+		in   Port
+		want string
+	}{
+		{Port{Target: "80"}, "80"},
+		{Port{Published: "8080", Target: "80"}, "8080:80"},
+		{Port{HostIP: "127.0.0.1", Target: "5432"}, "127.0.0.1::5432"},
+		{Port{HostIP: "127.0.0.1", Published: "5432", Target: "5432", Protocol: "tcp"}, "127.0.0.1:5432:5432/tcp"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package compose
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+type Service struct {
+	Name        string
+	Image       string
+	Build       *Build
+	Command     []string
+	Entrypoint  []string
+	Environment []EnvVar
+	EnvFiles    []string
+	Ports       []Port
+	Expose      []Port
+	Volumes     []Volume
+	Healthcheck *Healthcheck
+	Replicas    int
+	WorkingDir  string
+	Restart     string
+	// * 原始欄位名稱，供呼叫端判斷不支援的設定
+	Keys []string
+}
+
+type Build struct {
+	Context    string
+	Dockerfile string
+}
+
+type EnvVar struct {
+	Name  string
+	Value string
+	// * 只有名稱沒有值（例如 - API_KEY），代表沿用執行環境的變數
+	Inherit bool
+}
+
+type Healthcheck struct {
+	Test        []string
+	Shell       bool
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+	Disable     bool
+}
+
+func (p *Project) Services() ([]Service, error) {
+	var services []Service
+	for _, name := range p.ServiceNames() {
+		raw, ok := p.service(name)
+		if !ok {
+			continue
+		}
+		svc, err := parseService(name, raw)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		services = append(services, svc)
+	}
+	return services, nil
+}
+
+// * 頂層 volumes 宣告的 named volume
+func (p *Project) NamedVolumes() []string {
+	volumes, _ := get(p.doc, "volumes").(yaml.MapSlice)
+	names := make([]string, 0, len(volumes))
+	for _, e := range volumes {
+		names = append(names, fmt.Sprint(e.Key))
+	}
+	return names
+}
+
+func parseService(name string, raw yaml.MapSlice) (Service, error) {
+	svc := Service{
+		Name:       name,
+		Image:      scalar(get(raw, "image")),
+		Build:      parseBuild(get(raw, "build")),
+		WorkingDir: scalar(get(raw, "working_dir")),
+		Restart:    scalar(get(raw, "restart")),
+		Replicas:   1,
+	}
+	for _, e := range raw {
+		svc.Keys = append(svc.Keys, fmt.Sprint(e.Key))
+	}
+
+	var err error
+	if svc.Command, err = stringList(get(raw, "command")); err != nil {
+		return svc, fmt.Errorf("command: %w", err)
+	}
+	if svc.Entrypoint, err = stringList(get(raw, "entrypoint")); err != nil {
+		return svc, fmt.Errorf("entrypoint: %w", err)
+	}
+	svc.Environment = parseEnvironment(get(raw, "environment"))
+	svc.EnvFiles = parseEnvFiles(get(raw, "env_file"))
+
+	items, _ := get(raw, "ports").([]any)
+	for _, item := range items {
+		port, err := ParsePort(item)
+		if err != nil {
+			return svc, err
+		}
+		svc.Ports = append(svc.Ports, port)
+	}
+	items, _ = get(raw, "expose").([]any)
+	for _, item := range items {
+		port, err := ParsePort(item)
+		if err != nil {
+			return svc, err
+		}
+		svc.Expose = append(svc.Expose, port)
+	}
+	items, _ = get(raw, "volumes").([]any)
+	for _, item := range items {
+		vol, err := ParseVolume(item)
+		if err != nil {
+			return svc, err
+		}
+		svc.Volumes = append(svc.Volumes, vol)
+	}
+
+	if hc, ok := get(raw, "healthcheck").(yaml.MapSlice); ok {
+		if svc.Healthcheck, err = parseHealthcheck(hc); err != nil {
+			return svc, fmt.Errorf("healthcheck: %w", err)
+		}
+	}
+
+	if deploy, ok := get(raw, "deploy").(yaml.MapSlice); ok {
+		if n, err := strconv.Atoi(scalar(get(deploy, "replicas"))); err == nil {
+			svc.Replicas = n
+		}
+	}
+	return svc, nil
+}
+
+// * 支援 build: ./app 與 build: {context, dockerfile}
+func parseBuild(v any) *Build {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return &Build{Context: value}
+	}
+	m, ok := asMap(v)
+	if !ok {
+		return nil
+	}
+	build := &Build{
+		Context:    scalar(m["context"]),
+		Dockerfile: scalar(m["dockerfile"]),
+	}
+	if build.Context == "" {
+		build.Context = "."
+	}
+	return build
+}
+
+func parseEnvironment(v any) []EnvVar {
+	var env []EnvVar
+	switch value := v.(type) {
+	case []any:
+		for _, item := range value {
+			k, val, ok := strings.Cut(fmt.Sprint(item), "=")
+			env = append(env, EnvVar{Name: k, Value: val, Inherit: !ok})
+		}
+	case yaml.MapSlice:
+		for _, e := range value {
+			env = append(env, EnvVar{
+				Name:    fmt.Sprint(e.Key),
+				Value:   scalar(e.Value),
+				Inherit: e.Value == nil,
+			})
+		}
+	}
+	return env
+}
+
+func parseEnvFiles(v any) []string {
+	switch value := v.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		var files []string
+		for _, item := range value {
+			if m, ok := asMap(item); ok {
+				files = append(files, scalar(m["path"]))
+				continue
+			}
+			files = append(files, fmt.Sprint(item))
+		}
+		return files
+	}
+	return nil
+}
+
+func parseHealthcheck(raw yaml.MapSlice) (*Healthcheck, error) {
+	hc := &Healthcheck{
+		Disable: scalar(get(raw, "disable")) == "true",
+	}
+
+	switch test := get(raw, "test").(type) {
+	case string:
+		hc.Test = []string{test}
+		hc.Shell = true
+	case []any:
+		for _, e := range test {
+			hc.Test = append(hc.Test, fmt.Sprint(e))
+		}
+		if len(hc.Test) > 0 {
+			switch hc.Test[0] {
+			case "NONE":
+				hc.Disable = true
+			case "CMD-SHELL":
+				hc.Shell = true
+				hc.Test = []string{strings.Join(hc.Test[1:], " ")}
+			case "CMD":
+				hc.Test = hc.Test[1:]
+			}
+		}
+	}
+
+	var err error
+	if hc.Interval, err = duration(get(raw, "interval")); err != nil {
+		return nil, err
+	}
+	if hc.Timeout, err = duration(get(raw, "timeout")); err != nil {
+		return nil, err
+	}
+	if hc.StartPeriod, err = duration(get(raw, "start_period")); err != nil {
+		return nil, err
+	}
+	hc.Retries, _ = strconv.Atoi(scalar(get(raw, "retries")))
+	return hc, nil
+}
+
+func duration(v any) (time.Duration, error) {
+	s := scalar(v)
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// * string 形式依 shell 規則切割（compose 的 command: npm run start）
+func stringList(v any) ([]string, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return shellSplit(value)
+	case []any:
+		list := make([]string, len(value))
+		for i, e := range value {
+			list[i] = fmt.Sprint(e)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("unsupported value: %v", v)
+}
+
+func shellSplit(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				continue
+			}
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
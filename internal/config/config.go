@@ -0,0 +1,258 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+// * 設定來源，由低至高：default < global < .env < project < env；指令旗標（--server、--sync）再覆蓋全部
+// * .env 視為檔案設定，低於專案的 podrun.yaml，避免遺留的 .env 蓋過專案設定
+const (
+	LayerDefault = "default"
+	LayerGlobal  = "global"
+	LayerProject = "project"
+	LayerDotenv  = ".env"
+	LayerEnv     = "env"
+)
+
+// * 設定檔中的名稱與對應的環境變數；程式其他部分仍只讀取環境變數
+type Key struct {
+	Name string
+	Env  string
+	// * 不可寫入專案設定檔，避免隨專案提交
+	Secret bool
+}
+
+var Keys = []Key{
+	{Name: "server", Env: "PODRUN_SERVER"},
+	{Name: "username", Env: "PODRUN_USERNAME"},
+	{Name: "password", Env: "PODRUN_PASSWORD", Secret: true},
+	{Name: "identity", Env: "PODRUN_IDENTITY"},
+	{Name: "credential", Env: "PODRUN_CREDENTIAL"},
+	{Name: "user", Env: "PODRUN_USER"},
+	{Name: "sync", Env: "PODRUN_SYNC"},
+	{Name: "gitignore", Env: "PODRUN_GITIGNORE"},
+	{Name: "overwrite", Env: "PODRUN_OVERWRITE"},
+	{Name: "no_input", Env: "PODRUN_NO_INPUT"},
+	{Name: "proxy_network", Env: "PODRUN_PROXY_NETWORK"},
+	{Name: "kubectl", Env: "PODRUN_KUBECTL"},
+	{Name: "registry", Env: "PODRUN_REGISTRY"},
+	{Name: "api", Env: "PODRUN_API"},
+	{Name: "token", Env: "PODRUN_TOKEN", Secret: true},
+	{Name: "db_path", Env: "DB_PATH"},
+	{Name: "port_range", Env: "PORT_RANGE"},
+	{Name: "api_auth", Env: "API_AUTH"},
+	{Name: "reconcile_interval", Env: "RECONCILE_INTERVAL"},
+	{Name: "reconcile_concurrency", Env: "RECONCILE_CONCURRENCY"},
+}
+
+// * 接受設定檔名稱或環境變數名稱
+func Lookup(name string) (Key, bool) {
+	for _, k := range Keys {
+		if strings.EqualFold(name, k.Name) || name == k.Env {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+type Value struct {
+	Key   Key
+	Value string
+	Layer string
+	// * 設定檔路徑或環境變數名稱
+	Source string
+}
+
+func (v Value) Origin() string {
+	if v.Source == "" {
+		return v.Layer
+	}
+	return fmt.Sprintf("%s (%s)", v.Layer, v.Source)
+}
+
+// * $XDG_CONFIG_HOME/podrun/config.toml，未設定時為 ~/.config/podrun/config.toml
+func GlobalPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "podrun", "config.toml")
+}
+
+const ProjectFile = "podrun.yaml"
+
+// * 由 dir 往上尋找含 podrun.yaml 或 compose 檔的資料夾，找不到時回傳空字串
+func FindProject(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		for _, name := range []string{ProjectFile, "docker-compose.yml", "docker-compose.yaml"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// * 專案資料夾的 .env；沒有專案時沿用舊行為，由目前資料夾讀取
+func dotenvPath(dir string) string {
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	if project := FindProject(dir); project != "" {
+		dir = project
+	}
+	return filepath.Join(dir, ".env")
+}
+
+// * dir 為空時使用目前資料夾
+func Resolve(dir string) ([]Value, error) {
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	values := make([]Value, len(Keys))
+	for i, k := range Keys {
+		values[i] = Value{Key: k, Layer: LayerDefault}
+	}
+	set := func(layer, source string, file map[string]string) {
+		for i, k := range Keys {
+			if value, ok := file[k.Name]; ok {
+				values[i] = Value{Key: k, Value: value, Layer: layer, Source: source}
+			}
+		}
+	}
+
+	global := GlobalPath()
+	file, err := readFile(global)
+	if err != nil {
+		return nil, err
+	}
+	set(LayerGlobal, global, file)
+
+	project := FindProject(dir)
+	dotenv := dotenvPath(dir)
+	if env, err := godotenv.Read(dotenv); err == nil {
+		file := map[string]string{}
+		for _, k := range Keys {
+			if value, ok := env[k.Env]; ok && value != "" {
+				file[k.Name] = value
+			}
+		}
+		set(LayerDotenv, dotenv, file)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("parse %s: %w", dotenv, err)
+	}
+
+	if project != "" {
+		path := filepath.Join(project, ProjectFile)
+		if file, err = readFile(path); err != nil {
+			return nil, err
+		}
+		// * 專案設定檔會隨專案提交，不接受密碼與 token
+		for _, k := range Keys {
+			if _, ok := file[k.Name]; ok && k.Secret {
+				return nil, fmt.Errorf("parse %s: %s is a secret and cannot be stored in %s, use the global config or %s instead", path, k.Name, ProjectFile, k.Env)
+			}
+		}
+		set(LayerProject, path, file)
+	}
+
+	for i, k := range Keys {
+		if value := environ()[k.Env]; value != "" {
+			values[i] = Value{Key: k, Value: value, Layer: LayerEnv, Source: k.Env}
+		}
+	}
+	return values, nil
+}
+
+var (
+	mu sync.Mutex
+	// * 第一次 Apply 前的環境變數，用來區分使用者設定與 Apply 寫入的值
+	original map[string]string
+	applied  = map[string]bool{}
+	// * 由 .env 載入、不屬於 Keys 的環境變數（例如 inventory 的 password_env、ALLOW_EMAILS）
+	extra = map[string]bool{}
+)
+
+func environ() map[string]string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if original == nil {
+		original = map[string]string{}
+		for _, k := range Keys {
+			original[k.Env] = os.Getenv(k.Env)
+		}
+	}
+	return original
+}
+
+// * 將各層設定寫入環境變數；可依專案資料夾重新套用，只覆寫先前由 Apply 寫入的值
+// * .env 中不屬於 Keys 的變數與 godotenv.Load 相同，以最低優先權載入，不覆蓋既有的環境變數
+func Apply(dir string) error {
+	values, err := Resolve(dir)
+	if err != nil {
+		return err
+	}
+	env, err := godotenv.Read(dotenvPath(dir))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		switch {
+		case v.Layer == LayerEnv:
+			continue
+		case v.Value != "":
+			os.Setenv(v.Key.Env, v.Value)
+			applied[v.Key.Env] = true
+		case applied[v.Key.Env]:
+			os.Unsetenv(v.Key.Env)
+			delete(applied, v.Key.Env)
+		}
+	}
+
+	for name, value := range env {
+		if isKey(name) {
+			continue
+		}
+		if _, ok := os.LookupEnv(name); ok && !extra[name] {
+			continue
+		}
+		os.Setenv(name, value)
+		extra[name] = true
+	}
+	for name := range extra {
+		if _, ok := env[name]; !ok {
+			os.Unsetenv(name)
+			delete(extra, name)
+		}
+	}
+	return nil
+}
+
+func isKey(env string) bool {
+	for _, k := range Keys {
+		if k.Env == env {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// * 以暫存 HOME 與空的 PODRUN_* 環境變數執行，回傳專案資料夾
+func isolate(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	for _, k := range Keys {
+		t.Setenv(k.Env, "")
+	}
+	reset := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		original, applied, extra = nil, map[string]bool{}, map[string]bool{}
+	}
+	reset()
+	t.Cleanup(reset)
+
+	project := filepath.Join(home, "project")
+	if err := os.MkdirAll(filepath.Join(project, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(project, "docker-compose.yml"), "services: {}\n")
+	return project
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func resolved(t *testing.T, dir, name string) Value {
+	t.Helper()
+
+	values, err := Resolve(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range values {
+		if v.Key.Name == name {
+			return v
+		}
+	}
+	t.Fatalf("key %s not resolved", name)
+	return Value{}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	layers := map[string]func(t *testing.T, project string){
+		LayerGlobal: func(t *testing.T, project string) {
+			writeTestFile(t, GlobalPath(), "server = \"from-global\"\n")
+		},
+		LayerDotenv: func(t *testing.T, project string) {
+			writeTestFile(t, filepath.Join(project, ".env"), "PODRUN_SERVER=from-dotenv\n")
+		},
+		LayerProject: func(t *testing.T, project string) {
+			writeTestFile(t, filepath.Join(project, ProjectFile), "server: from-project\n")
+		},
+		LayerEnv: func(t *testing.T, project string) {
+			t.Setenv("PODRUN_SERVER", "from-env")
+		},
+	}
+
+	tests := []struct {
+		name   string
+		layers []string
+		want   string
+		layer  string
+	}{
+		{"default", nil, "", LayerDefault},
+		{"global over default", []string{LayerGlobal}, "from-global", LayerGlobal},
+		{".env over global", []string{LayerGlobal, LayerDotenv}, "from-dotenv", LayerDotenv},
+		{"project over .env", []string{LayerGlobal, LayerDotenv, LayerProject}, "from-project", LayerProject},
+		{"project over global", []string{LayerGlobal, LayerProject}, "from-project", LayerProject},
+		{"env over all", []string{LayerGlobal, LayerDotenv, LayerProject, LayerEnv}, "from-env", LayerEnv},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := isolate(t)
+			for _, layer := range tt.layers {
+				layers[layer](t, project)
+			}
+			// * 由子資料夾向上找到專案
+			v := resolved(t, filepath.Join(project, "sub"), "server")
+			if v.Value != tt.want || v.Layer != tt.layer {
+				t.Fatalf("server = %q from %s, want %q from %s", v.Value, v.Layer, tt.want, tt.layer)
+			}
+		})
+	}
+}
+
+// * 沒有專案時 .env 由目前資料夾讀取
+func TestResolveDotenvWithoutProject(t *testing.T) {
+	isolate(t)
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, ".env"), "PODRUN_SYNC=native\nUNRELATED=1\n")
+
+	if v := resolved(t, dir, "sync"); v.Value != "native" || v.Layer != LayerDotenv {
+		t.Fatalf("sync = %+v", v)
+	}
+}
+
+func TestResolveRejectsSecrets(t *testing.T) {
+	for _, key := range []string{"password", "token"} {
+		t.Run(key, func(t *testing.T) {
+			project := isolate(t)
+			writeTestFile(t, filepath.Join(project, ProjectFile), key+": hunter2\n")
+
+			_, err := Resolve(project)
+			if err == nil || !strings.Contains(err.Error(), "is a secret") {
+				t.Fatalf("got %v, want a secret error", err)
+			}
+		})
+	}
+
+	// * 全域設定可保存密碼
+	project := isolate(t)
+	writeTestFile(t, GlobalPath(), "password = \"hunter2\"\n")
+	if v := resolved(t, project, "password"); v.Value != "hunter2" || v.Layer != LayerGlobal {
+		t.Fatalf("password = %+v", v)
+	}
+	if _, err := SetProject(project, "token", "abc"); err == nil {
+		t.Fatal("SetProject accepted a token")
+	}
+}
+
+func TestResolveInvalidFiles(t *testing.T) {
+	tests := map[string]string{
+		"unknown key":   "servers = \"prod\"\n",
+		"nested value":  "[server]\nname = \"prod\"\n",
+		"invalid toml":  "server = \n",
+		"env var names": "PODRUN_SERVER = \"prod\"\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			project := isolate(t)
+			writeTestFile(t, GlobalPath(), content)
+			if _, err := Resolve(project); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+// * 重新套用其他專案時，只移除先前由 Apply 寫入的值，不動使用者的環境變數
+func TestApply(t *testing.T) {
+	project := isolate(t)
+	t.Setenv("PODRUN_USER", "from-env")
+	writeTestFile(t, filepath.Join(project, ProjectFile), "server: prod\nuser: from-project\n")
+
+	if err := Apply(project); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("PODRUN_SERVER"); got != "prod" {
+		t.Fatalf("PODRUN_SERVER = %q", got)
+	}
+	if got := os.Getenv("PODRUN_USER"); got != "from-env" {
+		t.Fatalf("PODRUN_USER = %q, want the environment value", got)
+	}
+
+	other := t.TempDir()
+	writeTestFile(t, filepath.Join(other, ProjectFile), "sync: rsync\n")
+	if err := Apply(other); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("PODRUN_SERVER"); got != "" {
+		t.Fatalf("PODRUN_SERVER = %q after switching project", got)
+	}
+	if got := os.Getenv("PODRUN_SYNC"); got != "rsync" {
+		t.Fatalf("PODRUN_SYNC = %q", got)
+	}
+	if got := os.Getenv("PODRUN_USER"); got != "from-env" {
+		t.Fatalf("PODRUN_USER = %q after switching project", got)
+	}
+}
+
+// * .env 中不屬於 Keys 的變數仍會載入，但不覆蓋使用者的環境變數
+func TestApplyDotenvExtras(t *testing.T) {
+	project := isolate(t)
+	t.Setenv("PROD_PASSWORD", "")
+	os.Unsetenv("PROD_PASSWORD")
+	t.Setenv("ALLOW_EMAILS", "from-env@example.com")
+	writeTestFile(t, filepath.Join(project, ".env"), "PROD_PASSWORD=hunter2\nALLOW_EMAILS=from-dotenv@example.com\nPODRUN_SYNC=native\n")
+
+	if err := Apply(project); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("PROD_PASSWORD"); got != "hunter2" {
+		t.Fatalf("PROD_PASSWORD = %q", got)
+	}
+	if got := os.Getenv("ALLOW_EMAILS"); got != "from-env@example.com" {
+		t.Fatalf("ALLOW_EMAILS = %q, want the environment value", got)
+	}
+	if got := os.Getenv("PODRUN_SYNC"); got != "native" {
+		t.Fatalf("PODRUN_SYNC = %q", got)
+	}
+
+	// * 切換到沒有 .env 的專案時移除先前載入的值
+	if err := Apply(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := os.LookupEnv("PROD_PASSWORD"); ok {
+		t.Fatal("PROD_PASSWORD still set after switching project")
+	}
+	if got := os.Getenv("ALLOW_EMAILS"); got != "from-env@example.com" {
+		t.Fatalf("ALLOW_EMAILS = %q after switching project", got)
+	}
+}
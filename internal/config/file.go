@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// * 依副檔名以 TOML 或 YAML 解析；檔案不存在時回傳空設定
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]any{}
+	if isTOML(path) {
+		err = toml.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	file := make(map[string]string, len(raw))
+	for name, value := range raw {
+		k, ok := Lookup(name)
+		if !ok || k.Name != name {
+			return nil, fmt.Errorf("parse %s: unknown key %s", path, name)
+		}
+		switch value.(type) {
+		case map[string]any, []any:
+			return nil, fmt.Errorf("parse %s: %s must be a single value", path, name)
+		case nil:
+			continue
+		}
+		file[name] = fmt.Sprint(value)
+	}
+	return file, nil
+}
+
+// * 改寫整個檔案，原有的註解不會保留；value 為空時移除該設定
+func writeFile(path string, key Key, value string) error {
+	file, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		delete(file, key.Name)
+	} else {
+		file[key.Name] = value
+	}
+
+	var data []byte
+	if isTOML(path) {
+		data, err = toml.Marshal(file)
+	} else if len(file) > 0 {
+		data, err = yaml.Marshal(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	// * 全域設定可能含密碼與 token，只允許本人讀取
+	perm, dirPerm := os.FileMode(0644), os.FileMode(0755)
+	if isTOML(path) {
+		perm, dirPerm = 0600, 0700
+	}
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+func isTOML(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// * 寫入全域設定檔
+func SetGlobal(name, value string) (string, error) {
+	k, ok := Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("unknown key: %s", name)
+	}
+	path := GlobalPath()
+	return path, writeFile(path, k, value)
+}
+
+// * 寫入 dir 所屬專案的 podrun.yaml；密碼與 token 只能放在全域設定
+func SetProject(dir, name, value string) (string, error) {
+	k, ok := Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("unknown key: %s", name)
+	}
+	if k.Secret && value != "" {
+		return "", fmt.Errorf("%s is a secret and cannot be stored in %s, use the global config instead", k.Name, ProjectFile)
+	}
+	project := FindProject(dir)
+	if project == "" {
+		return "", fmt.Errorf("no project found: %s or docker-compose.yml is required in %s or a parent folder", ProjectFile, dir)
+	}
+	path := filepath.Join(project, ProjectFile)
+	return path, writeFile(path, k, value)
+}
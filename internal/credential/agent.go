@@ -0,0 +1,22 @@
+package credential
+
+import (
+	"fmt"
+	"os"
+)
+
+// * 使用 SSH_AUTH_SOCK 指向的 ssh-agent
+type Agent struct {
+	name string
+}
+
+func (p *Agent) Name() string {
+	return p.name
+}
+
+func (p *Agent) Credential() (*Credential, error) {
+	if os.Getenv("SSH_AUTH_SOCK") == "" {
+		return nil, fmt.Errorf("credential %s: ssh-agent is not running (SSH_AUTH_SOCK is empty)", p.name)
+	}
+	return &Credential{Agent: true}, nil
+}
@@ -0,0 +1,32 @@
+package credential
+
+import "strings"
+
+// * 合併多個 provider：agent 任一啟用即啟用，私鑰與密碼取第一個有值者
+type Chain []Provider
+
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, p := range c {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+func (c Chain) Credential() (*Credential, error) {
+	merged := &Credential{}
+	for _, p := range c {
+		cred, err := p.Credential()
+		if err != nil {
+			return nil, err
+		}
+		merged.Agent = merged.Agent || cred.Agent
+		if merged.IdentityFile == "" {
+			merged.IdentityFile = cred.IdentityFile
+		}
+		if merged.Password == "" {
+			merged.Password = cred.Password
+		}
+	}
+	return merged, nil
+}
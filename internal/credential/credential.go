@@ -0,0 +1,168 @@
+package credential
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// * 交給 SSH transport 的驗證資料；Password 搭配 IdentityFile 時作為 passphrase
+type Credential struct {
+	Agent        bool
+	IdentityFile string
+	Password     string
+}
+
+type Provider interface {
+	Name() string
+	Credential() (*Credential, error)
+}
+
+const (
+	TypeAgent           = "agent"
+	TypeIdentity        = "identity"
+	TypePasswordFile    = "password_file"
+	TypePrompt          = "prompt"
+	TypePasswordCommand = "password_command"
+	TypeEnv             = "env"
+)
+
+// * inventory 中 credentials 區塊的一個項目
+type Spec struct {
+	Type     string `yaml:"type" json:"type"`
+	Path     string `yaml:"path,omitempty" json:"path,omitempty"`
+	Command  string `yaml:"command,omitempty" json:"command,omitempty"`
+	Variable string `yaml:"variable,omitempty" json:"variable,omitempty"`
+	// * 私鑰 passphrase 的 provider 名稱
+	Passphrase string `yaml:"passphrase,omitempty" json:"passphrase,omitempty"`
+}
+
+// * target 為 user@host，用於提示文字與 session 快取；lookup 解析 passphrase 參照的 provider
+func New(name string, spec *Spec, target string, lookup func(string) (Provider, error)) (Provider, error) {
+	switch spec.Type {
+	case TypeAgent:
+		return &Agent{name: name}, nil
+
+	case TypeIdentity:
+		if spec.Path == "" {
+			return nil, fmt.Errorf("credential %s: path is required", name)
+		}
+		p := &Identity{name: name, Path: expandHome(spec.Path)}
+		if spec.Passphrase != "" {
+			passphrase, err := lookup(spec.Passphrase)
+			if err != nil {
+				return nil, err
+			}
+			p.Passphrase = passphrase
+		}
+		return p, nil
+
+	case TypePasswordFile:
+		if spec.Path == "" {
+			return nil, fmt.Errorf("credential %s: path is required", name)
+		}
+		return &PasswordFile{name: name, Path: expandHome(spec.Path)}, nil
+
+	case TypePrompt:
+		return &Prompt{name: name, Target: target}, nil
+
+	case TypePasswordCommand:
+		if spec.Command == "" {
+			return nil, fmt.Errorf("credential %s: command is required", name)
+		}
+		return &PasswordCommand{name: name, Command: spec.Command, Target: target}, nil
+
+	case TypeEnv:
+		if spec.Variable == "" {
+			return nil, fmt.Errorf("credential %s: variable is required", name)
+		}
+		return &Env{name: name, Variable: spec.Variable}, nil
+	}
+	return nil, fmt.Errorf("credential %s: unsupported type %q", name, spec.Type)
+}
+
+// * 不需要額外設定、可直接以名稱使用的 provider
+func Builtin(name string) (*Spec, bool) {
+	switch name {
+	case TypeAgent, TypePrompt:
+		return &Spec{Type: name}, true
+	}
+	return nil, false
+}
+
+// * 同一個 CLI 執行期間只詢問或執行一次，以 provider 名稱與 user@host 為 key
+var (
+	sessionMu sync.Mutex
+	session   = map[string]string{}
+)
+
+func cached(key string) (string, bool) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+
+	secret, ok := session[key]
+	return secret, ok
+}
+
+func remember(key, secret string) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+
+	session[key] = secret
+}
+
+// * rsync 呼叫的 __rsh 子程序無法互動，已取得的密碼寫入僅本人可讀的暫存檔，環境變數只帶路徑
+// * 避免密碼出現在子程序的環境（/proc/<pid>/environ）
+const sessionEnv = "PODRUN_SESSION_FILE"
+
+// * 回傳需加入子程序的環境變數與結束後移除暫存檔的函式
+func Export() ([]string, func()) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+
+	noop := func() {}
+	if len(session) == 0 {
+		return nil, noop
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, noop
+	}
+
+	f, err := os.CreateTemp("", "podrun-session-*")
+	if err != nil {
+		return nil, noop
+	}
+	cleanup := func() { os.Remove(f.Name()) }
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, noop
+	}
+	return []string{sessionEnv + "=" + f.Name()}, cleanup
+}
+
+// * 讀取後立即移除環境變數，避免再傳給其他子程序；暫存檔由父程序移除
+func Import() {
+	path := os.Getenv(sessionEnv)
+	if path == "" {
+		return
+	}
+	os.Unsetenv(sessionEnv)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return
+	}
+	for key, secret := range secrets {
+		remember(key, secret)
+	}
+}
@@ -0,0 +1,255 @@
+package credential
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// * session 快取為全域狀態，每個測試從空的快取開始
+func resetSession(t *testing.T) {
+	t.Helper()
+
+	reset := func() {
+		sessionMu.Lock()
+		defer sessionMu.Unlock()
+		session = map[string]string{}
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestPasswordFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on Windows")
+	}
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		mode    os.FileMode
+		want    string
+		wantErr string
+	}{
+		{"owner only", "hunter2\n", 0600, "hunter2", ""},
+		{"crlf", "hunter2\r\n", 0400, "hunter2", ""},
+		{"group readable", "hunter2\n", 0640, "", "accessible by other users"},
+		{"world readable", "hunter2\n", 0644, "", "chmod 600"},
+		{"empty", "\n", 0600, "", "is empty"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("password-%d", i))
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(path, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+
+			cred, err := (&PasswordFile{name: "file", Path: path}).Credential()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || cred.Password != tt.want {
+				t.Fatalf("got %+v, %v", cred, err)
+			}
+		})
+	}
+
+	if _, err := (&PasswordFile{name: "file", Path: filepath.Join(dir, "missing")}).Credential(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("missing file: got %v", err)
+	}
+}
+
+// * 記錄呼叫順序的 provider
+type fakeProvider struct {
+	name  string
+	cred  Credential
+	err   error
+	calls *[]string
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Credential() (*Credential, error) {
+	*p.calls = append(*p.calls, p.name)
+	if p.err != nil {
+		return nil, p.err
+	}
+	cred := p.cred
+	return &cred, nil
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	chain := Chain{
+		&fakeProvider{name: "agent", cred: Credential{Agent: true}, calls: &calls},
+		&fakeProvider{name: "first", cred: Credential{IdentityFile: "/keys/first"}, calls: &calls},
+		&fakeProvider{name: "second", cred: Credential{IdentityFile: "/keys/second", Password: "from-second"}, calls: &calls},
+		&fakeProvider{name: "third", cred: Credential{Password: "from-third"}, calls: &calls},
+	}
+
+	cred, err := chain.Credential()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cred.Agent || cred.IdentityFile != "/keys/first" || cred.Password != "from-second" {
+		t.Fatalf("got %+v", cred)
+	}
+	if fmt.Sprint(calls) != "[agent first second third]" {
+		t.Fatalf("calls = %v", calls)
+	}
+	if chain.Name() != "agent+first+second+third" {
+		t.Fatalf("Name = %s", chain.Name())
+	}
+
+	// * 任一 provider 失敗即停止
+	calls = nil
+	chain[1].(*fakeProvider).err = errors.New("boom")
+	if _, err := chain.Credential(); err == nil || fmt.Sprint(calls) != "[agent first]" {
+		t.Fatalf("got %v, calls %v", err, calls)
+	}
+}
+
+func TestNewIdentityPassphrase(t *testing.T) {
+	key := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(key, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KEY_PASSPHRASE", "phrase")
+
+	var looked []string
+	lookup := func(name string) (Provider, error) {
+		looked = append(looked, name)
+		return New(name, &Spec{Type: TypeEnv, Variable: "KEY_PASSPHRASE"}, "deploy@prod", nil)
+	}
+	p, err := New("key", &Spec{Type: TypeIdentity, Path: key, Passphrase: "vault"}, "deploy@prod", lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred, err := p.Credential()
+	if err != nil || cred.IdentityFile != key || cred.Password != "phrase" {
+		t.Fatalf("got %+v, %v", cred, err)
+	}
+	if fmt.Sprint(looked) != "[vault]" {
+		t.Fatalf("lookup calls = %v", looked)
+	}
+
+	for _, spec := range []*Spec{
+		{Type: TypeIdentity},
+		{Type: TypePasswordFile},
+		{Type: TypePasswordCommand},
+		{Type: TypeEnv},
+		{Type: "vault"},
+	} {
+		if _, err := New("bad", spec, "deploy@prod", nil); err == nil {
+			t.Errorf("New(%+v) succeeded", spec)
+		}
+	}
+}
+
+func TestPromptSessionCache(t *testing.T) {
+	resetSession(t)
+	t.Setenv("PODRUN_NO_INPUT", "true")
+
+	p := &Prompt{name: "prompt", Target: "deploy@prod"}
+	if _, err := p.Credential(); err == nil || !strings.Contains(err.Error(), "terminal is required") {
+		t.Fatalf("got %v, want a terminal error", err)
+	}
+
+	// * 已輸入過的密碼不再詢問；不同主機分開快取
+	remember("prompt@deploy@prod", "hunter2")
+	cred, err := p.Credential()
+	if err != nil || cred.Password != "hunter2" {
+		t.Fatalf("got %+v, %v", cred, err)
+	}
+	if _, err := (&Prompt{name: "prompt", Target: "deploy@staging"}).Credential(); err == nil {
+		t.Fatal("cached password was reused for another host")
+	}
+}
+
+func TestPasswordCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	resetSession(t)
+	counter := filepath.Join(t.TempDir(), "calls")
+
+	p := &PasswordCommand{
+		name:    "vault",
+		Command: fmt.Sprintf("echo x >> %s; printf 'hunter2\\nignored\\n'", counter),
+		Target:  "deploy@prod",
+	}
+	for range 2 {
+		cred, err := p.Credential()
+		if err != nil || cred.Password != "hunter2" {
+			t.Fatalf("got %+v, %v", cred, err)
+		}
+	}
+	// * 同一次執行只呼叫一次
+	if data, err := os.ReadFile(counter); err != nil || string(data) != "x\n" {
+		t.Fatalf("command ran %q times, %v", data, err)
+	}
+
+	if _, err := (&PasswordCommand{name: "fail", Command: "exit 3", Target: "t"}).Credential(); err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Fatalf("failing command: got %v", err)
+	}
+	if _, err := (&PasswordCommand{name: "empty", Command: "true", Target: "t"}).Credential(); err == nil || !strings.Contains(err.Error(), "printed nothing") {
+		t.Fatalf("empty output: got %v", err)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	resetSession(t)
+
+	env, cleanup := Export()
+	if env != nil {
+		t.Fatalf("empty session exported %v", env)
+	}
+	cleanup()
+
+	remember("prompt@deploy@prod", "hunter2")
+	env, cleanup = Export()
+	if len(env) != 1 || !strings.HasPrefix(env[0], sessionEnv+"=") {
+		t.Fatalf("env = %v", env)
+	}
+	path := strings.TrimPrefix(env[0], sessionEnv+"=")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Fatalf("session file mode = %04o, want 0600", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(strings.Join(env, " "), "hunter2") || !strings.Contains(string(data), "hunter2") {
+		t.Fatal("the secret must be in the file, not in the environment")
+	}
+
+	// * 子程序：由檔案讀回快取並移除環境變數
+	resetSession(t)
+	t.Setenv(sessionEnv, path)
+	Import()
+	if _, ok := os.LookupEnv(sessionEnv); ok {
+		t.Fatal("Import left the session path in the environment")
+	}
+	if secret, ok := cached("prompt@deploy@prod"); !ok || secret != "hunter2" {
+		t.Fatalf("cached = %q, %v", secret, ok)
+	}
+
+	cleanup()
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("session file still exists after cleanup: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package credential
+
+import (
+	"fmt"
+	"os"
+)
+
+// * 由環境變數讀取密碼，相容舊有的 PODRUN_PASSWORD 與 password_env
+type Env struct {
+	name     string
+	Variable string
+}
+
+func (p *Env) Name() string {
+	return p.name
+}
+
+func (p *Env) Credential() (*Credential, error) {
+	password := os.Getenv(p.Variable)
+	if password == "" {
+		return nil, fmt.Errorf("credential %s: %s is empty", p.name, p.Variable)
+	}
+	return &Credential{Password: password}, nil
+}
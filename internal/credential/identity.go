@@ -0,0 +1,47 @@
+package credential
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// * 私鑰檔案；加密的私鑰由 Passphrase 提供 passphrase
+type Identity struct {
+	name       string
+	Path       string
+	Passphrase Provider
+}
+
+func (p *Identity) Name() string {
+	return p.name
+}
+
+func (p *Identity) Credential() (*Credential, error) {
+	if _, err := os.Stat(p.Path); err != nil {
+		return nil, fmt.Errorf("credential %s: %w", p.name, err)
+	}
+	c := &Credential{IdentityFile: p.Path}
+	if p.Passphrase != nil {
+		passphrase, err := p.Passphrase.Credential()
+		if err != nil {
+			return nil, err
+		}
+		c.Password = passphrase.Password
+	}
+	return c, nil
+}
+
+// * 設定檔中常以 ~/ 表示家目錄，shell 不會替我們展開
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
@@ -0,0 +1,49 @@
+package credential
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// * 執行外部指令（例如 pass、op、vault）取得密碼，取第一行輸出；同一次執行只呼叫一次
+type PasswordCommand struct {
+	name    string
+	Command string
+	Target  string
+}
+
+func (p *PasswordCommand) Name() string {
+	return p.name
+}
+
+func (p *PasswordCommand) Credential() (*Credential, error) {
+	key := p.name + "@" + p.Target
+	if password, ok := cached(key); ok {
+		return &Credential{Password: password}, nil
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", p.Command)
+	} else {
+		cmd = exec.Command("sh", "-c", p.Command)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("credential %s: password_command failed: %w", p.name, err)
+	}
+
+	password, _, _ := strings.Cut(stdout.String(), "\n")
+	password = strings.TrimRight(password, "\r")
+	if password == "" {
+		return nil, fmt.Errorf("credential %s: password_command printed nothing", p.name)
+	}
+	remember(key, password)
+	return &Credential{Password: password}, nil
+}
@@ -0,0 +1,39 @@
+package credential
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// * 檔案內容為密碼；僅限擁有者可讀寫，與 ssh 對私鑰的要求相同
+type PasswordFile struct {
+	name string
+	Path string
+}
+
+func (p *PasswordFile) Name() string {
+	return p.name
+}
+
+func (p *PasswordFile) Credential() (*Credential, error) {
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("credential %s: %w", p.name, err)
+	}
+	// * Windows 的權限位元不代表實際 ACL，不檢查
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("credential %s: %s is accessible by other users (mode %04o), run chmod 600 %s", p.name, p.Path, info.Mode().Perm(), p.Path)
+	}
+
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("credential %s: %w", p.name, err)
+	}
+	password := strings.TrimRight(string(data), "\r\n")
+	if password == "" {
+		return nil, fmt.Errorf("credential %s: %s is empty", p.name, p.Path)
+	}
+	return &Credential{Password: password}, nil
+}
@@ -0,0 +1,46 @@
+package credential
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+)
+
+// * 由終端機輸入密碼，同一次執行只詢問一次
+type Prompt struct {
+	name   string
+	Target string
+}
+
+func (p *Prompt) Name() string {
+	return p.name
+}
+
+func (p *Prompt) Credential() (*Credential, error) {
+	key := p.name + "@" + p.Target
+	if password, ok := cached(key); ok {
+		return &Credential{Password: password}, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	noInput, _ := strconv.ParseBool(os.Getenv("PODRUN_NO_INPUT"))
+	if noInput || !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("credential %s: a terminal is required to prompt for the password of %s", p.name, p.Target)
+	}
+	// * stdout 可能被導向檔案，提示一律寫到 stderr
+	fmt.Fprintf(os.Stderr, "[?] password for %s: ", p.Target)
+	data, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("credential %s: %w", p.name, err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("credential %s: empty password", p.name)
+	}
+
+	password := string(data)
+	remember(key, password)
+	return &Credential{Password: password}, nil
+}
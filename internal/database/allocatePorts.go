@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+// * 同一組 (uid, service, container_port, protocol, seq) 重複部署時沿用原本的 host port
+// * host port 只需在 pod 所在的主機上不重複；不在本次請求中的舊分配會被釋放
+func (s *SQLite) AllocatePorts(ctx context.Context, uid string, reqs []model.Port, min, max int) ([]model.Port, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// * 尚未登錄或未記錄主機的 pod 歸在 0
+	var serverID int64
+	err = tx.QueryRowContext(ctx, `
+  SELECT COALESCE(server_id, 0) FROM pods WHERE uid = ?
+  `, uid).Scan(&serverID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// * pod 換到其他主機時，原主機上的分配不再沿用
+	if _, err := tx.ExecContext(ctx, `
+  DELETE FROM ports WHERE uid = ? AND server_id != ?
+  `, uid, serverID); err != nil {
+		return nil, err
+	}
+
+	used := map[int]bool{}
+	rows, err := tx.QueryContext(ctx, `
+  SELECT host_port
+  FROM ports
+  WHERE server_id = ? AND host_port BETWEEN ? AND ?
+  `, serverID, min, max)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var port int
+		if err := rows.Scan(&port); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		used[port] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	keep := []any{uid}
+	results := make([]model.Port, 0, len(reqs))
+	next := min
+	for _, req := range reqs {
+		if req.Protocol == "" {
+			req.Protocol = "tcp"
+		}
+		req.UID = uid
+
+		err := tx.QueryRowContext(ctx, `
+  SELECT id, host_port
+  FROM ports
+  WHERE uid = ? AND service = ? AND container_port = ? AND protocol = ? AND seq = ?
+  `, uid, req.Service, req.ContainerPort, req.Protocol, req.Seq).Scan(&req.ID, &req.HostPort)
+		switch {
+		case err == nil:
+		case errors.Is(err, sql.ErrNoRows):
+			for next <= max && used[next] {
+				next++
+			}
+			if next > max {
+				return nil, fmt.Errorf("no free host port in range %d-%d", min, max)
+			}
+			req.HostPort = next
+			used[next] = true
+
+			res, err := tx.ExecContext(ctx, `
+  INSERT INTO ports (
+    uid, server_id, service, container_port, protocol,
+    seq, host_port
+  )
+  VALUES (
+    ?, ?, ?, ?, ?,
+    ?, ?
+  )
+  `,
+				uid, serverID, req.Service, req.ContainerPort, req.Protocol,
+				req.Seq, req.HostPort,
+			)
+			if err != nil {
+				return nil, err
+			}
+			req.ID, _ = res.LastInsertId()
+		default:
+			return nil, err
+		}
+
+		keep = append(keep, req.ID)
+		results = append(results, req)
+	}
+
+	query := `DELETE FROM ports WHERE uid = ?`
+	if len(keep) > 1 {
+		query += ` AND id NOT IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(keep)-1), ", ") + `)`
+	}
+	if _, err := tx.ExecContext(ctx, query, keep...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
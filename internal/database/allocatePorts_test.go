@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func newTestDB(t *testing.T) *SQLite {
+	t.Helper()
+
+	s, err := NewSQLite(filepath.Join(t.TempDir(), "podrun.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func registerPod(t *testing.T, s *SQLite, uid, server string) {
+	t.Helper()
+
+	if err := s.UpsertPod(context.Background(), &model.Pod{
+		UID:       uid,
+		PodID:     uid,
+		PodName:   uid,
+		RemoteDir: "/srv/" + uid,
+		Status:    model.StatusStarting,
+		Server:    server,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func allocate(t *testing.T, s *SQLite, uid string, min, max int, reqs ...model.Port) []model.Port {
+	t.Helper()
+
+	ports, err := s.AllocatePorts(context.Background(), uid, reqs, min, max)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ports
+}
+
+func hostPorts(ports []model.Port) string {
+	var s []string
+	for _, e := range ports {
+		s = append(s, fmt.Sprintf("%s/%d/%d=%d", e.Service, e.ContainerPort, e.Seq, e.HostPort))
+	}
+	return fmt.Sprint(s)
+}
+
+// * 重新部署沿用原本的 host port，移除的對應會被釋放並可再分配
+func TestAllocatePortsReuse(t *testing.T) {
+	s := newTestDB(t)
+	registerPod(t, s, "a", "prod")
+	web := model.Port{Service: "web", ContainerPort: 80}
+	db := model.Port{Service: "db", ContainerPort: 5432}
+
+	first := allocate(t, s, "a", 20000, 20009, web, db)
+	if got := hostPorts(first); got != "[web/80/0=20000 db/5432/0=20001]" {
+		t.Fatalf("first deploy = %s", got)
+	}
+
+	// * 順序不同也依 (service, container_port, protocol, seq) 對應
+	again := allocate(t, s, "a", 20000, 20009, db, web)
+	if got := hostPorts(again); got != "[db/5432/0=20001 web/80/0=20000]" {
+		t.Fatalf("redeploy = %s", got)
+	}
+
+	allocate(t, s, "a", 20000, 20009, db)
+	listed, err := s.ListPorts(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hostPorts(listed); got != "[db/5432/0=20001]" {
+		t.Fatalf("after removing web = %s", got)
+	}
+	if got := hostPorts(allocate(t, s, "a", 20000, 20009, db, web)); got != "[db/5432/0=20001 web/80/0=20000]" {
+		t.Fatalf("after re-adding web = %s", got)
+	}
+}
+
+// * 同一個 container port 多次對應時依 seq 各自分配
+func TestAllocatePortsSeq(t *testing.T) {
+	s := newTestDB(t)
+	registerPod(t, s, "a", "prod")
+
+	ports := allocate(t, s, "a", 20000, 20009,
+		model.Port{Service: "web", ContainerPort: 80, Seq: 0},
+		model.Port{Service: "web", ContainerPort: 80, Seq: 1},
+		model.Port{Service: "web", ContainerPort: 80, Protocol: "udp", Seq: 0},
+	)
+	if got := hostPorts(ports); got != "[web/80/0=20000 web/80/1=20001 web/80/0=20002]" {
+		t.Fatalf("ports = %s", got)
+	}
+	if ports[0].Protocol != "tcp" || ports[2].Protocol != "udp" {
+		t.Fatalf("protocols = %s, %s", ports[0].Protocol, ports[2].Protocol)
+	}
+
+	// * 只保留 seq 1 時仍沿用原本的 host port
+	again := allocate(t, s, "a", 20000, 20009, model.Port{Service: "web", ContainerPort: 80, Seq: 1})
+	if got := hostPorts(again); got != "[web/80/1=20001]" {
+		t.Fatalf("redeploy = %s", got)
+	}
+}
+
+// * host port 只在同一台主機上不重複
+func TestAllocatePortsPerServer(t *testing.T) {
+	s := newTestDB(t)
+	registerPod(t, s, "a", "prod")
+	registerPod(t, s, "b", "prod")
+	registerPod(t, s, "c", "staging")
+	web := model.Port{Service: "web", ContainerPort: 80}
+
+	a := allocate(t, s, "a", 20000, 20009, web)
+	b := allocate(t, s, "b", 20000, 20009, web)
+	c := allocate(t, s, "c", 20000, 20009, web)
+	if a[0].HostPort != 20000 || b[0].HostPort != 20001 {
+		t.Fatalf("same server: a=%d b=%d", a[0].HostPort, b[0].HostPort)
+	}
+	if c[0].HostPort != 20000 {
+		t.Fatalf("other server: c=%d, want 20000", c[0].HostPort)
+	}
+
+	// * 換到其他主機時重新分配，原主機上的 port 釋放
+	registerPod(t, s, "a", "staging")
+	if moved := allocate(t, s, "a", 20000, 20009, web); moved[0].HostPort != 20001 {
+		t.Fatalf("moved to staging = %d, want 20001", moved[0].HostPort)
+	}
+	registerPod(t, s, "d", "prod")
+	if d := allocate(t, s, "d", 20000, 20009, web); d[0].HostPort != 20000 {
+		t.Fatalf("released port not reused: %d", d[0].HostPort)
+	}
+}
+
+// * 範圍用完時整批失敗，不留下部分分配
+func TestAllocatePortsExhausted(t *testing.T) {
+	s := newTestDB(t)
+	registerPod(t, s, "a", "prod")
+	registerPod(t, s, "b", "prod")
+
+	allocate(t, s, "a", 20000, 20001,
+		model.Port{Service: "web", ContainerPort: 80},
+		model.Port{Service: "web", ContainerPort: 443},
+	)
+	_, err := s.AllocatePorts(context.Background(), "b", []model.Port{
+		{Service: "api", ContainerPort: 8080},
+	}, 20000, 20001)
+	if err == nil {
+		t.Fatal("expected range exhaustion")
+	}
+
+	listed, err := s.ListPorts(context.Background(), "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 0 {
+		t.Fatalf("b has %s after a failed allocation", hostPorts(listed))
+	}
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+var ErrUnauthorized = errors.New("invalid or revoked token")
+
+// * 已撤銷的 token 與已停用的使用者都視為無效
+func (s *SQLite) Authenticate(ctx context.Context, token string) (*model.User, error) {
+	var tokenID int64
+	var user model.User
+	err := s.db.QueryRowContext(ctx, `
+  SELECT t.id, u.id, u.name, u.email, u.created_at
+  FROM tokens t
+  JOIN users u ON u.id = t.user_id
+  WHERE t.hash = ? AND t.revoked_at IS NULL AND u.dismiss = 0
+  `, HashToken(token)).Scan(
+		&tokenID,
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+  UPDATE tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?
+  `, tokenID); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"context"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// * 包含已撤銷的 token，建立過 token 後 auto 模式不會因全部撤銷而回到未驗證
+func (s *SQLite) CountTokens(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `
+  SELECT COUNT(*) FROM tokens
+  `).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+const (
+	tokenPrefix = "podrun_"
+	// * podrun_ 加上前 8 碼，足以辨識又不洩漏 token
+	tokenPrefixLen = len(tokenPrefix) + 8
+)
+
+// * 使用者不存在時一併建立；回傳的明文 token 不會被保存
+func (s *SQLite) CreateToken(ctx context.Context, user *model.User, name string) (string, *model.Token, error) {
+	if err := s.UpsertUser(ctx, user); err != nil {
+		return "", nil, err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	token := tokenPrefix + hex.EncodeToString(b)
+
+	t := &model.Token{
+		UserID: user.ID,
+		User:   user.Name,
+		Name:   name,
+		Prefix: token[:tokenPrefixLen],
+	}
+	if err := s.db.QueryRowContext(ctx, `
+  INSERT INTO tokens (user_id, name, prefix, hash)
+  VALUES (?, ?, ?, ?)
+  RETURNING id, created_at
+  `,
+		t.UserID,
+		t.Name,
+		t.Prefix,
+		HashToken(token),
+	).Scan(&t.ID, &t.CreatedAt); err != nil {
+		return "", nil, err
+	}
+	return token, t, nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
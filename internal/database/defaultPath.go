@@ -0,0 +1,15 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// * 容器內使用 /data，主機上使用 ~/.podrun
+func DefaultPath() string {
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return "/data/database.db"
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".podrun", "database.db")
+}
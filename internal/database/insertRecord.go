@@ -2,21 +2,43 @@ package database
 
 import (
 	"context"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/pardnchiu/go-podrun/internal/model"
 )
 
+// * event_id 重複時略過；created_at 保留 CLI 端的發生時間（離線重送）
 func (s *SQLite) InsertRecord(ctx context.Context, d *model.Record) error {
-	_, err := s.db.ExecContext(ctx, `
+	var eventID, createdAt any
+	if d.EventID != "" {
+		eventID = d.EventID
+	}
+	if !d.CreatedAt.IsZero() {
+		createdAt = d.CreatedAt.UTC().Format(time.DateTime)
+	}
+
+	userID, err := resolveUser(ctx, s.db, d.User)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
   INSERT INTO records (
-    pod_id, content, hostname, ip
+    pod_id, content, hostname, ip, created_at,
+    event_id, command, exit_code, error, duration_ms,
+    git_commit, payload, user_id
   )
   VALUES (
-    (SELECT id FROM pods WHERE uid = ?), ?, ?, ?
+    (SELECT id FROM pods WHERE uid = ?), ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP),
+    ?, ?, ?, ?, ?,
+    ?, ?, ?
   )
+  ON CONFLICT (event_id) DO NOTHING
   `,
-		d.UID, d.Content, d.Hostname, d.IP,
+		d.UID, d.Content, d.Hostname, d.IP, createdAt,
+		eventID, d.Command, d.ExitCode, d.Error, d.DurationMs,
+		d.GitCommit, string(d.Payload), userID,
 	)
 	return err
 }
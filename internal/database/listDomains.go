@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func (s *SQLite) ListDomains(ctx context.Context, uid string) ([]model.Domain, error) {
+	rows, err := s.db.QueryContext(ctx, `
+  SELECT
+    domains.id, domains.pod_id, pods.uid, domains.container_name, domains.domain,
+    domains.created_at, domains.updated_at
+  FROM domains
+  LEFT JOIN pods ON domains.pod_id = pods.id
+  WHERE domains.dismiss = 0 AND pods.uid = ?
+  ORDER BY domains.container_name, domains.domain
+  `, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var domains []model.Domain
+	for rows.Next() {
+		var d model.Domain
+		if err := rows.Scan(
+			&d.ID, &d.PodID, &d.UID, &d.ContainerName, &d.Domain,
+			&d.CreatedAt, &d.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		domains = append(domains, d)
+	}
+
+	return domains, rows.Err()
+}
@@ -1,34 +1,104 @@
 package database
 
-import "context"
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
 
-func (s *SQLite) ListPodRecords(ctx context.Context, uid string) ([]ContainerRecord, error) {
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+// * 單次查詢的筆數上限
+const MaxRecordLimit = 500
+
+// * 由新到舊；回傳符合條件的總筆數以便分頁，limit <= 0 或超過上限時以 MaxRecordLimit 為準
+// * pod 不存在（含已移除的記錄）時回傳 sql.ErrNoRows
+func (s *SQLite) ListPodRecords(ctx context.Context, uid string, f RecordFilter) ([]model.Record, int, error) {
+	var exists int
+	if err := s.db.QueryRowContext(ctx, `
+  SELECT 1 FROM pods WHERE uid = ? LIMIT 1
+  `, uid).Scan(&exists); err != nil {
+		return nil, 0, err
+	}
+
+	where := "pods.uid = ?"
+	args := []any{uid}
+	if !f.Since.IsZero() {
+		where += " AND records.created_at >= ?"
+		args = append(args, f.Since.UTC().Format(time.DateTime))
+	}
+	if !f.Until.IsZero() {
+		where += " AND records.created_at < ?"
+		args = append(args, f.Until.UTC().Format(time.DateTime))
+	}
+	if f.User != "" {
+		where += " AND users.name = ?"
+		args = append(args, f.User)
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `
+  SELECT COUNT(*)
+  FROM records
+  JOIN pods ON records.pod_id = pods.id
+  LEFT JOIN users ON users.id = records.user_id
+  WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := f.Limit
+	if limit <= 0 || limit > MaxRecordLimit {
+		limit = MaxRecordLimit
+	}
 	rows, err := s.db.QueryContext(ctx, `
   SELECT
-    pods.local_dir,
-    pods.remote_dir,
-    pods.file,
-    pods.hostname,
-    pods.ip,
-    records.content
+    records.id,
+    records.pod_id,
+    pods.uid,
+    records.content,
+    records.hostname,
+    records.ip,
+    records.created_at,
+    records.event_id,
+    COALESCE(records.command, ''),
+    COALESCE(records.exit_code, 0),
+    COALESCE(records.error, ''),
+    COALESCE(records.duration_ms, 0),
+    COALESCE(records.git_commit, ''),
+    COALESCE(records.payload, ''),
+    COALESCE(users.name, '')
   FROM records
-  LEFT JOIN pods ON records.pod_id = pods.id
-  WHERE pods.dismiss = 0 AND pods.uid = ?
-  `, uid)
+  JOIN pods ON records.pod_id = pods.id
+  LEFT JOIN users ON users.id = records.user_id
+  WHERE `+where+`
+  ORDER BY records.id DESC
+  LIMIT ? OFFSET ?
+  `, append(args, limit, max(f.Offset, 0))...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var records []ContainerRecord
+	var records []model.Record
 	for rows.Next() {
-		var r ContainerRecord
-		if err := rows.Scan(&r.LocalDir, &r.RemoteDir, &r.File,
-			&r.Hostname, &r.IP, &r.Content); err != nil {
-			return nil, err
+		var r model.Record
+		var createdAt sql.NullTime
+		var eventID sql.NullString
+		var payload string
+		if err := rows.Scan(&r.ID, &r.PodID, &r.UID, &r.Content,
+			&r.Hostname, &r.IP, &createdAt, &eventID,
+			&r.Command, &r.ExitCode, &r.Error, &r.DurationMs,
+			&r.GitCommit, &payload, &r.User); err != nil {
+			return nil, 0, err
+		}
+		r.CreatedAt = createdAt.Time
+		r.EventID = eventID.String
+		if payload != "" {
+			r.Payload = json.RawMessage(payload)
 		}
 		records = append(records, r)
 	}
 
-	return records, rows.Err()
+	return records, total, rows.Err()
 }
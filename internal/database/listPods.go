@@ -2,19 +2,40 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/pardnchiu/go-podrun/internal/model"
 )
 
-func (s *SQLite) ListPods(ctx context.Context) ([]model.Pod, error) {
+type PodFilter struct {
+	// * 使用者名稱，空字串時不過濾
+	Owner string
+	// * 主機名稱，空字串時不過濾
+	Server string
+}
+
+func (s *SQLite) ListPods(ctx context.Context, f PodFilter) ([]model.Pod, error) {
+	where := "pods.dismiss = 0"
+	var args []any
+	if f.Owner != "" {
+		where += " AND users.name = ?"
+		args = append(args, f.Owner)
+	}
+	if f.Server != "" {
+		where += " AND servers.name = ?"
+		args = append(args, f.Server)
+	}
+
 	rows, err := s.db.QueryContext(ctx, `
 	SELECT
-	  id, uid, pod_uid, pod_name, local_dir,
-		remote_dir, file, target, status, hostname,
-		ip, replicas, created_at, updated_at
+	  pods.id, pods.uid, pods.pod_uid, pods.pod_name, pods.local_dir,
+		pods.remote_dir, pods.file, pods.target, pods.status, pods.hostname,
+		pods.ip, pods.replicas, pods.created_at, pods.updated_at, pods.restarts,
+		pods.last_seen_at, COALESCE(users.name, ''), COALESCE(servers.name, ''), COALESCE(pods.project, '')
 	FROM pods
-	WHERE dismiss = 0
-	`)
+	LEFT JOIN users ON users.id = pods.user_id
+	LEFT JOIN servers ON servers.id = pods.server_id
+	WHERE `+where, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -23,13 +44,18 @@ func (s *SQLite) ListPods(ctx context.Context) ([]model.Pod, error) {
 	var containers []model.Pod
 	for rows.Next() {
 		var c model.Pod
+		var lastSeen sql.NullTime
 		if err := rows.Scan(
 			&c.ID, &c.UID, &c.PodID, &c.PodName, &c.LocalDir,
 			&c.RemoteDir, &c.File, &c.Target, &c.Status, &c.Hostname,
-			&c.IP, &c.Replicas, &c.CreatedAt, &c.UpdatedAt,
+			&c.IP, &c.Replicas, &c.CreatedAt, &c.UpdatedAt, &c.Restarts,
+			&lastSeen, &c.Owner, &c.Server, &c.Project,
 		); err != nil {
 			return nil, err
 		}
+		if lastSeen.Valid {
+			c.LastSeenAt = &lastSeen.Time
+		}
 		containers = append(containers, c)
 	}
 
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func (s *SQLite) ListPorts(ctx context.Context, uid string) ([]model.Port, error) {
+	rows, err := s.db.QueryContext(ctx, `
+  SELECT
+    id, uid, service, container_port, protocol,
+    seq, host_port, created_at, updated_at
+  FROM ports
+  WHERE uid = ?
+  ORDER BY service, container_port, protocol, seq
+  `, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ports []model.Port
+	for rows.Next() {
+		var p model.Port
+		if err := rows.Scan(
+			&p.ID, &p.UID, &p.Service, &p.ContainerPort, &p.Protocol,
+			&p.Seq, &p.HostPort, &p.CreatedAt, &p.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		ports = append(ports, p)
+	}
+
+	return ports, rows.Err()
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func (s *SQLite) ListServers(ctx context.Context) ([]model.Server, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT id, name, host, port, user, base_dir, created_at, updated_at
+	FROM servers
+	ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []model.Server
+	for rows.Next() {
+		var c model.Server
+		if err := rows.Scan(
+			&c.ID, &c.Name, &c.Host, &c.Port, &c.User,
+			&c.BaseDir, &c.CreatedAt, &c.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, c)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+// * 包含已撤銷的 token，依建立順序
+func (s *SQLite) ListTokens(ctx context.Context) ([]model.Token, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT
+	  t.id, t.user_id, u.name, t.name, t.prefix,
+	  t.created_at, t.last_used_at, t.revoked_at
+	FROM tokens t
+	JOIN users u ON u.id = t.user_id
+	ORDER BY t.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []model.Token
+	for rows.Next() {
+		var c model.Token
+		var lastUsed, revoked sql.NullTime
+		if err := rows.Scan(
+			&c.ID, &c.UserID, &c.User, &c.Name, &c.Prefix,
+			&c.CreatedAt, &lastUsed, &revoked,
+		); err != nil {
+			return nil, err
+		}
+		if lastUsed.Valid {
+			c.LastUsedAt = &lastUsed.Time
+		}
+		if revoked.Valid {
+			c.RevokedAt = &revoked.Time
+		}
+		results = append(results, c)
+	}
+
+	return results, rows.Err()
+}
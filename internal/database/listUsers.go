@@ -1,32 +1,37 @@
 package database
 
 import (
+	"context"
+
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/pardnchiu/go-podrun/internal/model"
 )
 
-// # NOT THIS PROJECT POINT, REMOVE IT FOR NOW
-// func (s *SQLite) ListUsers(ctx context.Context) ([]model.User, error) {
-// 	rows, err := s.db.QueryContext(ctx, `
-// 	SELECT email, create_at
-// 	FROM users
-// 	WHERE dismiss = 0
-// 	`)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	defer rows.Close()
+func (s *SQLite) ListUsers(ctx context.Context) ([]model.User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT id, name, email, created_at
+	FROM users
+	WHERE dismiss = 0
+	ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-// 	var results []model.User
-// 	for rows.Next() {
-// 		var c model.User
-// 		if err := rows.Scan(
-// 			&c.Email,
-// 			&c.CreatedAt,
-// 		); err != nil {
-// 			return nil, err
-// 		}
-// 		results = append(results, c)
-// 	}
+	var results []model.User
+	for rows.Next() {
+		var c model.User
+		if err := rows.Scan(
+			&c.ID,
+			&c.Name,
+			&c.Email,
+			&c.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, c)
+	}
 
-// 	return results, rows.Err()
-// }
+	return results, rows.Err()
+}
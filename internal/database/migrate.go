@@ -0,0 +1,204 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	schema "github.com/pardnchiu/go-podrun/sql"
+)
+
+type Migration struct {
+	Version   int
+	Name      string
+	SQL       string
+	AppliedAt time.Time
+}
+
+func (m Migration) Applied() bool {
+	return !m.AppliedAt.IsZero()
+}
+
+func loadMigrations() ([]Migration, error) {
+	files, err := fs.Glob(schema.Migrations, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(files))
+	seen := map[int]string{}
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name: %s", file)
+		}
+		if prev, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s, %s", version, prev, file)
+		}
+		seen[version] = file
+
+		data, err := fs.ReadFile(schema.Migrations, file)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    name,
+			SQL:     string(data),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// * 回傳所有 migration 與套用時間，未套用的 AppliedAt 為零值
+func (s *SQLite) Migrations(ctx context.Context) ([]Migration, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := hasTable(ctx, s.db, "schema_migrations")
+	if err != nil || !exists {
+		return migrations, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range migrations {
+		migrations[i].AppliedAt = applied[migrations[i].Version]
+	}
+	return migrations, nil
+}
+
+// * 依版本順序套用尚未執行的 migration，每一個各自在 transaction 內完成；
+// * 導入版本管理前以 create.sql 建立的資料庫與 0001_init 相同（CREATE TABLE IF NOT EXISTS），可直接從頭套用
+func (s *SQLite) Migrate(ctx context.Context) ([]Migration, error) {
+	if _, err := s.db.ExecContext(ctx, `
+  CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+  )
+  `); err != nil {
+		return nil, err
+	}
+
+	migrations, err := s.Migrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if m.Applied() {
+			continue
+		}
+		ok, err := s.apply(ctx, m)
+		if err != nil {
+			return applied, fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		if ok {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// * API server 與 embedded CLI 可能同時升級同一個檔案：先以 BEGIN IMMEDIATE 取得寫入鎖，
+// * 再於 transaction 內確認尚未套用，避免兩邊重複執行 ALTER TABLE；已被其他程序套用時回傳 false
+func (s *SQLite) apply(ctx context.Context, m Migration) (bool, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// * 未設定 busy_timeout 時等待對方完成，而不是立即回傳 database is locked
+	var timeout int
+	if err := conn.QueryRowContext(ctx, `PRAGMA busy_timeout`).Scan(&timeout); err != nil {
+		return false, err
+	}
+	if timeout == 0 {
+		if _, err := conn.ExecContext(ctx, `PRAGMA busy_timeout = 5000`); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		return false, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			conn.ExecContext(context.Background(), `ROLLBACK`)
+		}
+	}()
+
+	var n int
+	if err := conn.QueryRowContext(ctx, `
+  SELECT COUNT(*) FROM schema_migrations WHERE version = ?
+  `, m.Version).Scan(&n); err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return false, nil
+	}
+
+	if _, err := conn.ExecContext(ctx, m.SQL); err != nil {
+		return false, err
+	}
+
+	if _, err := conn.ExecContext(ctx, `
+  INSERT INTO schema_migrations (version, name)
+  VALUES (?, ?)
+  `, m.Version, m.Name); err != nil {
+		return false, err
+	}
+	if _, err := conn.ExecContext(ctx, `COMMIT`); err != nil {
+		return false, err
+	}
+	committed = true
+	return true, nil
+}
+
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func hasTable(ctx context.Context, q querier, table string) (bool, error) {
+	var n int
+	err := q.QueryRowContext(ctx, `
+  SELECT COUNT(*) FROM sqlite_master
+  WHERE type = 'table' AND name = ?
+  `, table).Scan(&n)
+	return n > 0, err
+}
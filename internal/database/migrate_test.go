@@ -0,0 +1,178 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// * 導入版本管理前的 schema（sql/create.sql）
+func openBaseline(t *testing.T) *SQLite {
+	t.Helper()
+
+	baseline, err := os.ReadFile(filepath.Join("testdata", "create.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(filepath.Join(t.TempDir(), "podrun.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	for _, stmt := range []string{
+		string(baseline),
+		`INSERT INTO pods (uid, pod_uid, pod_name, local_dir, remote_dir, status) VALUES ('uid-1', 'web', 'web', '/src/web', '/srv/web', 'running')`,
+		`INSERT INTO records (pod_id, content, hostname, ip) VALUES (1, 'up', 'laptop', '10.0.0.2')`,
+		`INSERT INTO domains (pod_id, container_name, domain) VALUES (1, 'web', 'web.example.com')`,
+	} {
+		if _, err := s.db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+// * sqlite_master 中所有物件的定義，用於確認再次執行不會改變 schema
+func schemaDump(t *testing.T, s *SQLite) string {
+	t.Helper()
+
+	rows, err := s.db.Query(`SELECT type, name, COALESCE(sql, '') FROM sqlite_master ORDER BY type, name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var dump string
+	for rows.Next() {
+		var typ, name, sql string
+		if err := rows.Scan(&typ, &name, &sql); err != nil {
+			t.Fatal(err)
+		}
+		dump += fmt.Sprintf("%s %s\n%s\n", typ, name, sql)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return dump
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	s := openBaseline(t)
+	ctx := context.Background()
+
+	all, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := s.Migrate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(all) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(all))
+	}
+
+	migrations, err := s.Migrations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 || !m.Applied() {
+			t.Fatalf("migration %d = %04d_%s applied=%v", i, m.Version, m.Name, m.Applied())
+		}
+	}
+
+	// * 既有資料保留，且可用新的欄位讀寫
+	pod, err := s.PodInfo(ctx, "uid-1")
+	if err != nil || pod.PodName != "web" || pod.Status != "running" {
+		t.Fatalf("PodInfo = %+v, %v", pod, err)
+	}
+	records, total, err := s.ListPodRecords(ctx, "uid-1", RecordFilter{Limit: 10})
+	if err != nil || total != 1 || len(records) != 1 || records[0].Content != "up" {
+		t.Fatalf("ListPodRecords = %+v (%d), %v", records, total, err)
+	}
+	domains, err := s.ListDomains(ctx, "uid-1")
+	if err != nil || len(domains) != 1 {
+		t.Fatalf("ListDomains = %+v, %v", domains, err)
+	}
+
+	// * 第二次執行不套用任何 migration，schema 與紀錄不變
+	before := schemaDump(t, s)
+	again, err := s.Migrate(ctx)
+	if err != nil || len(again) != 0 {
+		t.Fatalf("second Migrate applied %d, %v", len(again), err)
+	}
+	if after := schemaDump(t, s); after != before {
+		t.Fatalf("schema changed on second run\n--- before\n%s\n--- after\n%s", before, after)
+	}
+	recorded, err := s.Migrations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range recorded {
+		if !recorded[i].AppliedAt.Equal(migrations[i].AppliedAt) {
+			t.Fatalf("migration %04d applied_at changed", recorded[i].Version)
+		}
+	}
+}
+
+// * 新資料庫與由 baseline 升級的資料庫 schema 相同
+func TestMigrateFresh(t *testing.T) {
+	fresh, err := NewSQLite(filepath.Join(t.TempDir(), "podrun.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+
+	upgraded := openBaseline(t)
+	if _, err := upgraded.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := schemaDump(t, fresh), schemaDump(t, upgraded); a != b {
+		t.Fatalf("fresh and upgraded schemas differ\n--- fresh\n%s\n--- upgraded\n%s", a, b)
+	}
+}
+
+// * API server 與 embedded CLI 同時升級同一個檔案時，每個 migration 只套用一次
+func TestMigrateConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "podrun.db")
+	all, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 4
+	var wg sync.WaitGroup
+	counts := make([]int, n)
+	errs := make([]error, n)
+	for i := range n {
+		s, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			applied, err := s.Migrate(context.Background())
+			counts[i], errs[i] = len(applied), err
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range n {
+		if errs[i] != nil {
+			t.Fatalf("Migrate %d: %v", i, errs[i])
+		}
+		total += counts[i]
+	}
+	if total != len(all) {
+		t.Fatalf("applied %d migrations in total, want %d", total, len(all))
+	}
+}
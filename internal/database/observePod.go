@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// * reconciler 回報的實際狀態；from 為讀取時的狀態，期間已被 CLI 更新則不覆蓋
+// * seenAt 為零值代表遠端沒有任何容器，保留上次的 last_seen_at
+func (s *SQLite) ObservePod(ctx context.Context, uid, from, status string, restarts int, seenAt time.Time) (bool, error) {
+	var lastSeen any
+	if !seenAt.IsZero() {
+		lastSeen = seenAt.UTC().Format(time.DateTime)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	exists, err := checkTransition(ctx, tx, uid, status)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+  UPDATE pods
+  SET
+    status = ?,
+    dismiss = ?,
+    restarts = ?,
+    last_seen_at = COALESCE(?, last_seen_at),
+    updated_at = CASE WHEN status = ? THEN updated_at ELSE CURRENT_TIMESTAMP END
+  WHERE uid = ? AND status = ?
+  `,
+		status,
+		dismissed(status),
+		restarts,
+		lastSeen,
+		status,
+		uid,
+		from,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/pardnchiu/go-podrun/internal/model"
 )
@@ -9,23 +10,31 @@ import (
 func (s *SQLite) PodInfo(ctx context.Context, uid string) (*model.Pod, error) {
 	row := s.db.QueryRowContext(ctx, `
   SELECT
-    id, uid, pod_uid, pod_name, local_dir,
-    remote_dir, file, target, status, hostname,
-    ip, replicas, created_at, updated_at
+    pods.id, pods.uid, pods.pod_uid, pods.pod_name, pods.local_dir,
+    pods.remote_dir, pods.file, pods.target, pods.status, pods.hostname,
+    pods.ip, pods.replicas, pods.created_at, pods.updated_at, pods.restarts,
+    pods.last_seen_at, COALESCE(users.name, ''), COALESCE(servers.name, ''), COALESCE(pods.project, '')
   FROM pods
-  WHERE dismiss = 0 AND uid = ?
+  LEFT JOIN users ON users.id = pods.user_id
+  LEFT JOIN servers ON servers.id = pods.server_id
+  WHERE pods.dismiss = 0 AND pods.uid = ?
   LIMIT 1
   `, uid)
 
 	var c model.Pod
+	var lastSeen sql.NullTime
 	err := row.Scan(
 		&c.ID, &c.UID, &c.PodID, &c.PodName, &c.LocalDir,
 		&c.RemoteDir, &c.File, &c.Target, &c.Status, &c.Hostname,
-		&c.IP, &c.Replicas, &c.CreatedAt, &c.UpdatedAt,
+		&c.IP, &c.Replicas, &c.CreatedAt, &c.UpdatedAt, &c.Restarts,
+		&lastSeen, &c.Owner, &c.Server, &c.Project,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if lastSeen.Valid {
+		c.LastSeenAt = &lastSeen.Time
+	}
 
 	return &c, nil
 }
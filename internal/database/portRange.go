@@ -0,0 +1,26 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultPortMin = 20000
+	DefaultPortMax = 29999
+)
+
+// * PORT_RANGE=20000-29999，未設定時使用預設範圍
+func ParsePortRange(value string) (int, int, error) {
+	if value == "" {
+		return DefaultPortMin, DefaultPortMax, nil
+	}
+	start, end, ok := strings.Cut(value, "-")
+	min, err1 := strconv.Atoi(strings.TrimSpace(start))
+	max, err2 := strconv.Atoi(strings.TrimSpace(end))
+	if !ok || err1 != nil || err2 != nil || min < 1 || max > 65535 || min > max {
+		return 0, 0, fmt.Errorf("invalid PORT_RANGE: %s", value)
+	}
+	return min, max, nil
+}
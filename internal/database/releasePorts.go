@@ -0,0 +1,15 @@
+package database
+
+import (
+	"context"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func (s *SQLite) ReleasePorts(ctx context.Context, uid string) error {
+	_, err := s.db.ExecContext(ctx, `
+  DELETE FROM ports
+  WHERE uid = ?
+  `, uid)
+	return err
+}
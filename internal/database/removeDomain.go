@@ -0,0 +1,29 @@
+package database
+
+import (
+	"context"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func (s *SQLite) RemoveDomain(ctx context.Context, d *model.Domain) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+  UPDATE domains
+  SET
+    updated_at = CURRENT_TIMESTAMP,
+    dismiss = 1
+  WHERE dismiss = 0
+    AND domain = ?
+    AND (? = '' OR container_name = ?)
+    AND pod_id = (SELECT id FROM pods WHERE uid = ?)
+  `,
+		d.Domain,
+		d.ContainerName, d.ContainerName,
+		d.UID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,24 @@
+package database
+
+import "context"
+
+// * 依名稱取得主機 ID，不存在時只以名稱建立；name 為空時回傳 nil
+func resolveServer(ctx context.Context, q execQuerier, name string) (any, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if _, err := q.ExecContext(ctx, `
+  INSERT INTO servers (name, host) VALUES (?, ?)
+  ON CONFLICT(name) DO NOTHING
+  `, name, name); err != nil {
+		return nil, err
+	}
+
+	var id int64
+	if err := q.QueryRowContext(ctx, `
+  SELECT id FROM servers WHERE name = ?
+  `, name).Scan(&id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
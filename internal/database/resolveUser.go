@@ -0,0 +1,32 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+type execQuerier interface {
+	querier
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// * 依名稱取得使用者 ID，不存在時建立；name 為空時回傳 nil（不歸屬任何人）
+func resolveUser(ctx context.Context, q execQuerier, name string) (any, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if _, err := q.ExecContext(ctx, `
+  INSERT INTO users (name) VALUES (?)
+  ON CONFLICT(name) DO NOTHING
+  `, name); err != nil {
+		return nil, err
+	}
+
+	var id int64
+	if err := q.QueryRowContext(ctx, `
+  SELECT id FROM users WHERE name = ?
+  `, name).Scan(&id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
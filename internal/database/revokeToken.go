@@ -0,0 +1,24 @@
+package database
+
+import (
+	"context"
+	"strconv"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// * key 可為 token ID、prefix 或使用者名稱（撤銷該使用者的全部 token）
+func (s *SQLite) RevokeToken(ctx context.Context, key string) (int64, error) {
+	id, _ := strconv.ParseInt(key, 10, 64)
+	result, err := s.db.ExecContext(ctx, `
+  UPDATE tokens
+  SET revoked_at = CURRENT_TIMESTAMP
+  WHERE revoked_at IS NULL AND (
+    id = ? OR prefix = ? OR user_id = (SELECT id FROM users WHERE name = ?)
+  )
+  `, id, key, key)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -1,8 +1,9 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"os"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -11,38 +12,36 @@ type SQLite struct {
 	db *sql.DB
 }
 
-type ContainerRecord struct {
-	LocalDir  string `json:"local_dir"`
-	RemoteDir string `json:"remote_dir"`
-	File      string `json:"file"`
-	Hostname  string `json:"hostname"`
-	IP        string `json:"ip"`
-	Content   string `json:"content"`
+type RecordFilter struct {
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+	Offset int
+	// * 執行者的使用者名稱，空字串時不過濾
+	User string
 }
 
-func NewSQLite(dbPath string) (*SQLite, error) {
+func Open(dbPath string) (*SQLite, error) {
 	db, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, err
 	}
+	return &SQLite{db: db}, nil
+}
 
-	s := &SQLite{db: db}
-	if err := s.create(); err != nil {
-		db.Close()
+// * 開啟並套用尚未執行的 migration
+func NewSQLite(dbPath string) (*SQLite, error) {
+	s, err := Open(dbPath)
+	if err != nil {
 		return nil, err
 	}
 
-	return s, nil
-}
-
-func (s *SQLite) create() error {
-	schema, err := os.ReadFile("sql/create.sql")
-	if err != nil {
-		return err
+	if _, err := s.Migrate(context.Background()); err != nil {
+		s.Close()
+		return nil, err
 	}
 
-	_, err = s.db.Exec(string(schema))
-	return err
+	return s, nil
 }
 
 func (s *SQLite) Close() error {
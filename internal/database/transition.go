@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// * 回傳目前狀態；pod 不存在時 exists 為 false
+func checkTransition(ctx context.Context, tx *sql.Tx, uid, to string) (bool, error) {
+	var from sql.NullString
+	err := tx.QueryRowContext(ctx, `
+  SELECT status FROM pods WHERE uid = ?
+  `, uid).Scan(&from)
+	if errors.Is(err, sql.ErrNoRows) {
+		if !model.ValidStatus(to) {
+			return false, fmt.Errorf("%w: unknown status %q", ErrInvalidTransition, to)
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if !model.ValidTransition(from.String, to) {
+		return true, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, displayStatus(from.String), displayStatus(to))
+	}
+	return true, nil
+}
+
+func displayStatus(status string) string {
+	if status == "" {
+		return "(none)"
+	}
+	return status
+}
+
+func dismissed(status string) int {
+	if status == model.StatusRemoved {
+		return 1
+	}
+	return 0
+}
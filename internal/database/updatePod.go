@@ -2,13 +2,35 @@ package database
 
 import (
 	"context"
+	"fmt"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/pardnchiu/go-podrun/internal/model"
 )
 
+// * 只更新狀態；dismiss 由狀態決定（removed 為 1）
+// * 舊版 client 只送 dismiss = 1 時視為 removed；pod 不存在時不做任何事
 func (s *SQLite) UpdatePod(ctx context.Context, d *model.Pod) error {
-	_, err := s.db.ExecContext(ctx, `
+	status := d.Status
+	if status == "" && d.Dismiss == 1 {
+		status = model.StatusRemoved
+	}
+	if status == "" {
+		return fmt.Errorf("%w: status is required", ErrInvalidTransition)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	exists, err := checkTransition(ctx, tx, d.UID, status)
+	if err != nil || !exists {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
   UPDATE pods
   SET
     status = ?,
@@ -16,9 +38,11 @@ func (s *SQLite) UpdatePod(ctx context.Context, d *model.Pod) error {
     dismiss = ?
   WHERE uid = ?
   `,
-		d.Status,
-		d.Dismiss,
+		status,
+		dismissed(status),
 		d.UID,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+var ErrDomainTaken = errors.New("domain is already used by another pod")
+
+func (s *SQLite) UpsertDomain(ctx context.Context, d *model.Domain) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// * 已 down 的 pod 仍可設定，下次 up 時套用；尚未登錄的 pod 回傳 sql.ErrNoRows
+	if err := tx.QueryRowContext(ctx, `
+  SELECT id FROM pods WHERE uid = ?
+  `, d.UID).Scan(&d.PodID); err != nil {
+		return err
+	}
+
+	// * 同一個 domain 只能指向一個運作中的 pod；已 down 的 pod 不佔用，其設定改由本次取代
+	rows, err := tx.QueryContext(ctx, `
+  SELECT domains.pod_id, pods.dismiss
+  FROM domains
+  JOIN pods ON domains.pod_id = pods.id
+  WHERE domains.dismiss = 0 AND domains.domain = ?
+  `, d.Domain)
+	if err != nil {
+		return err
+	}
+	exists := false
+	var stale []any
+	for rows.Next() {
+		var owner int64
+		var dismissed int
+		if err := rows.Scan(&owner, &dismissed); err != nil {
+			rows.Close()
+			return err
+		}
+		switch {
+		case owner == d.PodID:
+			exists = true
+		case dismissed == 0:
+			rows.Close()
+			return fmt.Errorf("%w: %s", ErrDomainTaken, d.Domain)
+		default:
+			stale = append(stale, owner)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, owner := range stale {
+		if _, err := tx.ExecContext(ctx, `
+  UPDATE domains
+  SET
+    updated_at = CURRENT_TIMESTAMP,
+    dismiss = 1
+  WHERE dismiss = 0 AND domain = ? AND pod_id = ?
+  `, d.Domain, owner); err != nil {
+			return err
+		}
+	}
+
+	if exists {
+		_, err = tx.ExecContext(ctx, `
+  UPDATE domains
+  SET
+    container_name = ?,
+    updated_at = CURRENT_TIMESTAMP
+  WHERE dismiss = 0 AND domain = ? AND pod_id = ?
+  `,
+			d.ContainerName,
+			d.Domain,
+			d.PodID,
+		)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+  INSERT INTO domains (
+    pod_id, container_name, domain
+  )
+  VALUES (
+    ?, ?, ?
+  )
+  `,
+			d.PodID,
+			d.ContainerName,
+			d.Domain,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
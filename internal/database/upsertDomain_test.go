@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func listDomainNames(t *testing.T, s *SQLite, uid string) []string {
+	t.Helper()
+
+	domains, err := s.ListDomains(context.Background(), uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range domains {
+		names = append(names, e.ContainerName+"="+e.Domain)
+	}
+	return names
+}
+
+func dismissPod(t *testing.T, s *SQLite, uid string) {
+	t.Helper()
+
+	for _, status := range []string{model.StatusRunning, model.StatusRemoved} {
+		if err := s.UpdatePod(context.Background(), &model.Pod{UID: uid, Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUpsertDomain(t *testing.T) {
+	s := newTestDB(t)
+	ctx := context.Background()
+
+	// * 尚未登錄的 pod
+	err := s.UpsertDomain(ctx, &model.Domain{UID: "a", ContainerName: "web", Domain: "a.example.com"})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("unregistered pod: got %v, want sql.ErrNoRows", err)
+	}
+
+	registerPod(t, s, "a", "prod")
+	registerPod(t, s, "b", "prod")
+	if err := s.UpsertDomain(ctx, &model.Domain{UID: "a", ContainerName: "web", Domain: "a.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	// * 同一個 pod 重設時改為新的服務
+	if err := s.UpsertDomain(ctx, &model.Domain{UID: "a", ContainerName: "api", Domain: "a.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := listDomainNames(t, s, "a"); len(got) != 1 || got[0] != "api=a.example.com" {
+		t.Fatalf("a domains = %v", got)
+	}
+
+	err = s.UpsertDomain(ctx, &model.Domain{UID: "b", ContainerName: "web", Domain: "a.example.com"})
+	if !errors.Is(err, ErrDomainTaken) {
+		t.Fatalf("taken by a running pod: got %v, want ErrDomainTaken", err)
+	}
+
+	// * a 已 down：a 自己仍可設定，b 可接手 a 的 domain
+	dismissPod(t, s, "a")
+	if err := s.UpsertDomain(ctx, &model.Domain{UID: "a", ContainerName: "web", Domain: "docs.example.com"}); err != nil {
+		t.Fatalf("dismissed pod: %v", err)
+	}
+	if err := s.UpsertDomain(ctx, &model.Domain{UID: "b", ContainerName: "web", Domain: "a.example.com"}); err != nil {
+		t.Fatalf("domain of a dismissed pod: %v", err)
+	}
+	if got := listDomainNames(t, s, "a"); len(got) != 1 || got[0] != "web=docs.example.com" {
+		t.Fatalf("a domains after takeover = %v", got)
+	}
+	if got := listDomainNames(t, s, "b"); len(got) != 1 || got[0] != "web=a.example.com" {
+		t.Fatalf("b domains = %v", got)
+	}
+}
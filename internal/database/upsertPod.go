@@ -2,22 +2,47 @@ package database
 
 import (
 	"context"
+	"fmt"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/pardnchiu/go-podrun/internal/model"
 )
 
+// * 狀態變更需符合 model.ValidTransition；新的 pod 需以 starting 登錄
 func (s *SQLite) UpsertPod(ctx context.Context, d *model.Pod) error {
-	_, err := s.db.ExecContext(ctx, `
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	exists, err := checkTransition(ctx, tx, d.UID, d.Status)
+	if err != nil {
+		return err
+	}
+	if !exists && !model.ValidInitial(d.Status) {
+		return fmt.Errorf("%w: new pod must start as %s, got %s", ErrInvalidTransition, model.StatusStarting, displayStatus(d.Status))
+	}
+
+	userID, err := resolveUser(ctx, tx, d.Owner)
+	if err != nil {
+		return err
+	}
+	serverID, err := resolveServer(ctx, tx, d.Server)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
   INSERT INTO pods (
     uid, pod_uid, pod_name, local_dir, remote_dir,
     file, target, status, hostname, ip,
-    replicas
+    replicas, dismiss, user_id, server_id, project
   )
   VALUES (
     ?, ?, ?, ?, ?,
     ?, ?, ?, ?, ?,
-    ?
+    ?, ?, ?, ?, ?
   )
   ON CONFLICT(uid) DO UPDATE SET
     pod_name = excluded.pod_name,
@@ -30,7 +55,10 @@ func (s *SQLite) UpsertPod(ctx context.Context, d *model.Pod) error {
     ip = excluded.ip,
     replicas = excluded.replicas,
     updated_at = CURRENT_TIMESTAMP,
-    dismiss = 0
+    dismiss = excluded.dismiss,
+    user_id = COALESCE(pods.user_id, excluded.user_id),
+    server_id = COALESCE(excluded.server_id, pods.server_id),
+    project = COALESCE(NULLIF(excluded.project, ''), pods.project)
   `,
 		d.UID,
 		d.PodID,
@@ -43,6 +71,12 @@ func (s *SQLite) UpsertPod(ctx context.Context, d *model.Pod) error {
 		d.Hostname,
 		d.IP,
 		d.Replicas,
-	)
-	return err
+		dismissed(d.Status),
+		userID,
+		serverID,
+		d.Project,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"context"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+// * 以 name 為識別更新連線資訊
+func (s *SQLite) UpsertServer(ctx context.Context, d *model.Server) error {
+	_, err := s.db.ExecContext(ctx, `
+  INSERT INTO servers (name, host, port, user, base_dir)
+  VALUES (?, ?, ?, ?, ?)
+  ON CONFLICT(name) DO UPDATE SET
+    host = excluded.host,
+    port = excluded.port,
+    user = excluded.user,
+    base_dir = excluded.base_dir,
+    updated_at = CURRENT_TIMESTAMP
+  `,
+		d.Name,
+		d.Host,
+		d.Port,
+		d.User,
+		d.BaseDir,
+	)
+	return err
+}
@@ -1,18 +1,23 @@
 package database
 
 import (
+	"context"
+
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/pardnchiu/go-podrun/internal/model"
 )
 
-// # NOT THIS PROJECT POINT, REMOVE IT FOR NOW
-// func (s *SQLite) UpsertUser(ctx context.Context, d *model.User) error {
-// 	_, err := s.db.ExecContext(ctx, `
-//   INSERT INTO users (email)
-//   VALUES (?)
-//   ON CONFLICT(email) DO UPDATE SET
-//     dismiss = 0
-//   `,
-// 		d.Email,
-// 	)
-// 	return err
-// }
+// * 以 name 為識別；email 為空時沿用原本的值，並回寫 d.ID
+func (s *SQLite) UpsertUser(ctx context.Context, d *model.User) error {
+	return s.db.QueryRowContext(ctx, `
+  INSERT INTO users (name, email)
+  VALUES (?, ?)
+  ON CONFLICT(name) DO UPDATE SET
+    email = CASE WHEN excluded.email = '' THEN users.email ELSE excluded.email END,
+    dismiss = 0
+  RETURNING id
+  `,
+		d.Name,
+		d.Email,
+	).Scan(&d.ID)
+}
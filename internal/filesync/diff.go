@@ -0,0 +1,50 @@
+package filesync
+
+import "sort"
+
+const (
+	Added    = "added"
+	Modified = "modified"
+	Deleted  = "deleted"
+)
+
+type Change struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Type byte   `json:"-"`
+	Size int64  `json:"size"`
+	// * 型別改變（例如檔案變資料夾）時需先刪除遠端舊項目
+	Replace bool `json:"-"`
+}
+
+func Diff(local, remote Manifest) []Change {
+	var changes []Change
+
+	for path, l := range local {
+		r, exists := remote[path]
+		switch {
+		case !exists:
+			changes = append(changes, Change{Path: path, Kind: Added, Type: l.Type, Size: l.Size})
+		case l.Type != r.Type:
+			changes = append(changes, Change{Path: path, Kind: Modified, Type: l.Type, Size: l.Size, Replace: true})
+		case l.Type == 'f' && (l.Hash != r.Hash || l.Mode != r.Mode):
+			changes = append(changes, Change{Path: path, Kind: Modified, Type: l.Type, Size: l.Size})
+		// * 資料夾權限由 tar -p 套用至既有資料夾
+		case l.Type == 'd' && l.Mode != r.Mode:
+			changes = append(changes, Change{Path: path, Kind: Modified, Type: l.Type})
+		case l.Type == 'l' && l.Target != r.Target:
+			changes = append(changes, Change{Path: path, Kind: Modified, Type: l.Type, Replace: true})
+		}
+	}
+
+	for path, r := range remote {
+		if _, exists := local[path]; !exists {
+			changes = append(changes, Change{Path: path, Kind: Deleted, Type: r.Type})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
@@ -0,0 +1,84 @@
+package filesync
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	file := func(hash string, mode fs.FileMode) Entry {
+		return Entry{Type: 'f', Hash: hash, Mode: mode, Size: int64(len(hash))}
+	}
+
+	tests := []struct {
+		name   string
+		local  Manifest
+		remote Manifest
+		want   []string
+	}{
+		{
+			name:   "identical",
+			local:  Manifest{"a": file("x", 0644), "d": {Type: 'd', Mode: 0755}},
+			remote: Manifest{"a": file("x", 0644), "d": {Type: 'd', Mode: 0755}},
+		},
+		{
+			name:   "added and deleted",
+			local:  Manifest{"new": file("x", 0644)},
+			remote: Manifest{"old": file("y", 0644)},
+			want:   []string{"new added f", "old deleted f"},
+		},
+		{
+			name:   "content changed",
+			local:  Manifest{"a": file("x", 0644)},
+			remote: Manifest{"a": file("y", 0644)},
+			want:   []string{"a modified f"},
+		},
+		{
+			name:   "file mode changed",
+			local:  Manifest{"run.sh": file("x", 0755)},
+			remote: Manifest{"run.sh": file("x", 0644)},
+			want:   []string{"run.sh modified f"},
+		},
+		{
+			name:   "dir mode changed",
+			local:  Manifest{"d": {Type: 'd', Mode: 0700}},
+			remote: Manifest{"d": {Type: 'd', Mode: 0755}},
+			want:   []string{"d modified d"},
+		},
+		{
+			name:   "file replaced by dir",
+			local:  Manifest{"a": {Type: 'd', Mode: 0755}},
+			remote: Manifest{"a": file("x", 0644)},
+			want:   []string{"a modified d replace"},
+		},
+		{
+			name:   "symlink target changed",
+			local:  Manifest{"link": {Type: 'l', Target: "b"}},
+			remote: Manifest{"link": {Type: 'l', Target: "a"}},
+			want:   []string{"link modified l replace"},
+		},
+		{
+			name:   "sorted by path",
+			local:  Manifest{"b": file("x", 0644), "a/c": file("x", 0644), "a": {Type: 'd', Mode: 0755}},
+			remote: Manifest{},
+			want:   []string{"a added d", "a/c added f", "b added f"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range Diff(tt.local, tt.remote) {
+				s := fmt.Sprintf("%s %s %c", c.Path, c.Kind, c.Type)
+				if c.Replace {
+					s += " replace"
+				}
+				got = append(got, s)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
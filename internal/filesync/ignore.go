@@ -0,0 +1,246 @@
+package filesync
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// * gitignore 語法的排除規則：後面的規則優先，! 重新納入，
+// * 開頭或中間含 / 時以同步根目錄為基準，否則比對任一層名稱；結尾 / 只比對資料夾
+type Ignore struct {
+	rules []rule
+}
+
+type rule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+	// * 以同步根目錄為基準；否則可出現在任一層
+	anchored bool
+	// * 含 / 時比對完整路徑，否則只比對名稱
+	fullPath bool
+	re       *regexp.Regexp
+}
+
+func NewIgnore(patterns ...string) *Ignore {
+	ig := &Ignore{}
+	ig.Add(patterns...)
+	return ig
+}
+
+func (ig *Ignore) Add(patterns ...string) {
+	for _, line := range patterns {
+		if r, ok := parseRule(line); ok {
+			ig.rules = append(ig.rules, r)
+		}
+	}
+}
+
+// * 檔案不存在時略過
+func (ig *Ignore) AddFile(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		ig.Add(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+func parseRule(line string) (rule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// * 結尾空白除非以 \ 跳脫，否則忽略
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		r.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	// * **/foo 可出現在任一層，與 rsync 不以 / 開頭的規則相同
+	for strings.HasPrefix(line, "**/") {
+		line = strings.TrimPrefix(line, "**/")
+		r.anchored = false
+	}
+	r.fullPath = r.anchored || strings.Contains(line, "/")
+
+	prefix := "^"
+	if r.fullPath && !r.anchored {
+		prefix = "^(?:.*/)?"
+	}
+	re, err := regexp.Compile(prefix + globRegexp(line) + "$")
+	if err != nil {
+		return rule{}, false
+	}
+	r.pattern = line
+	r.re = re
+	return r, true
+}
+
+// * * 與 ? 不跨越 /；/**/ 可比對零或多層資料夾，結尾 /** 比對其下所有內容
+func globRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "/**/"):
+			b.WriteString("(?:/.*)?/")
+			i += 3
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+func (r rule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.fullPath {
+		return r.re.MatchString(rel)
+	}
+	return r.re.MatchString(path.Base(rel))
+}
+
+// * 只判斷 rel 本身，呼叫端需自行略過已排除資料夾的內容
+func (ig *Ignore) Match(rel string, isDir bool) bool {
+	if ig == nil {
+		return false
+	}
+	ignored := false
+	for _, r := range ig.rules {
+		if r.match(rel, isDir) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// * 連同上層資料夾一起判斷；與 git 相同，上層被排除時無法以 ! 重新納入
+func (ig *Ignore) MatchPath(rel string, isDir bool) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if ig.Match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return ig.Match(rel, isDir)
+}
+
+// * 轉為 rsync --filter 參數；rsync 採第一個符合的規則，因此反向輸出
+func (ig *Ignore) RsyncFilters() []string {
+	if ig == nil {
+		return nil
+	}
+	filters := make([]string, 0, len(ig.rules))
+	for i := len(ig.rules) - 1; i >= 0; i-- {
+		r := ig.rules[i]
+		pattern := r.pattern
+		if r.anchored {
+			pattern = "/" + pattern
+		}
+		if r.dirOnly {
+			pattern += "/"
+		}
+		action := "- "
+		if r.negate {
+			action = "+ "
+		}
+		filters = append(filters, "--filter="+action+pattern)
+		// * rsync 的 /**/ 至少比對一層，補上零層的版本
+		if strings.Contains(pattern, "/**/") {
+			filters = append(filters, "--filter="+action+strings.ReplaceAll(pattern, "/**/", "/"))
+		}
+	}
+	return filters
+}
+
+// * 轉為 find 的 -prune 條件，避免遠端走訪與雜湊已排除的資料夾（例如 node_modules）
+// * 只使用之後沒有 ! 規則的名稱或固定路徑規則；其餘仍由 Match 過濾，結果不變
+func (ig *Ignore) FindPrune() string {
+	if ig == nil {
+		return ""
+	}
+	var tests []string
+	for i, r := range ig.rules {
+		if r.negate || ig.negatedAfter(i) || strings.Contains(r.pattern, `\`) {
+			continue
+		}
+		switch {
+		case !r.fullPath && !strings.Contains(r.pattern, "**"):
+			// * gitignore 與 find -name 的 * ? [] 都不跨越 /
+			tests = append(tests, "-name "+shellQuote(r.pattern))
+		case r.anchored && !strings.ContainsAny(r.pattern, "*?["):
+			tests = append(tests, "-path "+shellQuote("./"+r.pattern))
+		}
+	}
+	if len(tests) == 0 {
+		return ""
+	}
+	return `\( -type d \( ` + strings.Join(tests, " -o ") + ` \) \) -prune -o`
+}
+
+func (ig *Ignore) negatedAfter(i int) bool {
+	for _, r := range ig.rules[i+1:] {
+		if r.negate {
+			return true
+		}
+	}
+	return false
+}
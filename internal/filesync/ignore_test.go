@@ -0,0 +1,237 @@
+package filesync
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{"name at root", []string{"*.log"}, "debug.log", false, true},
+		{"name in subdir", []string{"*.log"}, "a/b/debug.log", false, true},
+		{"name does not match", []string{"*.log"}, "log", false, false},
+		{"negation", []string{"*.log", "!keep.log"}, "a/keep.log", false, false},
+		{"later rule wins", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"leading slash anchors", []string{"/build"}, "build", true, true},
+		{"leading slash skips subdir", []string{"/build"}, "src/build", true, false},
+		{"middle slash anchors", []string{"docs/*.md"}, "docs/a.md", false, true},
+		{"middle slash not in subdir", []string{"docs/*.md"}, "x/docs/a.md", false, false},
+		{"star does not cross slash", []string{"docs/*.md"}, "docs/sub/a.md", false, false},
+		{"leading ** at root", []string{"**/cache"}, "cache", true, true},
+		{"leading ** in subdir", []string{"**/cache"}, "a/b/cache", true, true},
+		{"leading ** with path", []string{"**/a/z"}, "x/a/z", false, true},
+		{"middle ** zero dirs", []string{"a/**/z"}, "a/z", false, true},
+		{"middle ** many dirs", []string{"a/**/z"}, "a/b/c/z", false, true},
+		{"middle ** stays anchored", []string{"a/**/z"}, "b/a/z", false, false},
+		{"trailing ** matches contents", []string{"logs/**"}, "logs/a/b", false, true},
+		{"trailing ** not the dir", []string{"logs/**"}, "logs", true, false},
+		{"dir only matches dir", []string{"tmp/"}, "a/tmp", true, true},
+		{"dir only skips file", []string{"tmp/"}, "tmp", false, false},
+		{"question mark", []string{"?.c"}, "x.c", false, true},
+		{"question mark single char", []string{"?.c"}, "xy.c", false, false},
+		{"class", []string{"[ab].txt"}, "a.txt", false, true},
+		{"negated class", []string{"[!ab].txt"}, "a.txt", false, false},
+		{"escaped bang", []string{`\!important`}, "!important", false, true},
+		{"escaped hash", []string{`\#hash`}, "#hash", false, true},
+		{"comment", []string{"# debug.log"}, "# debug.log", false, false},
+		{"trailing spaces", []string{"foo   "}, "foo", false, true},
+		{"escaped trailing space", []string{`foo\ `}, "foo ", false, true},
+		{"no rules", nil, "anything", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewIgnore(tt.patterns...).Match(tt.path, tt.isDir); got != tt.want {
+				t.Fatalf("Match(%q) with %q = %v, want %v", tt.path, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+// * 上層資料夾被排除時，! 無法重新納入其下的檔案
+func TestIgnoreMatchPath(t *testing.T) {
+	ig := NewIgnore("node_modules/", "!node_modules/keep", "*.tmp", "!important.tmp")
+
+	tests := map[string]bool{
+		"node_modules/keep":       true,
+		"src/node_modules/x/y.js": true,
+		"src/a.tmp":               true,
+		"src/important.tmp":       false,
+		"src/main.go":             false,
+	}
+	for path, want := range tests {
+		if got := ig.MatchPath(path, false); got != want {
+			t.Errorf("MatchPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestRsyncFilters(t *testing.T) {
+	ig := NewIgnore("*.log", "!keep.log", "/build/", "a/**/z", "**/cache")
+
+	want := []string{
+		"--filter=- cache",
+		"--filter=- /a/**/z",
+		"--filter=- /a/z",
+		"--filter=- /build/",
+		"--filter=+ keep.log",
+		"--filter=- *.log",
+	}
+	if got := ig.RsyncFilters(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if filters := (*Ignore)(nil).RsyncFilters(); filters != nil {
+		t.Fatalf("nil Ignore = %q", filters)
+	}
+}
+
+func TestFindPrune(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     string
+	}{
+		{
+			name:     "names and fixed paths",
+			patterns: []string{"node_modules/", "/dist", "*.tmp", "build/**", `a\b`, "src/*/gen"},
+			want:     `\( -type d \( -name 'node_modules' -o -path './dist' -o -name '*.tmp' \) \) -prune -o`,
+		},
+		{
+			name:     "rules before a negation are kept out",
+			patterns: []string{"node_modules/", "!keep", "cache/"},
+			want:     `\( -type d \( -name 'cache' \) \) -prune -o`,
+		},
+		{
+			name:     "only negations",
+			patterns: []string{"!keep"},
+			want:     "",
+		},
+		{
+			name:     "quotes",
+			patterns: []string{"it's"},
+			want:     `\( -type d \( -name 'it'\''s' \) \) -prune -o`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewIgnore(tt.patterns...).FindPrune(); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+var agreementRules = []string{
+	"*.log", "!keep.log", "/build", "node_modules/", "docs/*.md",
+	"a/**/z", "**/cache", "!src/cache/", "tmp/", "logs/**",
+}
+
+var agreementTree = map[string]string{
+	"a.log":                     "",
+	"keep.log":                  "",
+	"src/keep.log":              "",
+	"src/main.go":               "",
+	"src/build/x":               "",
+	"build/out":                 "",
+	"node_modules/p/index":      "",
+	"web/node_modules/p/x":      "",
+	"docs/a.md":                 "",
+	"docs/sub/b.md":             "",
+	"a/z":                       "",
+	"a/b/z/file":                "",
+	"b/a/z":                     "",
+	"cache/c":                   "",
+	"src/cache/d":               "",
+	"lib/cache/e":               "",
+	"tmp/t":                     "",
+	"src/tmp":                   "",
+	"logs/2024/app.txt":         "",
+	"docker-compose.yml":        "",
+	"docker-compose.podrun.yml": "",
+}
+
+// * BuildLocal 依 Match 走訪後保留的路徑
+func matchedPaths(t *testing.T, root string, ig *Ignore) []string {
+	t.Helper()
+
+	m, err := BuildLocal(root, ig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make([]string, 0, len(m))
+	for path := range m {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func outputPaths(output string, trim string) []string {
+	var paths []string
+	for line := range strings.SplitSeq(output, "\n") {
+		line = strings.TrimSuffix(strings.TrimPrefix(line, trim), "/")
+		if line == "" || line == "." {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// * rsync 依 RsyncFilters 傳送的路徑需與 Match 相同
+func TestRsyncFiltersAgreeWithMatch(t *testing.T) {
+	if _, err := exec.LookPath("rsync"); err != nil {
+		t.Skip("rsync not found")
+	}
+	root := t.TempDir()
+	writeTree(t, root, agreementTree)
+	ig := NewIgnore(agreementRules...)
+
+	args := append([]string{"-a", "-n", "--out-format=%n"}, ig.RsyncFilters()...)
+	args = append(args, root+"/", filepath.Join(t.TempDir(), "dest")+"/")
+	out, err := exec.Command("rsync", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("rsync: %v\n%s", err, out)
+	}
+
+	got, want := outputPaths(string(out), ""), matchedPaths(t, root, ig)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("rsync sent\n%q\nMatch kept\n%q", got, want)
+	}
+}
+
+// * find 的 -prune 只能少走訪已排除的資料夾，不可略過 Match 保留的路徑
+func TestFindPruneAgreesWithMatch(t *testing.T) {
+	if out, err := exec.Command("find", ".", "-maxdepth", "0", "-printf", "ok").Output(); err != nil || string(out) != "ok" {
+		t.Skip("GNU find is required")
+	}
+	root := t.TempDir()
+	writeTree(t, root, agreementTree)
+	ig := NewIgnore(agreementRules...)
+
+	out, err := exec.Command("sh", "-c", "cd "+shellQuote(root)+" && find . -mindepth 1 "+ig.FindPrune()+" -print").CombinedOutput()
+	if err != nil {
+		t.Fatalf("find: %v\n%s", err, out)
+	}
+	found := map[string]bool{}
+	for _, path := range outputPaths(string(out), "./") {
+		found[path] = true
+	}
+	for _, path := range matchedPaths(t, root, ig) {
+		if !found[path] {
+			t.Errorf("%s is kept by Match but pruned by find", path)
+		}
+	}
+}
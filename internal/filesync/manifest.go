@@ -0,0 +1,94 @@
+package filesync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type Entry struct {
+	Path   string      `json:"path"`
+	Type   byte        `json:"type"` // * f: file, d: dir, l: symlink
+	Mode   fs.FileMode `json:"mode"`
+	Size   int64       `json:"size"`
+	Hash   string      `json:"hash"`
+	Target string      `json:"target,omitempty"`
+}
+
+type Manifest map[string]Entry
+
+func BuildLocal(root string, ignore *Ignore) (Manifest, error) {
+	m := Manifest{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if ignore.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		e := Entry{
+			Path: rel,
+			Mode: info.Mode().Perm(),
+		}
+		switch {
+		case d.IsDir():
+			e.Type = 'd'
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			e.Type = 'l'
+			e.Target = target
+		case info.Mode().IsRegular():
+			hash, err := hashFile(path)
+			if err != nil {
+				return err
+			}
+			e.Type = 'f'
+			e.Size = info.Size()
+			e.Hash = hash
+		default:
+			// * socket / device 等特殊檔案不同步
+			return nil
+		}
+		m[rel] = e
+		return nil
+	})
+	return m, err
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,158 @@
+package filesync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// * 依路徑排序輸出 manifest，便於比對
+func describe(m Manifest) []string {
+	var lines []string
+	for _, e := range m {
+		line := fmt.Sprintf("%c %s %o", e.Type, e.Path, e.Mode)
+		switch e.Type {
+		case 'f':
+			line += fmt.Sprintf(" %d %.8s", e.Size, e.Hash)
+		case 'l':
+			line += " -> " + e.Target
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBuildLocal(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"app/main.go":          "package main\n",
+		"app/node_modules/x":   "x",
+		"debug.log":            "log",
+		"keep.log":             "keep",
+		"docker-compose.yml":   "services: {}\n",
+		".podrun/session.json": "{}",
+	})
+	if err := os.Chmod(filepath.Join(root, "app"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("app/main.go", filepath.Join(root, "main")); err != nil {
+		t.Skipf("symlink unavailable: %v", err)
+	}
+
+	m, err := BuildLocal(root, NewIgnore("node_modules/", "*.log", "!keep.log", "/.podrun"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"d app 700",
+		"f app/main.go 644 13 df1d036c",
+		"f docker-compose.yml 644 13 fa6ccea1",
+		"f keep.log 644 4 6ca7ea2f",
+		"l main 777 -> app/main.go",
+	}
+	if got := describe(m); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestParseListing(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		ignore  *Ignore
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "types",
+			output: "d\t755\t4096\t\tapp\x00f\t644\t12\t\tapp/main.go\x00l\t777\t11\tapp/main.go\tmain\x00",
+			want:   []string{"d app 755", "f app/main.go 644 12 ", "l main 777 -> app/main.go"},
+		},
+		{
+			name:   "names with tab and newline",
+			output: "f\t600\t1\t\ta\tb\x00f\t644\t2\t\tline\nbreak\x00",
+			want:   []string{"f a\tb 600 1 ", "f line\nbreak 644 2 "},
+		},
+		{
+			name:   "special files are skipped",
+			output: "s\t755\t0\t\tsock\x00p\t644\t0\t\tfifo\x00f\t644\t1\t\tok\x00",
+			want:   []string{"f ok 644 1 "},
+		},
+		{
+			name:   "ignored parent excludes children",
+			output: "d\t755\t0\t\tnode_modules\x00f\t644\t1\t\tnode_modules/x\x00f\t644\t1\t\tsrc/node_modules.go\x00",
+			ignore: NewIgnore("node_modules/"),
+			want:   []string{"f src/node_modules.go 644 1 "},
+		},
+		{
+			name:   "empty",
+			output: "",
+		},
+		{
+			name:    "truncated record",
+			output:  "f\t644\t1\x00",
+			wantErr: true,
+		},
+		{
+			name:    "invalid mode",
+			output:  "f\trw-\t1\t\ta\x00",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseListing(tt.output, tt.ignore)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", describe(m))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(m); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHashes(t *testing.T) {
+	m := Manifest{
+		"a":         {Path: "a", Type: 'f'},
+		"dir/b c":   {Path: "dir/b c", Type: 'f'},
+		"line\nend": {Path: "line\nend", Type: 'f'},
+		"dir":       {Path: "dir", Type: 'd'},
+	}
+	output := "aaaa  ./a\x00bbbb  ./dir/b c\x00cccc  ./line\nend\x00dddd  ./ignored\x00"
+	parseHashes(output, m)
+
+	want := map[string]string{"a": "aaaa", "dir/b c": "bbbb", "line\nend": "cccc", "dir": ""}
+	for path, hash := range want {
+		if m[path].Hash != hash {
+			t.Errorf("%q hash = %q, want %q", path, m[path].Hash, hash)
+		}
+	}
+	if _, ok := m["ignored"]; ok {
+		t.Error("hash of a path outside the manifest was added")
+	}
+}
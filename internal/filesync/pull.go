@@ -0,0 +1,248 @@
+package filesync
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pardnchiu/go-podrun/internal/transport"
+)
+
+// * 依遠端 manifest（已套用 ignore）打包，避免依賴 tar --exclude 的規則差異
+func Pull(client *transport.Client, remoteDir, localDir string, ignore *Ignore) (int, error) {
+	remote, err := FetchRemote(client, remoteDir, ignore)
+	if err != nil {
+		return 0, err
+	}
+	if len(remote) == 0 {
+		return 0, fmt.Errorf("remote folder is empty or missing: %s", remoteDir)
+	}
+
+	paths := make([]string, 0, len(remote))
+	for path := range remote {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var list bytes.Buffer
+	for _, path := range paths {
+		list.WriteString(path + "\x00")
+	}
+
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		return 0, err
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := readTar(pr, localDir)
+		pr.CloseWithError(err)
+		done <- err
+	}()
+
+	var stderr bytes.Buffer
+	code, err := client.Run(
+		fmt.Sprintf("cd %s && tar -czpf - --null --no-recursion -T -", shellQuote(remoteDir)),
+		&transport.RunOptions{
+			Stdin:  &list,
+			Stdout: pw,
+			Stderr: &stderr,
+		},
+	)
+	pw.Close()
+	if extractErr := <-done; extractErr != nil && err == nil {
+		err = extractErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if code != 0 {
+		return 0, fmt.Errorf("remote exited with status %d: %s", code, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return len(paths), nil
+}
+
+func readTar(r io.Reader, localDir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	root, err := filepath.Abs(localDir)
+	if err != nil {
+		return err
+	}
+
+	// * symlink 在所有檔案寫入後才建立，檔案不會經由本次建立的連結寫出
+	var links []*tar.Header
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return createLinks(root, links)
+		}
+		if err != nil {
+			return err
+		}
+
+		// * 拒絕跳出目標資料夾的路徑
+		path := filepath.Join(root, filepath.FromSlash(header.Name))
+		if !within(root, path) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+		// * 不經由既有的 symlink 寫入（上層資料夾或目標本身）
+		if err := checkParents(root, path); err != nil {
+			return err
+		}
+		mode := os.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				return fmt.Errorf("refusing to write through symlink: %s", header.Name)
+			}
+			if err := os.MkdirAll(path, mode|0700); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			// * 僅允許指向目標資料夾內的相對連結
+			target := filepath.FromSlash(header.Linkname)
+			if filepath.IsAbs(target) || !within(root, filepath.Join(filepath.Dir(path), target)) {
+				return fmt.Errorf("symlink escapes target folder: %s -> %s", header.Name, header.Linkname)
+			}
+			links = append(links, header)
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			// * 既有的 symlink 先移除，避免覆寫連結指向的檔案
+			if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// * 依序建立後再以實際的連結解析每個目標，避免 a -> . 與 sub/b -> ../a/.. 這類串接跳出目標資料夾
+// * 任一連結跳出時移除本次建立的所有連結
+func createLinks(root string, links []*tar.Header) error {
+	created := make([]string, 0, len(links))
+	cleanup := func() {
+		for _, path := range created {
+			os.Remove(path)
+		}
+	}
+	for _, header := range links {
+		path := filepath.Join(root, filepath.FromSlash(header.Name))
+		if err := checkParents(root, path); err != nil {
+			cleanup()
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			cleanup()
+			return err
+		}
+		os.Remove(path)
+		if err := os.Symlink(header.Linkname, path); err != nil {
+			cleanup()
+			return err
+		}
+		created = append(created, path)
+	}
+	for i, path := range created {
+		if _, err := resolveLink(root, filepath.Dir(path), links[i].Linkname, 0); err != nil {
+			cleanup()
+			return fmt.Errorf("symlink escapes target folder: %s -> %s: %w", links[i].Name, links[i].Linkname, err)
+		}
+	}
+	return nil
+}
+
+const maxLinkDepth = 40
+
+// * 逐段解析 target，遇到已存在的 symlink 時展開；任一步驟離開 root 即回傳錯誤
+// * 不存在的路徑視為一般資料夾（允許指向尚未存在的檔案）
+func resolveLink(root, dir, target string, depth int) (string, error) {
+	if depth > maxLinkDepth {
+		return "", fmt.Errorf("too many levels of symbolic links")
+	}
+	target = filepath.FromSlash(target)
+	if filepath.IsAbs(target) {
+		return "", fmt.Errorf("absolute target")
+	}
+	current := dir
+	for _, part := range strings.Split(target, string(os.PathSeparator)) {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+		default:
+			current = filepath.Join(current, part)
+		}
+		if !within(root, current) {
+			return "", fmt.Errorf("resolves outside %s", root)
+		}
+		if part == ".." {
+			continue
+		}
+		info, err := os.Lstat(current)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		next, err := os.Readlink(current)
+		if err != nil {
+			return "", err
+		}
+		if current, err = resolveLink(root, filepath.Dir(current), next, depth+1); err != nil {
+			return "", err
+		}
+	}
+	return current, nil
+}
+
+func within(root, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(os.PathSeparator))
+}
+
+// * 逐層檢查 root 與 path 之間的資料夾，任一層為 symlink 即拒絕
+func checkParents(root, path string) error {
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return err
+	}
+	current := root
+	for _, part := range strings.Split(rel, string(os.PathSeparator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to write through symlink: %s", current)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package filesync
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// * 測試用的 tar 項目；link 不為空時為 symlink，name 以 / 結尾時為資料夾
+type tarEntry struct {
+	name string
+	body string
+	link string
+}
+
+func buildTar(t *testing.T, entries ...tarEntry) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		switch {
+		case e.link != "":
+			header = &tar.Header{Name: e.name, Mode: 0777, Typeflag: tar.TypeSymlink, Linkname: e.link}
+		case strings.HasSuffix(e.name, "/"):
+			header = &tar.Header{Name: e.name, Mode: 0755, Typeflag: tar.TypeDir}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+// * 回傳解壓目標與其外層資料夾（用於確認沒有寫到外面）
+func extractDirs(t *testing.T) (string, string) {
+	t.Helper()
+
+	outside := t.TempDir()
+	root := filepath.Join(outside, "project")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return root, outside
+}
+
+func TestReadTar(t *testing.T) {
+	root, _ := extractDirs(t)
+	err := readTar(buildTar(t,
+		tarEntry{name: "src/"},
+		tarEntry{name: "src/main.go", body: "package main\n"},
+		tarEntry{name: "current", link: "src"},
+		tarEntry{name: "src/up", link: "../README.md"},
+		tarEntry{name: "README.md", body: "hello\n"},
+	), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "current", "main.go")); err != nil || string(data) != "package main\n" {
+		t.Fatalf("current/main.go = %q, %v", data, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "src", "up")); err != nil || string(data) != "hello\n" {
+		t.Fatalf("src/up = %q, %v", data, err)
+	}
+}
+
+func TestReadTarRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+	}{
+		{"parent path", []tarEntry{{name: "../evil", body: "x"}}},
+		{"nested parent path", []tarEntry{{name: "src/../../evil", body: "x"}}},
+		{"absolute link", []tarEntry{{name: "passwd", link: "/etc/passwd"}}},
+		{"escaping link", []tarEntry{{name: "src/out", link: "../../evil"}}},
+		{"link chain", []tarEntry{
+			{name: "a", link: "."},
+			{name: "sub/b", link: "../a/.."},
+		}},
+		{"link chain in reverse order", []tarEntry{
+			{name: "sub/b", link: "../a/.."},
+			{name: "a", link: "."},
+		}},
+		{"nested link chain", []tarEntry{
+			{name: "a", link: "."},
+			{name: "b", link: "a/a/a"},
+			{name: "c", link: "b/.."},
+		}},
+		{"link loop", []tarEntry{
+			{name: "a", link: "b"},
+			{name: "b", link: "a/x"},
+		}},
+		// * 檔案先寫入實體資料夾，之後無法再以 symlink 取代
+		{"file under a link", []tarEntry{
+			{name: "l", link: "src"},
+			{name: "l/f", body: "x"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, outside := extractDirs(t)
+			if err := readTar(buildTar(t, tt.entries...), root); err == nil {
+				t.Fatal("expected an error")
+			}
+
+			// * 外層資料夾只有目標本身，且目標內沒有留下 symlink
+			entries, err := os.ReadDir(outside)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("wrote outside the target: %v", entries)
+			}
+			filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+				if err == nil && d.Type()&os.ModeSymlink != 0 {
+					t.Errorf("symlink left behind: %s", path)
+				}
+				return nil
+			})
+		})
+	}
+}
+
+// * 目標資料夾中既有的 symlink 不能被用來寫到外面
+func TestReadTarExistingSymlinks(t *testing.T) {
+	root, outside := extractDirs(t)
+	secret := filepath.Join(outside, "secret")
+	if err := os.WriteFile(secret, []byte("keep"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(root, "file")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := readTar(buildTar(t, tarEntry{name: "dir/secret", body: "x"}), root); err == nil {
+		t.Fatal("wrote through a symlinked folder")
+	}
+
+	// * 同名檔案取代 symlink 本身，不覆寫連結指向的檔案
+	if err := readTar(buildTar(t, tarEntry{name: "file", body: "new"}), root); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(secret); string(data) != "keep" {
+		t.Fatalf("secret = %q, written through symlink", data)
+	}
+	if info, err := os.Lstat(filepath.Join(root, "file")); err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("file is not a regular file: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package filesync
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pardnchiu/go-podrun/internal/transport"
+)
+
+func Push(client *transport.Client, localDir, remoteDir string, changes []Change) error {
+	dir := shellQuote(remoteDir)
+
+	// * 先刪除，再以 tar 串流傳送新增與變更的檔案
+	var removes bytes.Buffer
+	var uploads []Change
+	for _, e := range changes {
+		if e.Kind == Deleted || e.Replace {
+			removes.WriteString(e.Path + "\x00")
+		}
+		if e.Kind != Deleted {
+			uploads = append(uploads, e)
+		}
+	}
+
+	if removes.Len() > 0 {
+		if err := runRemote(client,
+			fmt.Sprintf("cd %s && xargs -0 -r rm -rf --", dir),
+			&removes,
+		); err != nil {
+			return fmt.Errorf("delete remote files: %w", err)
+		}
+	}
+
+	if len(uploads) == 0 {
+		return nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeTar(pw, localDir, uploads))
+	}()
+
+	if err := runRemote(client,
+		fmt.Sprintf("mkdir -p %s && tar -xzpf - -C %s", dir, dir),
+		pr,
+	); err != nil {
+		pr.CloseWithError(err)
+		return fmt.Errorf("upload files: %w", err)
+	}
+	return nil
+}
+
+func writeTar(w io.Writer, localDir string, changes []Change) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, e := range changes {
+		path := filepath.Join(localDir, filepath.FromSlash(e.Path))
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = e.Path
+		header.Uname, header.Gname = "", ""
+		header.Uid, header.Gid = 0, 0
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(tw, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func runRemote(client *transport.Client, command string, stdin io.Reader) error {
+	var stderr bytes.Buffer
+	code, err := client.Run(command, &transport.RunOptions{
+		Stdin:  stdin,
+		Stdout: io.Discard,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("remote exited with status %d: %s", code, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package filesync
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/pardnchiu/go-podrun/internal/transport"
+)
+
+// * 遠端缺少 GNU find（例如 BusyBox、BSD）時無法列出檔案，不可視為空資料夾
+var ErrFindPrintf = errors.New("remote find does not support -printf, install GNU findutils on the server")
+
+// * 探測失敗時以此結束碼回報
+const findProbeExit = 3
+
+// * 遠端只依賴 GNU find / sha256sum，不需安裝 podrun
+func FetchRemote(client *transport.Client, remoteDir string, ignore *Ignore) (Manifest, error) {
+	dir := shellQuote(remoteDir)
+	prune := ignore.FindPrune()
+
+	listing, err := client.Output(fmt.Sprintf(
+		`[ "$(find . -maxdepth 0 -printf ok 2>/dev/null)" = ok ] || exit %d; `+
+			`[ -d %s ] || exit 0; cd %s && find . -mindepth 1 %s -printf '%%y\t%%m\t%%s\t%%l\t%%P\0'`,
+		findProbeExit, dir, dir, prune,
+	))
+	if err != nil {
+		return nil, err
+	}
+	switch listing.ExitCode {
+	case 0:
+	case findProbeExit:
+		return nil, ErrFindPrintf
+	default:
+		return nil, fmt.Errorf("list remote files: %s", strings.TrimSpace(listing.Stderr))
+	}
+
+	m, err := parseListing(listing.Stdout, ignore)
+	if err != nil || len(m) == 0 {
+		return m, err
+	}
+
+	hashes, err := client.Output(fmt.Sprintf(
+		`cd %s && find . %s -type f -print0 | xargs -0 -r sha256sum -z`,
+		dir, prune,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if hashes.ExitCode != 0 {
+		return nil, fmt.Errorf("hash remote files: %s", strings.TrimSpace(hashes.Stderr))
+	}
+	parseHashes(hashes.Stdout, m)
+	return m, nil
+}
+
+// * find -printf '%y\t%m\t%s\t%l\t%P\0' 的輸出；檔名可含 tab 與換行，因此只切前四個欄位
+func parseListing(output string, ignore *Ignore) (Manifest, error) {
+	m := Manifest{}
+	for record := range strings.SplitSeq(output, "\x00") {
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\t", 5)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected remote listing: %q", record)
+		}
+
+		var typ byte
+		switch fields[0] {
+		case "f", "d", "l":
+			typ = fields[0][0]
+		default:
+			continue
+		}
+		rel := fields[4]
+		// * 遠端清單為平面路徑，需連同上層資料夾一起判斷
+		if ignore.MatchPath(rel, typ == 'd') {
+			continue
+		}
+
+		mode, err := strconv.ParseUint(fields[1], 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected remote listing: %q", record)
+		}
+		size, _ := strconv.ParseInt(fields[2], 10, 64)
+		e := Entry{
+			Path: rel,
+			Type: typ,
+			Mode: fs.FileMode(mode).Perm(),
+		}
+		switch typ {
+		case 'f':
+			e.Size = size
+		case 'l':
+			e.Target = fields[3]
+		}
+		m[rel] = e
+	}
+	return m, nil
+}
+
+// * sha256sum -z 的輸出為 "<hash>  ./<path>\0"，只填入 manifest 中已有的檔案
+func parseHashes(output string, m Manifest) {
+	for record := range strings.SplitSeq(output, "\x00") {
+		hash, path, ok := strings.Cut(record, "  ")
+		if !ok {
+			continue
+		}
+		rel := strings.TrimPrefix(path, "./")
+		if e, exists := m[rel]; exists && e.Type == 'f' {
+			e.Hash = hash
+			m[rel] = e
+		}
+	}
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,247 @@
+package filesync
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/pardnchiu/go-podrun/internal/transport"
+	"golang.org/x/crypto/ssh"
+)
+
+// * 與 transport 測試相同的 in-process SSH server，exec 交給本機 sh 執行；
+// * env 會附加到指令環境，用於替換 PATH 上的工具
+func dialShell(t *testing.T, env ...string) *transport.Client {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	for _, tool := range []string{"sh", "find", "sha256sum", "tar", "xargs"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not found", tool)
+		}
+	}
+	if out, err := exec.Command("find", ".", "-maxdepth", "0", "-printf", "ok").Output(); err != nil || string(out) != "ok" {
+		t.Skip("GNU find is required")
+	}
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveShell(conn, config, env)
+		}
+	}()
+
+	client, err := transport.Dial(&transport.Config{
+		Host:            listener.Addr().String(),
+		User:            "podrun",
+		Password:        "unused",
+		HostKeyCallback: ssh.FixedHostKey(hostSigner.PublicKey()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func serveShell(conn net.Conn, config *ssh.ServerConfig, env []string) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			defer channel.Close()
+			for req := range requests {
+				if req.Type != "exec" {
+					req.Reply(false, nil)
+					continue
+				}
+				var payload struct{ Command string }
+				if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+					req.Reply(false, nil)
+					return
+				}
+				req.Reply(true, nil)
+
+				cmd := exec.Command("sh", "-c", payload.Command)
+				cmd.Env = append(os.Environ(), env...)
+				cmd.Stdin = channel
+				cmd.Stdout = channel
+				cmd.Stderr = channel.Stderr()
+				status := uint32(0)
+				if err := cmd.Run(); err != nil {
+					var exitErr *exec.ExitError
+					if !errors.As(err, &exitErr) {
+						fmt.Fprintln(channel.Stderr(), err)
+						status = 127
+					} else {
+						status = uint32(exitErr.ExitCode())
+					}
+				}
+				code := make([]byte, 4)
+				binary.BigEndian.PutUint32(code, status)
+				channel.SendRequest("exit-status", false, code)
+				return
+			}
+		}()
+	}
+}
+
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+
+	files := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		if d.Type()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			files[filepath.ToSlash(rel)] = "-> " + target
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		content, err := io.ReadAll(f)
+		files[filepath.ToSlash(rel)] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// * push 後遠端 manifest 與本機一致；再 pull 回另一個資料夾內容相同
+func TestPushPullRoundTrip(t *testing.T) {
+	client := dialShell(t)
+	local, remote, back := t.TempDir(), filepath.Join(t.TempDir(), "remote dir"), t.TempDir()
+
+	writeTree(t, local, map[string]string{
+		"docker-compose.yml": "services: {}\n",
+		"app/main.go":        "package main\n",
+		"app/with space.txt": "space",
+		"app/node_modules/x": "ignored",
+		"stale.txt":          "local",
+	})
+	if err := os.Chmod(filepath.Join(local, "docker-compose.yml"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("app/main.go", filepath.Join(local, "main")); err != nil {
+		t.Fatal(err)
+	}
+	// * 遠端已有的舊檔案與型別不同的項目
+	writeTree(t, remote, map[string]string{
+		"old.txt":       "remove me",
+		"stale.txt/dir": "replace me",
+	})
+
+	ignore := NewIgnore("node_modules/")
+	sync := func() []Change {
+		t.Helper()
+		l, err := BuildLocal(local, ignore)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := FetchRemote(client, remote, ignore)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Diff(l, r)
+	}
+
+	changes := sync()
+	if len(changes) == 0 {
+		t.Fatal("expected changes before the first push")
+	}
+	if err := Push(client, local, remote, changes); err != nil {
+		t.Fatal(err)
+	}
+	if changes := sync(); len(changes) != 0 {
+		t.Fatalf("remote differs after push: %+v", changes)
+	}
+	if info, err := os.Stat(filepath.Join(remote, "docker-compose.yml")); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("mode not preserved: %v, %v", info, err)
+	}
+
+	n, err := Pull(client, remote, back, ignore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := readTree(t, local)
+	delete(want, "app/node_modules/x")
+	got := readTree(t, back)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("pulled %v, want %v", got, want)
+	}
+	// * 資料夾 app 與 4 個檔案、1 個 symlink
+	if n != 6 {
+		t.Fatalf("pulled %d entries, want 6", n)
+	}
+}
+
+func TestFetchRemoteMissingDir(t *testing.T) {
+	client := dialShell(t)
+
+	m, err := FetchRemote(client, filepath.Join(t.TempDir(), "missing"), nil)
+	if err != nil || len(m) != 0 {
+		t.Fatalf("got %v, %v", m, err)
+	}
+}
+
+// * find 不支援 -printf 時（BusyBox、BSD）回報錯誤，而非空的 manifest
+func TestFetchRemoteWithoutGNUFind(t *testing.T) {
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho \"find: unrecognized: -printf\" >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(bin, "find"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	client := dialShell(t, "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if _, err := FetchRemote(client, t.TempDir(), nil); !errors.Is(err, ErrFindPrintf) {
+		t.Fatalf("got %v, want %v", err, ErrFindPrintf)
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pardnchiu/go-podrun/internal/database"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+const (
+	AuthAuto     = "auto"
+	AuthRequired = "required"
+	AuthOff      = "off"
+
+	userKey = "user"
+)
+
+func parseAuthMode(value string) (string, error) {
+	switch value {
+	case "":
+		return AuthAuto, nil
+	case AuthAuto, AuthRequired, AuthOff:
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid API_AUTH: %s (auto|required|off)", value)
+}
+
+// * Authorization: Bearer <token>
+// * auto 模式在建立第一個 token 之前不檢查，避免升級後既有的 CLI 立即無法使用
+// * 撤銷的 token 仍計入，撤銷全部 token 後 auto 模式維持鎖定，需改用 API_AUTH=off 才會開放
+func requireToken(ctx *gin.Context) {
+	if AuthMode == AuthOff {
+		ctx.Next()
+		return
+	}
+
+	token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	token = strings.TrimSpace(token)
+	if !ok || token == "" {
+		if AuthMode == AuthAuto {
+			n, err := DB.CountTokens(ctx.Request.Context())
+			if err != nil {
+				ctx.String(http.StatusInternalServerError, err.Error())
+				ctx.Abort()
+				return
+			}
+			if n == 0 {
+				ctx.Next()
+				return
+			}
+		}
+		ctx.Header("WWW-Authenticate", `Bearer realm="podrun"`)
+		ctx.String(http.StatusUnauthorized, "token is required")
+		ctx.Abort()
+		return
+	}
+
+	user, err := DB.Authenticate(ctx.Request.Context(), token)
+	switch {
+	case errors.Is(err, database.ErrUnauthorized):
+		ctx.Header("WWW-Authenticate", `Bearer realm="podrun", error="invalid_token"`)
+		ctx.String(http.StatusUnauthorized, err.Error())
+		ctx.Abort()
+		return
+	case err != nil:
+		ctx.String(http.StatusInternalServerError, err.Error())
+		ctx.Abort()
+		return
+	}
+
+	ctx.Set(userKey, user)
+	ctx.Next()
+}
+
+// * 未驗證（auth 關閉或尚未建立 token）時為 nil
+func currentUser(ctx *gin.Context) *model.User {
+	if v, ok := ctx.Get(userKey); ok {
+		if user, ok := v.(*model.User); ok {
+			return user
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pardnchiu/go-podrun/internal/database"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+// * 以暫存 SQLite 與指定的 auth 模式建立路由
+func newTestRouter(t *testing.T, mode string) (*gin.Engine, *database.SQLite) {
+	t.Helper()
+
+	db, err := database.NewSQLite(filepath.Join(t.TempDir(), "podrun.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	prevDB, prevAuth := DB, AuthMode
+	DB, AuthMode = db, mode
+	t.Cleanup(func() { DB, AuthMode = prevDB, prevAuth })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	Register(r)
+	return r, db
+}
+
+func get(r *gin.Engine, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func createToken(t *testing.T, db *database.SQLite, name string) (string, *model.Token) {
+	t.Helper()
+	token, info, err := db.CreateToken(context.Background(), &model.User{Name: name}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token, info
+}
+
+func revokeToken(t *testing.T, db *database.SQLite, info *model.Token) {
+	t.Helper()
+	n, err := db.RevokeToken(context.Background(), info.Prefix)
+	if err != nil || n != 1 {
+		t.Fatalf("RevokeToken = %d, %v", n, err)
+	}
+}
+
+func TestAuthAuto(t *testing.T) {
+	r, db := newTestRouter(t, AuthAuto)
+
+	// * 尚未建立 token，不檢查
+	if w := get(r, "/api/server/list", ""); w.Code != http.StatusOK {
+		t.Fatalf("before first token: status %d, want 200", w.Code)
+	}
+
+	token, _ := createToken(t, db, "alice")
+
+	if w := get(r, "/api/server/list", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("without token: status %d, want 401", w.Code)
+	}
+	if w := get(r, "/api/server/list", "podrun_invalid"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("invalid token: status %d, want 401", w.Code)
+	}
+
+	w := get(r, "/api/user/me", token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("valid token: status %d, want 200", w.Code)
+	}
+	var body struct {
+		Data model.User `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Data.Name != "alice" {
+		t.Fatalf("me = %s, %v", w.Body.String(), err)
+	}
+}
+
+// * 撤銷唯一的 token 後 auto 模式維持鎖定，不會回到未驗證
+func TestAuthAutoStaysLockedAfterRevoke(t *testing.T) {
+	r, db := newTestRouter(t, AuthAuto)
+
+	token, info := createToken(t, db, "alice")
+	revokeToken(t, db, info)
+
+	if w := get(r, "/api/server/list", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("without token: status %d, want 401", w.Code)
+	}
+	if w := get(r, "/api/server/list", token); w.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked token: status %d, want 401", w.Code)
+	}
+}
+
+func TestAuthRequired(t *testing.T) {
+	r, db := newTestRouter(t, AuthRequired)
+
+	// * required 模式即使沒有任何 token 也要拒絕
+	if w := get(r, "/api/server/list", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("no tokens: status %d, want 401", w.Code)
+	}
+
+	token, info := createToken(t, db, "alice")
+	other, _ := createToken(t, db, "bob")
+
+	if w := get(r, "/api/server/list", token); w.Code != http.StatusOK {
+		t.Fatalf("valid token: status %d, want 200", w.Code)
+	}
+
+	revokeToken(t, db, info)
+
+	w := get(r, "/api/server/list", token)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked token: status %d, want 401", w.Code)
+	}
+	if got := w.Header().Get("WWW-Authenticate"); got != `Bearer realm="podrun", error="invalid_token"` {
+		t.Fatalf("WWW-Authenticate = %q", got)
+	}
+	if w := get(r, "/api/server/list", other); w.Code != http.StatusOK {
+		t.Fatalf("other token: status %d, want 200", w.Code)
+	}
+}
+
+func TestAuthOff(t *testing.T) {
+	r, db := newTestRouter(t, AuthOff)
+	_, info := createToken(t, db, "alice")
+	revokeToken(t, db, info)
+
+	if w := get(r, "/api/server/list", ""); w.Code != http.StatusOK {
+		t.Fatalf("without token: status %d, want 200", w.Code)
+	}
+	// * off 模式不驗證，撤銷的 token 也不會被拒絕
+	if w := get(r, "/api/server/list", "podrun_revoked"); w.Code != http.StatusOK {
+		t.Fatalf("with token: status %d, want 200", w.Code)
+	}
+	if w := get(r, "/api/health", ""); w.Code != http.StatusOK {
+		t.Fatalf("health: status %d, want 200", w.Code)
+	}
+}
+
+func TestParseAuthMode(t *testing.T) {
+	for value, want := range map[string]string{
+		"":         AuthAuto,
+		"auto":     AuthAuto,
+		"required": AuthRequired,
+		"off":      AuthOff,
+	} {
+		if got, err := parseAuthMode(value); err != nil || got != want {
+			t.Errorf("parseAuthMode(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := parseAuthMode("on"); err == nil {
+		t.Error("parseAuthMode(on): expected error")
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pardnchiu/go-podrun/internal/database"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func getAPIDomainList(ctx *gin.Context) {
+	domains, err := DB.ListDomains(ctx.Request.Context(), ctx.Param("uid"))
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": domains})
+}
+
+func postAPIDomainUpsert(ctx *gin.Context) {
+	var domain model.Domain
+	if err := ctx.ShouldBindJSON(&domain); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if domain.UID == "" || domain.ContainerName == "" || domain.Domain == "" {
+		ctx.String(http.StatusBadRequest, "uid, container_name and domain are required")
+		return
+	}
+
+	err := DB.UpsertDomain(ctx.Request.Context(), &domain)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		ctx.String(http.StatusNotFound, "pod not found")
+		return
+	case errors.Is(err, database.ErrDomainTaken):
+		ctx.String(http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.String(http.StatusOK, "ok")
+}
+
+func postAPIDomainRemove(ctx *gin.Context) {
+	var domain model.Domain
+	if err := ctx.ShouldBindJSON(&domain); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if domain.UID == "" || domain.Domain == "" {
+		ctx.String(http.StatusBadRequest, "uid and domain are required")
+		return
+	}
+
+	removed, err := DB.RemoveDomain(ctx.Request.Context(), &domain)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if removed == 0 {
+		ctx.String(http.StatusNotFound, "domain not found")
+		return
+	}
+
+	ctx.String(http.StatusOK, "ok")
+}
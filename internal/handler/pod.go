@@ -1,14 +1,23 @@
 package handler
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pardnchiu/go-podrun/internal/database"
 	"github.com/pardnchiu/go-podrun/internal/model"
 )
 
+// * ?owner=<user>&server=<name>
 func getAPIPodList(ctx *gin.Context) {
-	containers, err := DB.ListPods(ctx.Request.Context())
+	containers, err := DB.ListPods(ctx.Request.Context(), database.PodFilter{
+		Owner:  ctx.Query("owner"),
+		Server: ctx.Query("server"),
+	})
 	if err != nil {
 		ctx.String(http.StatusInternalServerError, err.Error())
 		return
@@ -17,6 +26,68 @@ func getAPIPodList(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"data": containers})
 }
 
+func getAPIPodInfo(ctx *gin.Context) {
+	pod, err := DB.PodInfo(ctx.Request.Context(), ctx.Param("uid"))
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.String(http.StatusNotFound, "pod not found")
+		return
+	}
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": pod})
+}
+
+// * ?since=&until=（RFC 3339 或 2006-01-02）&limit=（1 至 MaxRecordLimit）&offset=&user=
+func getAPIPodRecords(ctx *gin.Context) {
+	var filter database.RecordFilter
+	var err error
+
+	if filter.Since, err = parseTime(ctx.Query("since")); err != nil {
+		ctx.String(http.StatusBadRequest, "invalid since: "+err.Error())
+		return
+	}
+	if filter.Until, err = parseTime(ctx.Query("until")); err != nil {
+		ctx.String(http.StatusBadRequest, "invalid until: "+err.Error())
+		return
+	}
+	if filter.Limit, err = strconv.Atoi(ctx.DefaultQuery("limit", "50")); err != nil || filter.Limit <= 0 {
+		ctx.String(http.StatusBadRequest, "invalid limit")
+		return
+	}
+	filter.Limit = min(filter.Limit, database.MaxRecordLimit)
+	if filter.Offset, err = strconv.Atoi(ctx.DefaultQuery("offset", "0")); err != nil || filter.Offset < 0 {
+		ctx.String(http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	filter.User = ctx.Query("user")
+
+	records, total, err := DB.ListPodRecords(ctx.Request.Context(), ctx.Param("uid"), filter)
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.String(http.StatusNotFound, "pod not found")
+		return
+	}
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": records, "total": total})
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
+}
+
 func postAPIPodUpsert(ctx *gin.Context) {
 	var pod model.Pod
 	if err := ctx.ShouldBindJSON(&pod); err != nil {
@@ -24,7 +95,17 @@ func postAPIPodUpsert(ctx *gin.Context) {
 		return
 	}
 
-	if err := DB.UpsertPod(ctx.Request.Context(), &pod); err != nil {
+	// * 有驗證時以 token 的使用者為準，不採用 client 自報的名稱
+	if user := currentUser(ctx); user != nil {
+		pod.Owner = user.Name
+	}
+
+	err := DB.UpsertPod(ctx.Request.Context(), &pod)
+	switch {
+	case errors.Is(err, database.ErrInvalidTransition):
+		ctx.String(http.StatusConflict, err.Error())
+		return
+	case err != nil:
 		ctx.String(http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -46,7 +127,12 @@ func postAPIPodRecordUpdate(ctx *gin.Context) {
 		return
 	}
 
-	if err := DB.UpdatePod(ctx.Request.Context(), &pod); err != nil {
+	err := DB.UpdatePod(ctx.Request.Context(), &pod)
+	switch {
+	case errors.Is(err, database.ErrInvalidTransition):
+		ctx.String(http.StatusConflict, err.Error())
+		return
+	case err != nil:
 		ctx.String(http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -61,6 +147,10 @@ func postAPIPodRecordInsert(ctx *gin.Context) {
 		return
 	}
 
+	if user := currentUser(ctx); user != nil {
+		record.User = user.Name
+	}
+
 	if err := DB.InsertRecord(ctx.Request.Context(), &record); err != nil {
 		ctx.String(http.StatusInternalServerError, err.Error())
 		return
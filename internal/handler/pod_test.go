@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pardnchiu/go-podrun/internal/database"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+// * 建立 pod 並於 2026-01-01 起每天寫入一筆紀錄（content 為 day-N）
+func seedRecords(t *testing.T, db *database.SQLite, uid string, days int) {
+	t.Helper()
+
+	ctx := context.Background()
+	if err := db.UpsertPod(ctx, &model.Pod{
+		UID:       uid,
+		PodID:     uid,
+		PodName:   uid,
+		RemoteDir: "/srv/" + uid,
+		Status:    model.StatusStarting,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < days; i++ {
+		if err := db.InsertRecord(ctx, &model.Record{
+			UID:       uid,
+			Content:   fmt.Sprintf("day-%d", i+1),
+			CreatedAt: start.AddDate(0, 0, i),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// * 回傳狀態碼與各筆紀錄的 content
+func getRecords(t *testing.T, r *gin.Engine, path string) (int, []string, int) {
+	t.Helper()
+
+	w := get(r, path, "")
+	if w.Code != http.StatusOK {
+		return w.Code, nil, 0
+	}
+	var resp struct {
+		Data  []model.Record `json:"data"`
+		Total int            `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, e := range resp.Data {
+		contents = append(contents, e.Content)
+	}
+	return w.Code, contents, resp.Total
+}
+
+func TestPodRecords(t *testing.T) {
+	r, db := newTestRouter(t, AuthOff)
+	seedRecords(t, db, "a", 5)
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		total int
+	}{
+		{"newest first", "", "[day-5 day-4 day-3 day-2 day-1]", 5},
+		{"since date", "?since=2026-01-03", "[day-5 day-4 day-3]", 3},
+		{"until is exclusive", "?until=2026-01-03T12:00:00Z", "[day-2 day-1]", 2},
+		{"since and until", "?since=2026-01-02T00:00:00Z&until=2026-01-04T00:00:00Z", "[day-3 day-2]", 2},
+		{"first page", "?limit=2", "[day-5 day-4]", 5},
+		{"second page", "?limit=2&offset=2", "[day-3 day-2]", 5},
+		{"past the end", "?limit=2&offset=10", "[]", 5},
+		{"over the cap", fmt.Sprintf("?limit=%d", database.MaxRecordLimit+1), "[day-5 day-4 day-3 day-2 day-1]", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, contents, total := getRecords(t, r, "/api/pod/a/records"+tt.query)
+			if code != http.StatusOK {
+				t.Fatalf("status %d, want 200", code)
+			}
+			if got := fmt.Sprint(contents); got != tt.want || total != tt.total {
+				t.Fatalf("got %s (total %d), want %s (total %d)", got, total, tt.want, tt.total)
+			}
+		})
+	}
+}
+
+func TestPodRecordsInvalid(t *testing.T) {
+	r, db := newTestRouter(t, AuthOff)
+	seedRecords(t, db, "a", 1)
+
+	tests := map[string]int{
+		"/api/pod/missing/records":            http.StatusNotFound,
+		"/api/pod/a/records?limit=0":          http.StatusBadRequest,
+		"/api/pod/a/records?limit=-1":         http.StatusBadRequest,
+		"/api/pod/a/records?limit=all":        http.StatusBadRequest,
+		"/api/pod/a/records?offset=-1":        http.StatusBadRequest,
+		"/api/pod/a/records?since=yesterday":  http.StatusBadRequest,
+		"/api/pod/a/records?until=2026-13-01": http.StatusBadRequest,
+	}
+	for path, want := range tests {
+		if code, _, _ := getRecords(t, r, path); code != want {
+			t.Errorf("%s: status %d, want %d", path, code, want)
+		}
+	}
+}
+
+// * 已移除的部署仍可查詢紀錄
+func TestPodRecordsDismissed(t *testing.T) {
+	r, db := newTestRouter(t, AuthOff)
+	seedRecords(t, db, "a", 1)
+	if err := db.UpdatePod(context.Background(), &model.Pod{UID: "a", Status: model.StatusRemoved}); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, contents, _ := getRecords(t, r, "/api/pod/a/records"); code != http.StatusOK || len(contents) != 1 {
+		t.Fatalf("status %d, records %v", code, contents)
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func getAPIPortList(ctx *gin.Context) {
+	ports, err := DB.ListPorts(ctx.Request.Context(), ctx.Param("uid"))
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": ports})
+}
+
+func postAPIPortAllocate(ctx *gin.Context) {
+	var reqs []model.Port
+	if err := ctx.ShouldBindJSON(&reqs); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	uid := ctx.Param("uid")
+	if uid == "" {
+		ctx.String(http.StatusBadRequest, "uid is required")
+		return
+	}
+
+	ports, err := DB.AllocatePorts(ctx.Request.Context(), uid, reqs, PortMin, PortMax)
+	if err != nil {
+		ctx.String(http.StatusConflict, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": ports})
+}
+
+func postAPIPortRelease(ctx *gin.Context) {
+	if err := DB.ReleasePorts(ctx.Request.Context(), ctx.Param("uid")); err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.String(http.StatusOK, "ok")
+}
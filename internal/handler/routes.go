@@ -3,6 +3,7 @@ package handler
 import (
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pardnchiu/go-podrun/internal/database"
@@ -11,6 +12,13 @@ import (
 
 var (
 	DB *database.SQLite
+
+	// * PORT_RANGE=20000-29999
+	PortMin = database.DefaultPortMin
+	PortMax = database.DefaultPortMax
+
+	// * API_AUTH=auto|required|off
+	AuthMode = AuthAuto
 )
 
 func NewRoutes(db *database.SQLite) error {
@@ -18,6 +26,14 @@ func NewRoutes(db *database.SQLite) error {
 		DB = db
 	}
 
+	var err error
+	if PortMin, PortMax, err = database.ParsePortRange(os.Getenv("PORT_RANGE")); err != nil {
+		return err
+	}
+	if AuthMode, err = parseAuthMode(os.Getenv("API_AUTH")); err != nil {
+		return err
+	}
+
 	r := gin.Default()
 
 	ip, err := utils.GetLocalIP()
@@ -29,18 +45,47 @@ func NewRoutes(db *database.SQLite) error {
 		"127.0.0.1",
 		ip,
 	})
+	Register(r)
+
+	log.Println("start on :8080")
+	if err := r.Run(":8080"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// * 註冊所有 API 路由，使用前需設定 DB
+func Register(r *gin.Engine) {
+	// * 除了 health 以外都需要 token
+	api := r.Group("/api", requireToken)
 
 	// * Pod > GET
-	r.GET("/api/pod/list", getAPIPodList)
+	api.GET("/pod/list", getAPIPodList)
+	api.GET("/pod/:uid", getAPIPodInfo)
+	api.GET("/pod/:uid/records", getAPIPodRecords)
 
 	// * Pod > POST
-	r.POST("/api/pod/upsert", postAPIPodUpsert)
-	r.POST("/api/pod/update/:uid", postAPIPodRecordUpdate)
-	r.POST("/api/pod/record/insert", postAPIPodRecordInsert)
+	api.POST("/pod/upsert", postAPIPodUpsert)
+	api.POST("/pod/update/:uid", postAPIPodRecordUpdate)
+	api.POST("/pod/record/insert", postAPIPodRecordInsert)
+
+	// * Port
+	api.GET("/pod/port/list/:uid", getAPIPortList)
+	api.POST("/pod/port/allocate/:uid", postAPIPortAllocate)
+	api.POST("/pod/port/release/:uid", postAPIPortRelease)
 
-	// # NOT THIS PROJECT POINT, REMOVE IT FOR NOW
-	// // * User > POST
-	// r.POST("/api/user/upsert", PostAPIUserUpsert)
+	// * Domain
+	api.GET("/pod/domain/list/:uid", getAPIDomainList)
+	api.POST("/pod/domain/upsert", postAPIDomainUpsert)
+	api.POST("/pod/domain/remove", postAPIDomainRemove)
+
+	// * Server
+	api.GET("/server/list", getAPIServerList)
+	api.POST("/server/upsert", postAPIServerUpsert)
+
+	// * User
+	api.GET("/user/me", getAPIUserMe)
 
 	// * Other
 	r.GET("/api/health", func(ctx *gin.Context) {
@@ -49,11 +94,4 @@ func NewRoutes(db *database.SQLite) error {
 	r.NoRoute(func(c *gin.Context) {
 		select {}
 	})
-
-	log.Println("start on :8080")
-	if err := r.Run(":8080"); err != nil {
-		return err
-	}
-
-	return nil
 }
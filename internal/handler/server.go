@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+func getAPIServerList(ctx *gin.Context) {
+	servers, err := DB.ListServers(ctx.Request.Context())
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": servers})
+}
+
+func postAPIServerUpsert(ctx *gin.Context) {
+	var server model.Server
+	if err := ctx.ShouldBindJSON(&server); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if server.Name == "" || server.Host == "" {
+		ctx.String(http.StatusBadRequest, "name and host are required")
+		return
+	}
+
+	if err := DB.UpsertServer(ctx.Request.Context(), &server); err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.String(http.StatusOK, "ok")
+}
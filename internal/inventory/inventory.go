@@ -0,0 +1,154 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pardnchiu/go-podrun/internal/credential"
+)
+
+// * 未指定 base_dir 時的遠端根目錄
+const DefaultBaseDir = "/home/podrun"
+
+// * 具名的部署目標；憑證只記錄參照，不保存明文密碼
+type Server struct {
+	Name string `yaml:"-" json:"name"`
+	Host string `yaml:"host" json:"host"`
+	Port int    `yaml:"port,omitempty" json:"port,omitempty"`
+	User string `yaml:"user,omitempty" json:"user,omitempty"`
+	// * credentials 中的名稱或內建的 agent、prompt；設定後忽略 identity_file 與 password_env
+	Credential   string `yaml:"credential,omitempty" json:"credential,omitempty"`
+	IdentityFile string `yaml:"identity_file,omitempty" json:"identity_file,omitempty"`
+	// * 讀取密碼的環境變數名稱
+	PasswordEnv string `yaml:"password_env,omitempty" json:"password_env,omitempty"`
+	BaseDir     string `yaml:"base_dir,omitempty" json:"base_dir,omitempty"`
+}
+
+type Inventory struct {
+	Default string             `yaml:"default,omitempty"`
+	Servers map[string]*Server `yaml:"servers"`
+	// * 具名的 credential provider，供多台主機共用
+	Credentials map[string]*credential.Spec `yaml:"credentials,omitempty"`
+
+	path string
+}
+
+func DefaultPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".podrun", "servers.yaml")
+}
+
+// * 檔案不存在時回傳空的 inventory
+func Load(path string) (*Inventory, error) {
+	inv := &Inventory{Servers: map[string]*Server{}, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return inv, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, inv); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if inv.Servers == nil {
+		inv.Servers = map[string]*Server{}
+	}
+	for name, s := range inv.Servers {
+		if s == nil {
+			return nil, fmt.Errorf("parse %s: server %s is empty", path, name)
+		}
+		s.Name = name
+	}
+	for name, spec := range inv.Credentials {
+		if spec == nil {
+			return nil, fmt.Errorf("parse %s: credential %s is empty", path, name)
+		}
+	}
+	return inv, nil
+}
+
+func (inv *Inventory) Path() string {
+	return inv.path
+}
+
+func (inv *Inventory) Save() error {
+	data, err := yaml.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(inv.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(inv.path, data, 0600)
+}
+
+// * 回傳複本，呼叫端修改不影響 inventory
+func (inv *Inventory) Get(name string) (*Server, bool) {
+	s, ok := inv.Servers[name]
+	if !ok {
+		return nil, false
+	}
+	copied := *s
+	return &copied, true
+}
+
+func (inv *Inventory) Names() []string {
+	names := make([]string, 0, len(inv.Servers))
+	for name := range inv.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// * target 為 user@host，用於密碼提示與 session 快取
+func (inv *Inventory) Provider(name, target string) (credential.Provider, error) {
+	return inv.provider(name, target, 0)
+}
+
+func (inv *Inventory) provider(name, target string, depth int) (credential.Provider, error) {
+	// * passphrase 互相參照時避免無限遞迴
+	if depth > 4 {
+		return nil, fmt.Errorf("credential %s: passphrase references are nested too deeply", name)
+	}
+	spec, ok := inv.Credentials[name]
+	if !ok {
+		spec, ok = credential.Builtin(name)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown credential: %s (define it under credentials in %s)", name, inv.path)
+	}
+	return credential.New(name, spec, target, func(ref string) (credential.Provider, error) {
+		return inv.provider(ref, target, depth+1)
+	})
+}
+
+// * host 可直接帶 port，例如 host:2222，此時忽略 Port
+func (s *Server) Addr() string {
+	if _, _, err := net.SplitHostPort(s.Host); err == nil || s.Port == 0 {
+		return s.Host
+	}
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// * 不含 port 的主機名稱，供 rsync 的遠端位置使用
+func (s *Server) Hostname() string {
+	if host, _, err := net.SplitHostPort(s.Host); err == nil {
+		return host
+	}
+	return s.Host
+}
+
+func (s *Server) RemoteBase() string {
+	if s.BaseDir == "" {
+		return DefaultBaseDir
+	}
+	return s.BaseDir
+}
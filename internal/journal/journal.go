@@ -0,0 +1,328 @@
+package journal
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/registry"
+)
+
+const (
+	OpUpsertPod    = "upsert_pod"
+	OpUpdatePod    = "update_pod"
+	OpInsertRecord = "insert_record"
+	OpReleasePorts = "release_ports"
+	OpUpsertServer = "upsert_server"
+)
+
+// * 一筆待送出的 registry 異動
+type Entry struct {
+	ID        string        `json:"id"`
+	Op        string        `json:"op"`
+	UID       string        `json:"uid"`
+	Pod       *model.Pod    `json:"pod,omitempty"`
+	Record    *model.Record `json:"record,omitempty"`
+	Server    *model.Server `json:"server,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// * 重送結果：送出、因錯誤捨棄、仍留在 journal 的筆數
+// * Err 為中斷重送的錯誤（registry 無法寫入、token 被拒或缺少 cgo）
+type Result struct {
+	Applied int
+	Dropped []Dropped
+	Pending int
+	Err     error
+}
+
+type Dropped struct {
+	Entry Entry
+	Err   error
+}
+
+type Journal struct {
+	path string
+}
+
+func DefaultPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".podrun", "journal.jsonl")
+}
+
+func New(path string) *Journal {
+	return &Journal{path: path}
+}
+
+func (j *Journal) Path() string {
+	return j.path
+}
+
+func NewEntry(op, uid string) Entry {
+	return Entry{
+		ID:        newID(),
+		Op:        op,
+		UID:       uid,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (j *Journal) Append(e Entry) error {
+	return j.locked(func() error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(j.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		// * 上次寫到一半被中斷時檔尾沒有換行，先換行避免這筆與殘留的內容接成同一行
+		if info, err := f.Stat(); err != nil {
+			return err
+		} else if size := info.Size(); size > 0 {
+			last := make([]byte, 1)
+			if _, err := f.ReadAt(last, size-1); err != nil {
+				return err
+			}
+			if last[0] != '\n' {
+				data = append([]byte{'\n'}, data...)
+			}
+		}
+		_, err = f.Write(append(data, '\n'))
+		return err
+	})
+}
+
+func (j *Journal) Entries() ([]Entry, error) {
+	var entries []Entry
+	err := j.locked(func() error {
+		var err error
+		entries, _, err = j.read()
+		return err
+	})
+	return entries, err
+}
+
+// * 依序重送；registry 無法寫入、token 被拒或 registry 設定錯誤（缺少 cgo）時停止，未送出的保留在 journal
+// * 其餘錯誤重送也不會成功，捨棄並回報
+// * 以獨立的 replay 鎖確保同時只有一個 podrun 重送，避免同一筆 pod 異動被重複且亂序送出
+// * 送出期間不持有 journal 的鎖（HTTP 重試可能很久），Append 不受影響；完成後再依 ID 移除
+func (j *Journal) Replay(ctx context.Context, r registry.Registry) (Result, error) {
+	var result Result
+	unlock, err := j.lock(j.path + ".replay")
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+
+	entries, err := j.Entries()
+	if err != nil {
+		return result, err
+	}
+
+	done := map[string]bool{}
+	i := 0
+	for ; i < len(entries); i++ {
+		err := apply(ctx, r, entries[i])
+		if err == nil {
+			result.Applied++
+			done[entries[i].ID] = true
+			continue
+		}
+		if registry.IsUnavailable(err) || registry.IsUnauthorized(err) || registry.IsRequiresCgo(err) {
+			result.Err = err
+			break
+		}
+		result.Dropped = append(result.Dropped, Dropped{Entry: entries[i], Err: err})
+		done[entries[i].ID] = true
+	}
+	result.Pending = len(entries) - i
+
+	if len(done) == 0 {
+		return result, nil
+	}
+	return result, j.locked(func() error {
+		current, invalid, err := j.read()
+		if err != nil {
+			return err
+		}
+		if err := j.quarantine(invalid); err != nil {
+			return err
+		}
+		remain := make([]Entry, 0, len(current))
+		for _, e := range current {
+			if !done[e.ID] {
+				remain = append(remain, e)
+			}
+		}
+		return j.rewrite(remain)
+	})
+}
+
+func apply(ctx context.Context, r registry.Registry, e Entry) error {
+	switch e.Op {
+	case OpUpsertPod:
+		if e.Pod == nil {
+			return fmt.Errorf("%s: missing pod", e.Op)
+		}
+		return r.UpsertPod(ctx, e.Pod)
+	case OpUpdatePod:
+		if e.Pod == nil {
+			return fmt.Errorf("%s: missing pod", e.Op)
+		}
+		return r.UpdatePod(ctx, e.UID, e.Pod)
+	case OpInsertRecord:
+		if e.Record == nil {
+			return fmt.Errorf("%s: missing record", e.Op)
+		}
+		// * event_id 讓重送不會重複寫入，created_at 保留實際發生時間
+		rec := *e.Record
+		if rec.EventID == "" {
+			rec.EventID = e.ID
+		}
+		if rec.CreatedAt.IsZero() {
+			rec.CreatedAt = e.CreatedAt
+		}
+		return r.InsertRecord(ctx, &rec)
+	case OpReleasePorts:
+		return r.ReleasePorts(ctx, e.UID)
+	case OpUpsertServer:
+		if e.Server == nil {
+			return fmt.Errorf("%s: missing server", e.Op)
+		}
+		return r.UpsertServer(ctx, e.Server)
+	}
+	return fmt.Errorf("unsupported journal op: %s", e.Op)
+}
+
+// * 以獨立的鎖檔避免多個 podrun 同時讀寫；journal 本身會被 rename 取代，不能直接鎖
+func (j *Journal) locked(fn func() error) error {
+	unlock, err := j.lock(j.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn()
+}
+
+func (j *Journal) lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lock journal: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// * 無法解析的行（例如寫到一半被中斷）不會重送，另外回傳，rewrite 前移到 QuarantinePath
+func (j *Journal) read() ([]Entry, [][]byte, error) {
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	var invalid [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			invalid = append(invalid, append([]byte(nil), line...))
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, invalid, scanner.Err()
+}
+
+// * 保留無法解析的行供人工檢查，不隨 rewrite 消失
+func (j *Journal) QuarantinePath() string {
+	return j.path + ".corrupt"
+}
+
+func (j *Journal) quarantine(lines [][]byte) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(j.QuarantinePath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// * 寫入同資料夾的暫存檔並 fsync 後 rename，中斷時不會留下寫一半的 journal
+func (j *Journal) rewrite(entries []Entry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		w.Write(data)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), j.path)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,296 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/client"
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/registry"
+)
+
+// * 記錄收到的異動；fail 依 op:uid 回傳指定的錯誤
+type fakeRegistry struct {
+	registry.Registry
+
+	mu      sync.Mutex
+	applied []string
+	fail    map[string]error
+	// * 模擬 HTTP 延遲，讓同時執行的重送互相重疊
+	delay time.Duration
+}
+
+func (r *fakeRegistry) record(op, uid string) error {
+	time.Sleep(r.delay)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := op + ":" + uid
+	if err := r.fail[key]; err != nil {
+		return err
+	}
+	r.applied = append(r.applied, key)
+	return nil
+}
+
+func (r *fakeRegistry) UpsertPod(ctx context.Context, d *model.Pod) error {
+	return r.record(OpUpsertPod, d.UID)
+}
+
+func (r *fakeRegistry) UpdatePod(ctx context.Context, uid string, d *model.Pod) error {
+	return r.record(OpUpdatePod, uid)
+}
+
+func (r *fakeRegistry) InsertRecord(ctx context.Context, rec *model.Record) error {
+	return r.record(OpInsertRecord, rec.UID)
+}
+
+func (r *fakeRegistry) ReleasePorts(ctx context.Context, uid string) error {
+	return r.record(OpReleasePorts, uid)
+}
+
+func newJournal(t *testing.T, entries ...Entry) *Journal {
+	t.Helper()
+
+	j := New(filepath.Join(t.TempDir(), "journal.jsonl"))
+	for _, e := range entries {
+		if err := j.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return j
+}
+
+func podEntry(op, uid, status string) Entry {
+	e := NewEntry(op, uid)
+	e.Pod = &model.Pod{UID: uid, Status: status}
+	return e
+}
+
+func recordEntry(uid string) Entry {
+	e := NewEntry(OpInsertRecord, uid)
+	e.Record = &model.Record{UID: uid, Content: "up"}
+	return e
+}
+
+func remaining(t *testing.T, j *Journal) []string {
+	t.Helper()
+
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, e := range entries {
+		keys = append(keys, e.Op+":"+e.UID)
+	}
+	return keys
+}
+
+func TestReplayOrder(t *testing.T) {
+	j := newJournal(t,
+		podEntry(OpUpsertPod, "a", model.StatusStarting),
+		recordEntry("a"),
+		podEntry(OpUpdatePod, "a", model.StatusRunning),
+		NewEntry(OpReleasePorts, "b"),
+	)
+	r := &fakeRegistry{}
+
+	result, err := j.Replay(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"upsert_pod:a", "insert_record:a", "update_pod:a", "release_ports:b"}
+	if fmt.Sprint(r.applied) != fmt.Sprint(want) {
+		t.Fatalf("applied %v, want %v", r.applied, want)
+	}
+	if result.Applied != 4 || result.Pending != 0 || len(result.Dropped) != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+	if keys := remaining(t, j); len(keys) != 0 {
+		t.Fatalf("journal still has %v", keys)
+	}
+}
+
+func TestReplayTwice(t *testing.T) {
+	j := newJournal(t, podEntry(OpUpsertPod, "a", model.StatusStarting), recordEntry("a"))
+	r := &fakeRegistry{}
+
+	if _, err := j.Replay(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	result, err := j.Replay(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Applied != 0 || len(r.applied) != 2 {
+		t.Fatalf("second replay applied %d, registry saw %v", result.Applied, r.applied)
+	}
+}
+
+func TestReplayKeepsOnUnavailable(t *testing.T) {
+	j := newJournal(t,
+		podEntry(OpUpsertPod, "a", model.StatusStarting),
+		podEntry(OpUpsertPod, "b", model.StatusStarting),
+		recordEntry("b"),
+	)
+	r := &fakeRegistry{fail: map[string]error{
+		"upsert_pod:b": fmt.Errorf("%w: connection refused", registry.ErrUnavailable),
+	}}
+
+	result, err := j.Replay(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Applied != 1 || result.Pending != 2 || !registry.IsUnavailable(result.Err) {
+		t.Fatalf("result = %+v", result)
+	}
+	// * 保留原本的順序，下次由 b 開始
+	want := []string{"upsert_pod:b", "insert_record:b"}
+	if keys := remaining(t, j); fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Fatalf("journal has %v, want %v", keys, want)
+	}
+
+	delete(r.fail, "upsert_pod:b")
+	if result, err = j.Replay(context.Background(), r); err != nil || result.Applied != 2 {
+		t.Fatalf("retry result = %+v, %v", result, err)
+	}
+}
+
+func TestReplayDropsConflict(t *testing.T) {
+	j := newJournal(t,
+		podEntry(OpUpdatePod, "a", model.StatusRunning),
+		recordEntry("a"),
+	)
+	r := &fakeRegistry{fail: map[string]error{
+		"update_pod:a": fmt.Errorf("%w: removed -> running", registry.ErrConflict),
+	}}
+
+	result, err := j.Replay(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Applied != 1 || len(result.Dropped) != 1 || result.Pending != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+	if d := result.Dropped[0]; d.Entry.Op != OpUpdatePod || !registry.IsConflict(d.Err) {
+		t.Fatalf("dropped %+v", d)
+	}
+	if keys := remaining(t, j); len(keys) != 0 {
+		t.Fatalf("journal still has %v", keys)
+	}
+}
+
+// * 同時重送時每筆異動只送出一次
+func TestReplayConcurrent(t *testing.T) {
+	var entries []Entry
+	for i := range 20 {
+		entries = append(entries, recordEntry(fmt.Sprint(i)))
+	}
+	j := newJournal(t, entries...)
+	r := &fakeRegistry{delay: time.Millisecond}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := New(j.Path()).Replay(context.Background(), r); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(r.applied) != len(entries) {
+		t.Fatalf("applied %d entries, want %d", len(r.applied), len(entries))
+	}
+	for i, key := range r.applied {
+		if want := fmt.Sprintf("insert_record:%d", i); key != want {
+			t.Fatalf("applied[%d] = %s, want %s", i, key, want)
+		}
+	}
+}
+
+func appendRaw(t *testing.T, j *Journal, data string) {
+	t.Helper()
+
+	f, err := os.OpenFile(j.Path(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// * 寫到一半被中斷的最後一行沒有換行，下一筆不能與它接成同一行
+func TestAppendAfterTruncatedLine(t *testing.T) {
+	j := newJournal(t, recordEntry("a"))
+	appendRaw(t, j, `{"id":"broken","op":"insert_rec`)
+
+	if err := j.Append(recordEntry("b")); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"insert_record:a", "insert_record:b"}
+	if keys := remaining(t, j); fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Fatalf("journal has %v, want %v", keys, want)
+	}
+}
+
+// * 無法解析的行在 rewrite 時移到 QuarantinePath，不會被直接刪除
+func TestReplayQuarantinesInvalidLines(t *testing.T) {
+	j := newJournal(t, recordEntry("a"))
+	appendRaw(t, j, "not json\n")
+	if err := j.Append(recordEntry("b")); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &fakeRegistry{}
+	result, err := j.Replay(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Applied != 2 || result.Pending != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+	if keys := remaining(t, j); len(keys) != 0 {
+		t.Fatalf("journal still has %v", keys)
+	}
+	data, err := os.ReadFile(j.QuarantinePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "not json\n" {
+		t.Fatalf("quarantine = %q", data)
+	}
+}
+
+// * 500（例如 SQLITE_BUSY）視為暫時無法寫入，保留在 journal
+func TestReplayKeepsOnServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "database is locked", http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	j := newJournal(t, recordEntry("a"))
+	r := registry.NewHTTP(client.New(server.URL, client.WithRetries(0, 0)))
+	result, err := j.Replay(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Pending != 1 || len(result.Dropped) != 0 || !registry.IsUnavailable(result.Err) {
+		t.Fatalf("result = %+v", result)
+	}
+	if keys := remaining(t, j); fmt.Sprint(keys) != "[insert_record:a]" {
+		t.Fatalf("journal has %v", keys)
+	}
+}
@@ -0,0 +1,417 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+	"github.com/pardnchiu/go-podrun/internal/compose"
+)
+
+const (
+	labelName      = "app.kubernetes.io/name"
+	labelPartOf    = "app.kubernetes.io/part-of"
+	labelManagedBy = "app.kubernetes.io/managed-by"
+	defaultStorage = "1Gi"
+)
+
+// * deploy 僅讀取 replicas，其餘欄位在轉換時提示
+var supportedKeys = []string{
+	"image", "build", "command", "entrypoint", "environment", "env_file",
+	"ports", "expose", "volumes", "healthcheck", "deploy", "working_dir", "restart",
+}
+
+var invalidNameRegex = regexp.MustCompile(`[^a-z0-9-]+`)
+
+type Options struct {
+	// * 非空時所有資源加上 namespace 並輸出 Namespace
+	Namespace string
+	// * 相對路徑 bind mount 轉為此目錄下的 hostPath；空值則略過並提示
+	HostPathRoot string
+	// * 指定服務使用的 image，覆蓋 compose 中的設定（例如遠端 build 後的名稱）
+	Images map[string]string
+}
+
+type Manifest struct {
+	Kind   string
+	Name   string
+	Object any
+}
+
+type generator struct {
+	project  *compose.Project
+	opt      Options
+	name     string
+	claims   map[string][]string
+	warnings []string
+}
+
+func Generate(project *compose.Project, opt Options) ([]Manifest, []string, error) {
+	services, err := project.Services()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g := &generator{
+		project: project,
+		opt:     opt,
+		name:    Name(project.Name()),
+		claims:  map[string][]string{},
+	}
+
+	var manifests []Manifest
+	if opt.Namespace != "" {
+		manifests = append(manifests, Manifest{
+			Kind: "Namespace",
+			Name: opt.Namespace,
+			Object: Namespace{
+				APIVersion: "v1",
+				Kind:       "Namespace",
+				Metadata:   Meta{Name: opt.Namespace, Labels: map[string]string{labelManagedBy: "podrun"}},
+			},
+		})
+	}
+
+	var workloads []Manifest
+	for _, svc := range services {
+		objects, err := g.service(svc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("service %s: %w", svc.Name, err)
+		}
+		workloads = append(workloads, objects...)
+	}
+
+	for _, volume := range project.NamedVolumes() {
+		if _, ok := g.claims[Name(volume)]; !ok {
+			g.warnf("volume %s is not used by any service, skipped", volume)
+		}
+	}
+	claimNames := make([]string, 0, len(g.claims))
+	for name := range g.claims {
+		claimNames = append(claimNames, name)
+	}
+	slices.Sort(claimNames)
+	for _, name := range claimNames {
+		if users := g.claims[name]; len(users) > 1 {
+			g.warnf("volume %s is shared by %s, ReadWriteOnce claims require them on the same node", name, strings.Join(users, ", "))
+		}
+		manifests = append(manifests, Manifest{
+			Kind: "PersistentVolumeClaim",
+			Name: name,
+			Object: PersistentVolumeClaim{
+				APIVersion: "v1",
+				Kind:       "PersistentVolumeClaim",
+				Metadata:   g.meta(name, ""),
+				Spec: ClaimSpec{
+					AccessModes: []string{"ReadWriteOnce"},
+					Resources:   ResourceClaim{Requests: map[string]string{"storage": defaultStorage}},
+				},
+			},
+		})
+	}
+
+	return append(manifests, workloads...), g.warnings, nil
+}
+
+func (g *generator) service(svc compose.Service) ([]Manifest, error) {
+	name := Name(svc.Name)
+	for _, key := range svc.Keys {
+		if !slices.Contains(supportedKeys, key) {
+			g.warnf("service %s: %s is not supported, ignored", svc.Name, key)
+		}
+	}
+	if svc.Restart == "no" {
+		g.warnf("service %s: restart: no is ignored, Deployments always restart containers", svc.Name)
+	}
+
+	image := svc.Image
+	pullPolicy := ""
+	if override, ok := g.opt.Images[svc.Name]; ok {
+		image = override
+		pullPolicy = "IfNotPresent"
+	}
+	if image == "" {
+		if svc.Build == nil {
+			return nil, fmt.Errorf("image is required")
+		}
+		image = g.name + "-" + name + ":latest"
+		g.warnf("service %s: build is not supported, expecting image %s to be available in the cluster", svc.Name, image)
+	}
+
+	container := Container{
+		Name:       name,
+		Image:      image,
+		PullPolicy: pullPolicy,
+		Command:    svc.Entrypoint,
+		Args:       svc.Command,
+		WorkingDir: svc.WorkingDir,
+	}
+
+	for _, e := range svc.Environment {
+		value := e.Value
+		if e.Inherit {
+			v, ok := os.LookupEnv(e.Name)
+			if !ok {
+				g.warnf("service %s: environment %s has no value, skipped", svc.Name, e.Name)
+				continue
+			}
+			value = v
+		}
+		container.Env = append(container.Env, EnvVar{Name: e.Name, Value: value})
+	}
+
+	var manifests []Manifest
+	if configMap, ok := g.envConfigMap(svc); ok {
+		container.EnvFrom = []EnvFromSource{{ConfigMapRef: LocalRef{Name: configMap.Metadata.Name}}}
+		manifests = append(manifests, Manifest{Kind: "ConfigMap", Name: configMap.Metadata.Name, Object: configMap})
+	}
+
+	servicePorts := g.ports(svc)
+	for _, e := range servicePorts {
+		container.Ports = append(container.Ports, ContainerPort{
+			Name:          e.Name,
+			ContainerPort: e.TargetPort,
+			Protocol:      e.Protocol,
+		})
+	}
+
+	var volumes []Volume
+	container.VolumeMounts, volumes = g.volumes(svc)
+
+	if probe := g.probe(svc); probe != nil {
+		container.LivenessProbe = probe
+		readiness := *probe
+		container.ReadinessProbe = &readiness
+	}
+
+	selector := map[string]string{labelName: name, labelPartOf: g.name}
+	manifests = append(manifests, Manifest{
+		Kind: "Deployment",
+		Name: name,
+		Object: Deployment{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Metadata:   g.meta(name, name),
+			Spec: DeploymentSpec{
+				Replicas: svc.Replicas,
+				Selector: LabelSelector{MatchLabels: selector},
+				Template: PodTemplate{
+					Metadata: Meta{Labels: g.meta(name, name).Labels},
+					Spec: PodSpec{
+						Containers: []Container{container},
+						Volumes:    volumes,
+					},
+				},
+			},
+		},
+	})
+
+	// * 沒有 port 的服務無法被其他服務連線，不建立 Service
+	if len(servicePorts) > 0 {
+		manifests = append(manifests, Manifest{
+			Kind: "Service",
+			Name: name,
+			Object: Service{
+				APIVersion: "v1",
+				Kind:       "Service",
+				Metadata:   g.meta(name, name),
+				Spec: ServiceSpec{
+					Selector: selector,
+					Ports:    servicePorts,
+				},
+			},
+		})
+	}
+	return manifests, nil
+}
+
+// * 多個 env_file 合併為一個 ConfigMap，後者覆蓋前者
+func (g *generator) envConfigMap(svc compose.Service) (ConfigMap, bool) {
+	if len(svc.EnvFiles) == 0 {
+		return ConfigMap{}, false
+	}
+
+	dir := filepath.Dir(g.project.Path)
+	data := map[string]string{}
+	for _, file := range svc.EnvFiles {
+		env, err := godotenv.Read(filepath.Join(dir, file))
+		if err != nil {
+			g.warnf("service %s: env_file %s: %s, skipped", svc.Name, file, err)
+			continue
+		}
+		for k, v := range env {
+			data[k] = v
+		}
+	}
+	if len(data) == 0 {
+		return ConfigMap{}, false
+	}
+
+	name := Name(svc.Name) + "-env"
+	return ConfigMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   g.meta(name, Name(svc.Name)),
+		Data:       data,
+	}, true
+}
+
+// * ports 與 expose 合併去重；port 名稱上限 15 字元
+func (g *generator) ports(svc compose.Service) []ServicePort {
+	var ports []ServicePort
+	for _, port := range append(slices.Clone(svc.Ports), svc.Expose...) {
+		target := port.TargetNumber()
+		if target == 0 || fmt.Sprint(target) != port.Target {
+			g.warnf("service %s: port %s is not supported, skipped", svc.Name, port.String())
+			continue
+		}
+
+		protocol := strings.ToUpper(port.Protocol)
+		if protocol == "TCP" {
+			protocol = ""
+		}
+		if slices.ContainsFunc(ports, func(e ServicePort) bool {
+			return e.TargetPort == target && e.Protocol == protocol
+		}) {
+			continue
+		}
+
+		prefix := "tcp"
+		if protocol != "" {
+			prefix = strings.ToLower(protocol)
+		}
+		ports = append(ports, ServicePort{
+			Name:       fmt.Sprintf("%s-%d", prefix, target),
+			Port:       target,
+			TargetPort: target,
+			Protocol:   protocol,
+		})
+	}
+	return ports
+}
+
+func (g *generator) volumes(svc compose.Service) ([]VolumeMount, []Volume) {
+	var mounts []VolumeMount
+	var volumes []Volume
+
+	for i, vol := range svc.Volumes {
+		name := fmt.Sprintf("%s-%d", Name(svc.Name), i)
+		var volume Volume
+
+		switch {
+		case vol.Type == "volume" && vol.Source != "":
+			claim := Name(vol.Source)
+			name = claim
+			volume = Volume{Name: name, PersistentVolumeClaim: &ClaimRef{ClaimName: claim}}
+			if !slices.Contains(g.claims[claim], svc.Name) {
+				g.claims[claim] = append(g.claims[claim], svc.Name)
+			}
+		case vol.Type == "volume" || vol.Type == "tmpfs":
+			volume = Volume{Name: name, EmptyDir: &EmptyDirSource{}}
+		case vol.IsRelativeBind():
+			if g.opt.HostPathRoot == "" {
+				g.warnf("service %s: bind mount %s is not portable, skipped", svc.Name, vol.Source)
+				continue
+			}
+			volume = Volume{Name: name, HostPath: &HostPathSource{
+				Path: path.Join(g.opt.HostPathRoot, path.Clean(filepath.ToSlash(vol.Source))),
+			}}
+		case vol.Type == "bind" && strings.HasPrefix(vol.Source, "/"):
+			g.warnf("service %s: bind mount %s uses a node-local hostPath", svc.Name, vol.Source)
+			volume = Volume{Name: name, HostPath: &HostPathSource{Path: vol.Source}}
+		default:
+			g.warnf("service %s: volume %s is not supported, skipped", svc.Name, vol.Source)
+			continue
+		}
+
+		mounts = append(mounts, VolumeMount{Name: name, MountPath: vol.Target, ReadOnly: vol.ReadOnly})
+		if !slices.ContainsFunc(volumes, func(e Volume) bool { return e.Name == name }) {
+			volumes = append(volumes, volume)
+		}
+	}
+	return mounts, volumes
+}
+
+func (g *generator) probe(svc compose.Service) *Probe {
+	hc := svc.Healthcheck
+	if hc == nil || hc.Disable || len(hc.Test) == 0 {
+		return nil
+	}
+
+	command := hc.Test
+	if hc.Shell {
+		command = []string{"sh", "-c", strings.Join(hc.Test, " ")}
+	}
+	return &Probe{
+		Exec:                ExecAction{Command: command},
+		InitialDelaySeconds: seconds(hc.StartPeriod),
+		PeriodSeconds:       seconds(hc.Interval),
+		TimeoutSeconds:      seconds(hc.Timeout),
+		FailureThreshold:    hc.Retries,
+	}
+}
+
+func (g *generator) meta(name, component string) Meta {
+	labels := map[string]string{
+		labelPartOf:    g.name,
+		labelManagedBy: "podrun",
+	}
+	if component != "" {
+		labels[labelName] = component
+	}
+	return Meta{Name: name, Namespace: g.opt.Namespace, Labels: labels}
+}
+
+func (g *generator) warnf(format string, args ...any) {
+	g.warnings = append(g.warnings, fmt.Sprintf(format, args...))
+}
+
+// * 轉為 RFC 1123 label（小寫英數與 -，最長 63 字元）
+func Name(s string) string {
+	name := invalidNameRegex.ReplaceAllString(strings.ToLower(s), "-")
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.Trim(name, "-")
+}
+
+// * 不足一秒的設定以一秒計
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return max(1, int(d/time.Second))
+}
+
+func Marshal(m Manifest) ([]byte, error) {
+	return yaml.MarshalWithOptions(m.Object,
+		yaml.IndentSequence(true),
+		yaml.CustomMarshaler[string](func(s string) ([]byte, error) {
+			return json.Marshal(s)
+		}),
+	)
+}
+
+// * 以 --- 分隔的多文件 YAML
+func MarshalAll(manifests []Manifest) ([]byte, error) {
+	var out []byte
+	for i, m := range manifests {
+		body, err := Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", m.Kind, m.Name, err)
+		}
+		if i > 0 {
+			out = append(out, "---\n"...)
+		}
+		out = append(out, body...)
+	}
+	return out, nil
+}
@@ -0,0 +1,108 @@
+package kube
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pardnchiu/go-podrun/internal/compose"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden.yml")
+
+// * 每個案例為 testdata/<name>/docker-compose.yml，輸出為 testdata/<name>.golden.yml
+var goldenOptions = map[string]Options{
+	"web":     {Namespace: "demo"},
+	"volumes": {HostPathRoot: "/srv/podrun/volumes"},
+}
+
+func TestGolden(t *testing.T) {
+	// * 未設定值的 environment 依本機環境變數決定，固定為未設定
+	t.Setenv("HOST_ONLY", "")
+	os.Unsetenv("HOST_ONLY")
+
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*", "docker-compose.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, input := range inputs {
+		name := filepath.Base(filepath.Dir(input))
+		t.Run(name, func(t *testing.T) {
+			p, err := compose.Load(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifests, warnings, err := Generate(p, goldenOptions[name])
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := MarshalAll(manifests)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got bytes.Buffer
+			for _, w := range warnings {
+				got.WriteString("# warning: " + w + "\n")
+			}
+			got.Write(body)
+
+			golden := filepath.Join("testdata", name+".golden.yml")
+			if *update {
+				if err := os.WriteFile(golden, got.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("%s mismatch (run go test -update to regenerate)\n--- got\n%s\n--- want\n%s", golden, got.Bytes(), want)
+			}
+
+			// * 每份文件需可再次解析
+			for _, doc := range bytes.Split(body, []byte("---\n")) {
+				var v map[string]any
+				if err := yaml.Unmarshal(doc, &v); err != nil || v["kind"] == nil {
+					t.Fatalf("generated document does not parse: %v\n%s", err, doc)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateRequiresImage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	if err := os.WriteFile(path, []byte("services:\n  app:\n    command: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := compose.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Generate(p, Options{}); err == nil {
+		t.Fatal("expected an error for a service without image or build")
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := map[string]string{
+		"web":        "web",
+		"Demo_App":   "demo-app",
+		"--api.v2--": "api-v2",
+		"":           "",
+	}
+	long := bytes.Repeat([]byte("a"), 70)
+	tests[string(long)] = string(long[:63])
+
+	for in, want := range tests {
+		if got := Name(in); got != want {
+			t.Errorf("Name(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
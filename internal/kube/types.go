@@ -0,0 +1,156 @@
+package kube
+
+// * 僅涵蓋 podrun 產生的欄位，非完整的 Kubernetes API
+
+type Meta struct {
+	Name      string            `yaml:"name,omitempty"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+type Namespace struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   Meta   `yaml:"metadata"`
+}
+
+type Deployment struct {
+	APIVersion string         `yaml:"apiVersion"`
+	Kind       string         `yaml:"kind"`
+	Metadata   Meta           `yaml:"metadata"`
+	Spec       DeploymentSpec `yaml:"spec"`
+}
+
+type DeploymentSpec struct {
+	Replicas int           `yaml:"replicas"`
+	Selector LabelSelector `yaml:"selector"`
+	Template PodTemplate   `yaml:"template"`
+}
+
+type LabelSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+type PodTemplate struct {
+	Metadata Meta    `yaml:"metadata"`
+	Spec     PodSpec `yaml:"spec"`
+}
+
+type PodSpec struct {
+	Containers []Container `yaml:"containers"`
+	Volumes    []Volume    `yaml:"volumes,omitempty"`
+}
+
+type Container struct {
+	Name           string          `yaml:"name"`
+	Image          string          `yaml:"image"`
+	PullPolicy     string          `yaml:"imagePullPolicy,omitempty"`
+	Command        []string        `yaml:"command,omitempty"`
+	Args           []string        `yaml:"args,omitempty"`
+	WorkingDir     string          `yaml:"workingDir,omitempty"`
+	Env            []EnvVar        `yaml:"env,omitempty"`
+	EnvFrom        []EnvFromSource `yaml:"envFrom,omitempty"`
+	Ports          []ContainerPort `yaml:"ports,omitempty"`
+	VolumeMounts   []VolumeMount   `yaml:"volumeMounts,omitempty"`
+	LivenessProbe  *Probe          `yaml:"livenessProbe,omitempty"`
+	ReadinessProbe *Probe          `yaml:"readinessProbe,omitempty"`
+}
+
+type EnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type EnvFromSource struct {
+	ConfigMapRef LocalRef `yaml:"configMapRef"`
+}
+
+type LocalRef struct {
+	Name string `yaml:"name"`
+}
+
+type ContainerPort struct {
+	Name          string `yaml:"name,omitempty"`
+	ContainerPort int    `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol,omitempty"`
+}
+
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+type Volume struct {
+	Name                  string          `yaml:"name"`
+	PersistentVolumeClaim *ClaimRef       `yaml:"persistentVolumeClaim,omitempty"`
+	HostPath              *HostPathSource `yaml:"hostPath,omitempty"`
+	EmptyDir              *EmptyDirSource `yaml:"emptyDir,omitempty"`
+}
+
+type ClaimRef struct {
+	ClaimName string `yaml:"claimName"`
+}
+
+type HostPathSource struct {
+	Path string `yaml:"path"`
+	Type string `yaml:"type,omitempty"`
+}
+
+type EmptyDirSource struct {
+	Medium string `yaml:"medium,omitempty"`
+}
+
+type Probe struct {
+	Exec                ExecAction `yaml:"exec"`
+	InitialDelaySeconds int        `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int        `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      int        `yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold    int        `yaml:"failureThreshold,omitempty"`
+}
+
+type ExecAction struct {
+	Command []string `yaml:"command"`
+}
+
+type Service struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   Meta        `yaml:"metadata"`
+	Spec       ServiceSpec `yaml:"spec"`
+}
+
+type ServiceSpec struct {
+	Selector map[string]string `yaml:"selector"`
+	Ports    []ServicePort     `yaml:"ports"`
+}
+
+type ServicePort struct {
+	Name       string `yaml:"name,omitempty"`
+	Port       int    `yaml:"port"`
+	TargetPort int    `yaml:"targetPort"`
+	Protocol   string `yaml:"protocol,omitempty"`
+}
+
+type ConfigMap struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   Meta              `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type PersistentVolumeClaim struct {
+	APIVersion string    `yaml:"apiVersion"`
+	Kind       string    `yaml:"kind"`
+	Metadata   Meta      `yaml:"metadata"`
+	Spec       ClaimSpec `yaml:"spec"`
+}
+
+type ClaimSpec struct {
+	AccessModes []string      `yaml:"accessModes"`
+	Resources   ResourceClaim `yaml:"resources"`
+}
+
+type ResourceClaim struct {
+	Requests map[string]string `yaml:"requests"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type Domain struct {
+	ID            int64     `json:"id"`
+	PodID         int64     `json:"pod_id"`
+	UID           string    `json:"uid"`
+	ContainerName string    `json:"container_name"`
+	Domain        string    `json:"domain"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Dismiss       int       `json:"dismiss"`
+}
@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"path"
+	"time"
+)
 
 type Pod struct {
 	ID        int64     `json:"id"`
@@ -18,13 +22,43 @@ type Pod struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Dismiss   int       `json:"dismiss"`
+	// * 第一次登錄的使用者，之後由其他人部署也不會改變
+	Owner string `json:"owner,omitempty"`
+	// * 部署所在主機的名稱（servers.name）
+	Server string `json:"server,omitempty"`
+	// * podman-compose 的 project 名稱，舊資料為空
+	Project string `json:"project,omitempty"`
+	// * 由 API server 的 reconciler 定期更新
+	Restarts   int        `json:"restarts"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// * compose 頂層 name: 未記錄時，podman-compose 以資料夾名稱作為 project
+func (d Pod) ProjectName() string {
+	if d.Project != "" {
+		return d.Project
+	}
+	return path.Base(d.RemoteDir)
 }
 
 type Record struct {
-	ID       int64  `json:"id"`
-	PodID    int64  `json:"pod_id"`
-	UID      string `json:"uid"`
-	Content  string `json:"content"`
-	Hostname string `json:"hostname"`
-	IP       string `json:"ip"`
+	ID        int64     `json:"id"`
+	PodID     int64     `json:"pod_id"`
+	UID       string    `json:"uid"`
+	Content   string    `json:"content"`
+	Hostname  string    `json:"hostname"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+	// * 執行動作的使用者
+	User string `json:"user,omitempty"`
+	// * 由 CLI 產生，重送時不會重複寫入
+	EventID string `json:"event_id,omitempty"`
+	// * 完整的 CLI 指令，例如 podrun up -d
+	Command    string `json:"command,omitempty"`
+	ExitCode   int    `json:"exit_code"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	GitCommit  string `json:"git_commit,omitempty"`
+	// * sync 為異動檔案清單，up 為容器 ID
+	Payload json.RawMessage `json:"payload,omitempty"`
 }
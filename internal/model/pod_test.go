@@ -0,0 +1,19 @@
+package model
+
+import "testing"
+
+func TestProjectName(t *testing.T) {
+	tests := []struct {
+		pod  Pod
+		want string
+	}{
+		{Pod{RemoteDir: "/srv/podrun/web", Project: "shop"}, "shop"},
+		{Pod{RemoteDir: "/srv/podrun/web"}, "web"},
+		{Pod{RemoteDir: "/srv/podrun/web/"}, "web"},
+	}
+	for _, tt := range tests {
+		if got := tt.pod.ProjectName(); got != tt.want {
+			t.Errorf("%+v.ProjectName() = %q, want %q", tt.pod, got, tt.want)
+		}
+	}
+}
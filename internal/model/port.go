@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type Port struct {
+	ID            int64     `json:"id"`
+	UID           string    `json:"uid"`
+	Service       string    `json:"service"`
+	ContainerPort int       `json:"container_port"`
+	Protocol      string    `json:"protocol"`
+	Seq           int       `json:"seq"`
+	HostPort      int       `json:"host_port"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
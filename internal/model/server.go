@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// * 部署目標主機；不含憑證
+type Server struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Host      string    `json:"host"`
+	Port      int       `json:"port"`
+	User      string    `json:"user"`
+	BaseDir   string    `json:"base_dir"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import "slices"
+
+const (
+	StatusStarting = "starting"
+	StatusRunning  = "running"
+	StatusFailed   = "failed"
+	StatusRemoved  = "removed"
+)
+
+// * starting → running → failed → removed
+// * 重新部署：running / failed / removed → starting
+// * running → removed 供 clear 與前景模式結束時使用；failed → running 供 reconciler 在容器自行恢復時使用
+// * starting → removed 供移除中斷的部署；reconciler 不處理 starting，只會來自 CLI 的移除
+var transitions = map[string][]string{
+	StatusStarting: {StatusRunning, StatusFailed, StatusRemoved},
+	StatusRunning:  {StatusStarting, StatusFailed, StatusRemoved},
+	StatusFailed:   {StatusStarting, StatusRunning, StatusRemoved},
+	StatusRemoved:  {StatusStarting},
+}
+
+func ValidStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// * 新的 pod 只能以 starting 登錄
+func ValidInitial(status string) bool {
+	return status == StatusStarting
+}
+
+// * 相同狀態視為合法（重送）；from 為空字串代表導入狀態機前的舊資料
+func ValidTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+	if !ValidStatus(to) {
+		return false
+	}
+	if from == "" {
+		return true
+	}
+	return slices.Contains(transitions[from], to)
+}
@@ -0,0 +1,43 @@
+package model
+
+import "testing"
+
+func TestValidTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{StatusStarting, StatusRunning, true},
+		{StatusStarting, StatusFailed, true},
+		{StatusStarting, StatusRemoved, true},
+		{StatusRunning, StatusFailed, true},
+		{StatusRunning, StatusRemoved, true},
+		{StatusRunning, StatusStarting, true},
+		{StatusFailed, StatusRemoved, true},
+		{StatusFailed, StatusStarting, true},
+		{StatusFailed, StatusRunning, true},
+		{StatusRemoved, StatusStarting, true},
+		{StatusRemoved, StatusRunning, false},
+		{StatusRemoved, StatusFailed, false},
+		{StatusRunning, StatusRunning, true},
+		{StatusRunning, "unknown", false},
+		// * 導入狀態機前的舊資料
+		{"", StatusRunning, true},
+	}
+	for _, tt := range tests {
+		if got := ValidTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("ValidTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestValidInitial(t *testing.T) {
+	for _, status := range []string{"", StatusRunning, StatusFailed, StatusRemoved} {
+		if ValidInitial(status) {
+			t.Errorf("ValidInitial(%q) = true", status)
+		}
+	}
+	if !ValidInitial(StatusStarting) {
+		t.Error("ValidInitial(starting) = false")
+	}
+}
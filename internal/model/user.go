@@ -4,7 +4,20 @@ import "time"
 
 type User struct {
 	ID        int64     `json:"id"`
-	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	Dismiss   int       `json:"dismiss"`
 }
+
+// * API token；只保存雜湊，Prefix 供辨識與撤銷
+type Token struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	User       string     `json:"user"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,222 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/utils"
+)
+
+const projectLabel = "io.podman.compose.project"
+
+type container struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	ExitCode int    `json:"exit_code"`
+	Restarts int    `json:"restarts"`
+}
+
+// * 狀態或重啟次數變更時寫入 record 的 payload
+type changePayload struct {
+	From       string      `json:"from"`
+	To         string      `json:"to"`
+	Restarts   int         `json:"restarts"`
+	Containers []container `json:"containers"`
+}
+
+// * 每台主機只查詢一次 podman ps，再依 compose project label 分組
+func (r *Reconciler) checkServer(ctx context.Context, server string, pods []model.Pod) error {
+	env, err := r.serverEnv(ctx, server)
+	if err != nil {
+		return err
+	}
+	client, err := r.dial(env)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	result, err := client.Output("podman ps -a --format json")
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("podman ps exited with status %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	projects, err := parseContainers(result.Stdout)
+	if err != nil {
+		return err
+	}
+
+	result, err = client.Output("podman pod ps --format json")
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("podman pod ps exited with status %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+	podNames, err := parsePods(result.Stdout)
+	if err != nil {
+		return err
+	}
+
+	seenAt := time.Now()
+	for _, e := range pods {
+		project := e.ProjectName()
+		if err := r.observe(ctx, e, projects[project], podNames["pod_"+project], seenAt); err != nil {
+			slog.Error("reconciler: failed to update pod",
+				slog.String("uid", e.UID),
+				slog.String("error", err.Error()))
+		}
+	}
+	return nil
+}
+
+func parseContainers(output string) (map[string][]container, error) {
+	var list []struct {
+		ID       string            `json:"Id"`
+		Names    []string          `json:"Names"`
+		State    string            `json:"State"`
+		ExitCode int               `json:"ExitCode"`
+		Restarts int               `json:"Restarts"`
+		Labels   map[string]string `json:"Labels"`
+	}
+	if strings.TrimSpace(output) != "" {
+		if err := json.Unmarshal([]byte(output), &list); err != nil {
+			return nil, fmt.Errorf("parse podman ps: %w", err)
+		}
+	}
+
+	projects := map[string][]container{}
+	for _, e := range list {
+		project := e.Labels[projectLabel]
+		if project == "" {
+			continue
+		}
+		id := e.ID
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		projects[project] = append(projects[project], container{
+			ID:       id,
+			Name:     strings.Join(e.Names, ","),
+			State:    e.State,
+			ExitCode: e.ExitCode,
+			Restarts: e.Restarts,
+		})
+	}
+	return projects, nil
+}
+
+// * podman-compose 建立的 pod 名稱為 pod_<project>
+func parsePods(output string) (map[string]bool, error) {
+	var list []struct {
+		Name string `json:"Name"`
+	}
+	if strings.TrimSpace(output) != "" {
+		if err := json.Unmarshal([]byte(output), &list); err != nil {
+			return nil, fmt.Errorf("parse podman pod ps: %w", err)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, e := range list {
+		names[e.Name] = true
+	}
+	return names, nil
+}
+
+// * 查無容器與 pod 時需連續 removeAfter 次才標記為 removed，之前不更新狀態
+func (r *Reconciler) observe(ctx context.Context, d model.Pod, containers []container, podExists bool, seenAt time.Time) error {
+	status, restarts, reason := derive(containers, podExists)
+	if len(containers) == 0 {
+		seenAt = time.Time{}
+	}
+	if n := r.countMissing(d.UID, status != model.StatusRemoved); n > 0 && n < removeAfter {
+		slog.Info("reconciler: no containers found",
+			slog.String("uid", d.UID),
+			slog.Int("observations", n))
+		return nil
+	}
+
+	applied, err := r.db.ObservePod(ctx, d.UID, d.Status, status, restarts, seenAt)
+	if err != nil || !applied {
+		return err
+	}
+	if status == model.StatusRemoved {
+		r.countMissing(d.UID, true)
+	}
+	if status == d.Status && restarts <= d.Restarts {
+		return nil
+	}
+
+	slog.Info("reconciler: state changed",
+		slog.String("uid", d.UID),
+		slog.String("from", d.Status),
+		slog.String("to", status),
+		slog.Int("restarts", restarts))
+
+	payload, err := json.Marshal(changePayload{
+		From:       d.Status,
+		To:         status,
+		Restarts:   restarts,
+		Containers: containers,
+	})
+	if err != nil {
+		return err
+	}
+	ip, _ := utils.GetLocalIP()
+	rec := &model.Record{
+		UID:      d.UID,
+		Content:  "reconcile",
+		Hostname: utils.GetHostName(),
+		IP:       ip,
+		Command:  "reconcile",
+		Error:    reason,
+		Payload:  payload,
+	}
+	if status != model.StatusRunning {
+		rec.ExitCode = 1
+	}
+	return r.db.InsertRecord(ctx, rec)
+}
+
+// * 沒有容器也沒有 pod 視為已被移除，pod 仍在為 failed；全部 running 為 running，其餘為 failed
+// * 以 0 結束的一次性容器（migration、init job）不算停止，但至少需有一個容器在執行
+func derive(containers []container, podExists bool) (string, int, string) {
+	restarts := 0
+	running := 0
+	var stopped, completed []string
+	for _, e := range containers {
+		restarts += e.Restarts
+		switch {
+		case e.State == "running":
+			running++
+		case e.State == "exited" && e.ExitCode == 0:
+			completed = append(completed, fmt.Sprintf("%s exited with code 0", e.Name))
+		case e.State == "exited":
+			stopped = append(stopped, fmt.Sprintf("%s exited with code %d", e.Name, e.ExitCode))
+		default:
+			stopped = append(stopped, fmt.Sprintf("%s is %s", e.Name, e.State))
+		}
+	}
+
+	switch {
+	case len(containers) == 0 && podExists:
+		return model.StatusFailed, restarts, "pod exists but has no containers"
+	case len(containers) == 0:
+		return model.StatusRemoved, restarts, "no containers or pod found on the server"
+	case len(stopped) > 0:
+		return model.StatusFailed, restarts, strings.Join(stopped, ", ")
+	case running == 0:
+		return model.StatusFailed, restarts, strings.Join(completed, ", ")
+	}
+	return model.StatusRunning, restarts, ""
+}
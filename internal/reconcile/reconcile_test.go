@@ -0,0 +1,238 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/database"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+// * 暫存 SQLite 中登錄一個狀態為 status 的 pod，回傳 reconciler 讀到的快照
+func newPod(t *testing.T, status string) (*Reconciler, *database.SQLite, model.Pod) {
+	t.Helper()
+
+	db, err := database.NewSQLite(filepath.Join(t.TempDir(), "podrun.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	pod := &model.Pod{UID: "uid-1", PodID: "web", PodName: "web", RemoteDir: "/srv/web", Status: model.StatusStarting}
+	if err := db.UpsertPod(ctx, pod); err != nil {
+		t.Fatal(err)
+	}
+	if status != model.StatusStarting {
+		if err := db.UpdatePod(ctx, &model.Pod{UID: pod.UID, Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshot, err := db.PodInfo(ctx, pod.UID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(db, nil, time.Minute, 1), db, *snapshot
+}
+
+// * 目前狀態；已移除的 pod 不會出現在 PodInfo
+func currentStatus(t *testing.T, db *database.SQLite, uid string) string {
+	t.Helper()
+
+	pod, err := db.PodInfo(context.Background(), uid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.StatusRemoved
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pod.Status
+}
+
+// * reconciler 寫入的狀態變更，依時間先後
+func transitions(t *testing.T, db *database.SQLite, uid string) []string {
+	t.Helper()
+
+	records, _, err := db.ListPodRecords(context.Background(), uid, database.RecordFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []string
+	for i := len(records) - 1; i >= 0; i-- {
+		var p changePayload
+		if err := json.Unmarshal(records[i].Payload, &p); err != nil {
+			t.Fatal(err)
+		}
+		changes = append(changes, p.From+"->"+p.To)
+	}
+	return changes
+}
+
+func TestObserveMissingOnce(t *testing.T) {
+	r, db, pod := newPod(t, model.StatusRunning)
+
+	if err := r.observe(context.Background(), pod, nil, false, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := currentStatus(t, db, pod.UID); got != model.StatusRunning {
+		t.Fatalf("status = %s after one empty observation", got)
+	}
+	if got := transitions(t, db, pod.UID); len(got) != 0 {
+		t.Fatalf("records = %v", got)
+	}
+}
+
+func TestObserveMissingRemoves(t *testing.T) {
+	r, db, pod := newPod(t, model.StatusRunning)
+	ctx := context.Background()
+
+	for i := 1; i <= removeAfter; i++ {
+		if err := r.observe(ctx, pod, nil, false, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		want := model.StatusRunning
+		if i == removeAfter {
+			want = model.StatusRemoved
+		}
+		if got := currentStatus(t, db, pod.UID); got != want {
+			t.Fatalf("observation %d: status = %s, want %s", i, got, want)
+		}
+	}
+	if got := fmt.Sprint(transitions(t, db, pod.UID)); got != "[running->removed]" {
+		t.Fatalf("records = %s", got)
+	}
+}
+
+// * 中途看到容器時重新計算
+func TestObserveMissingResets(t *testing.T) {
+	r, db, pod := newPod(t, model.StatusRunning)
+	ctx := context.Background()
+	running := []container{{ID: "abc", Name: "web", State: "running"}}
+
+	for _, containers := range [][]container{nil, nil, running, nil, nil} {
+		if err := r.observe(ctx, pod, containers, false, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := currentStatus(t, db, pod.UID); got != model.StatusRunning {
+		t.Fatalf("status = %s, want running", got)
+	}
+}
+
+func TestObserveRunning(t *testing.T) {
+	r, db, pod := newPod(t, model.StatusFailed)
+	ctx := context.Background()
+	running := []container{
+		{ID: "abc", Name: "web", State: "running", Restarts: 1},
+		{ID: "def", Name: "db", State: "running", Restarts: 2},
+	}
+
+	seenAt := time.Now().Truncate(time.Second)
+	if err := r.observe(ctx, pod, running, true, seenAt); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.PodInfo(ctx, pod.UID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != model.StatusRunning || got.Restarts != 3 || got.LastSeenAt == nil || !got.LastSeenAt.Equal(seenAt) {
+		t.Fatalf("pod = %+v", got)
+	}
+	if changes := fmt.Sprint(transitions(t, db, pod.UID)); changes != "[failed->running]" {
+		t.Fatalf("records = %s", changes)
+	}
+
+	// * 狀態與重啟次數不變時不寫入紀錄
+	if err := r.observe(ctx, *got, running, true, seenAt); err != nil {
+		t.Fatal(err)
+	}
+	if changes := transitions(t, db, pod.UID); len(changes) != 1 {
+		t.Fatalf("records = %v", changes)
+	}
+}
+
+// * 讀取後 CLI 已更新狀態（例如重新部署），reconciler 不覆蓋也不寫紀錄
+func TestObserveConflict(t *testing.T) {
+	r, db, stale := newPod(t, model.StatusRunning)
+	ctx := context.Background()
+
+	if err := db.UpdatePod(ctx, &model.Pod{UID: stale.UID, Status: model.StatusStarting}); err != nil {
+		t.Fatal(err)
+	}
+	stopped := []container{{ID: "abc", Name: "web", State: "exited"}}
+	if err := r.observe(ctx, stale, stopped, true, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := currentStatus(t, db, stale.UID); got != model.StatusStarting {
+		t.Fatalf("status = %s, want starting", got)
+	}
+	if changes := transitions(t, db, stale.UID); len(changes) != 0 {
+		t.Fatalf("records = %v", changes)
+	}
+}
+
+func TestDerive(t *testing.T) {
+	tests := []struct {
+		name       string
+		containers []container
+		podExists  bool
+		status     string
+		restarts   int
+	}{
+		{"nothing", nil, false, model.StatusRemoved, 0},
+		{"empty pod", nil, true, model.StatusFailed, 0},
+		{"all running", []container{{State: "running", Restarts: 1}, {State: "running", Restarts: 1}}, true, model.StatusRunning, 2},
+		{"one exited", []container{{Name: "web", State: "running"}, {Name: "db", State: "exited", ExitCode: 1}}, true, model.StatusFailed, 0},
+		{"one paused", []container{{Name: "web", State: "running"}, {Name: "db", State: "paused"}}, true, model.StatusFailed, 0},
+		// * 以 0 結束的 migration 不影響其餘服務
+		{"one-shot done", []container{{Name: "web", State: "running"}, {Name: "migrate", State: "exited"}}, true, model.StatusRunning, 0},
+		{"only one-shot done", []container{{Name: "migrate", State: "exited"}}, true, model.StatusFailed, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, restarts, _ := derive(tt.containers, tt.podExists)
+			if status != tt.status || restarts != tt.restarts {
+				t.Fatalf("got %s/%d, want %s/%d", status, restarts, tt.status, tt.restarts)
+			}
+		})
+	}
+}
+
+func TestParseContainers(t *testing.T) {
+	output := `[
+  {"Id": "0123456789abcdef", "Names": ["web_app_1"], "State": "running", "Restarts": 2, "Labels": {"io.podman.compose.project": "shop"}},
+  {"Id": "fedcba9876543210", "Names": ["web_db_1"], "State": "exited", "ExitCode": 137, "Labels": {"io.podman.compose.project": "shop"}},
+  {"Id": "1111", "Names": ["manual"], "State": "running", "Labels": null}
+]`
+	projects, err := parseContainers(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || len(projects["shop"]) != 2 {
+		t.Fatalf("projects = %+v", projects)
+	}
+	if c := projects["shop"][0]; c.ID != "0123456789ab" || c.Name != "web_app_1" || c.Restarts != 2 {
+		t.Fatalf("container = %+v", c)
+	}
+	if c := projects["shop"][1]; c.State != "exited" || c.ExitCode != 137 {
+		t.Fatalf("container = %+v", c)
+	}
+
+	if projects, err := parseContainers(""); err != nil || len(projects) != 0 {
+		t.Fatalf("empty output: %v, %v", projects, err)
+	}
+	if _, err := parseContainers("not json"); err == nil {
+		t.Fatal("expected a parse error")
+	}
+
+	pods, err := parsePods(`[{"Name": "pod_shop"}]`)
+	if err != nil || !pods["pod_shop"] || pods["pod_web"] {
+		t.Fatalf("pods = %v, %v", pods, err)
+	}
+}
@@ -0,0 +1,267 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/database"
+	"github.com/pardnchiu/go-podrun/internal/inventory"
+	"github.com/pardnchiu/go-podrun/internal/model"
+	"github.com/pardnchiu/go-podrun/internal/transport"
+	"github.com/pardnchiu/go-podrun/internal/utils"
+)
+
+const (
+	DefaultConcurrency = 4
+	// * 連續失敗時的最長等待時間
+	maxBackoff = 30 * time.Minute
+	// * 連續幾次查無容器與 pod 才標記為 removed，避免重新部署期間的短暫空窗
+	removeAfter = 3
+)
+
+type Reconciler struct {
+	db *database.SQLite
+	// * 未記錄主機的 pod 使用的預設主機，可為 nil
+	env         *utils.Podrun
+	interval    time.Duration
+	concurrency int
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+	// * 各 pod 連續查無容器的次數
+	missing map[string]int
+}
+
+// * 每台主機獨立計算 backoff，單一主機離線不影響其他主機
+type hostState struct {
+	failures int
+	next     time.Time
+}
+
+func New(db *database.SQLite, env *utils.Podrun, interval time.Duration, concurrency int) *Reconciler {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	return &Reconciler{
+		db:          db,
+		env:         env,
+		interval:    interval,
+		concurrency: concurrency,
+		hosts:       map[string]*hostState{},
+		missing:     map[string]int{},
+	}
+}
+
+// * RECONCILE_INTERVAL 未設定時不啟用，回傳 nil
+// * SSH 連線沿用 CLI 的 inventory 與 PODRUN_* 驗證方式
+func FromEnv(db *database.SQLite) (*Reconciler, error) {
+	value := os.Getenv("RECONCILE_INTERVAL")
+	if value == "" {
+		return nil, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		return nil, fmt.Errorf("invalid RECONCILE_INTERVAL: %s", value)
+	}
+
+	concurrency := DefaultConcurrency
+	if value := os.Getenv("RECONCILE_CONCURRENCY"); value != "" {
+		if concurrency, err = strconv.Atoi(value); err != nil || concurrency <= 0 {
+			return nil, fmt.Errorf("invalid RECONCILE_CONCURRENCY: %s", value)
+		}
+	}
+
+	// * 所有 pod 都記錄了主機時可不設定預設主機
+	env, err := utils.CheckENV()
+	if err != nil && !errors.Is(err, utils.ErrNoServer) {
+		return nil, fmt.Errorf("reconciler: %w", err)
+	}
+	return New(db, env, interval, concurrency), nil
+}
+
+func (r *Reconciler) Run(ctx context.Context) {
+	slog.Info("reconciler started",
+		slog.Duration("interval", r.interval),
+		slog.Int("concurrency", r.concurrency))
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reconciler) tick(ctx context.Context) {
+	pods, err := r.db.ListPods(ctx, database.PodFilter{})
+	if err != nil {
+		slog.Error("reconciler: failed to list pods",
+			slog.String("error", err.Error()))
+		return
+	}
+
+	groups := map[string][]model.Pod{}
+	for _, e := range pods {
+		if !reconcilable(e) {
+			continue
+		}
+		server := r.serverOf(e)
+		groups[server] = append(groups[server], e)
+	}
+
+	sem := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	now := time.Now()
+	for server, list := range groups {
+		if !r.due(server, now) {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := r.checkServer(ctx, server, list)
+			r.done(server, err)
+		}()
+	}
+	wg.Wait()
+}
+
+// * starting 代表 CLI 正在部署，交由 CLI 決定結果；k3s 尚未支援
+func reconcilable(d model.Pod) bool {
+	if d.Target == "k3s" {
+		return false
+	}
+	return d.Status == model.StatusRunning || d.Status == model.StatusFailed
+}
+
+// * 未記錄主機的舊資料歸到預設主機
+func (r *Reconciler) serverOf(d model.Pod) string {
+	if d.Server != "" || r.env == nil {
+		return d.Server
+	}
+	return r.env.Name
+}
+
+// * 依序使用本機 inventory、registry 的 servers 與預設主機；憑證只來自本機設定
+func (r *Reconciler) serverEnv(ctx context.Context, name string) (*utils.Podrun, error) {
+	if r.env != nil && (name == "" || name == r.env.Name) {
+		return r.env, nil
+	}
+	if name == "" {
+		return nil, utils.ErrNoServer
+	}
+
+	inv, err := inventory.Load(inventory.DefaultPath())
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := inv.Get(name); ok {
+		return utils.ServerEnv(s)
+	}
+
+	servers, err := r.db.ListServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range servers {
+		if e.Name == name {
+			return utils.ServerEnv(&inventory.Server{
+				Name:    e.Name,
+				Host:    e.Host,
+				Port:    e.Port,
+				User:    e.User,
+				BaseDir: e.BaseDir,
+			})
+		}
+	}
+	return utils.ServerEnv(&inventory.Server{Name: name, Host: name})
+}
+
+func (r *Reconciler) due(server string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.hosts[server]
+	return !ok || !now.Before(state.next)
+}
+
+// * 失敗時等待 interval * 2^(failures-1)，上限 maxBackoff；成功後歸零
+func (r *Reconciler) done(server string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.hosts[server]
+	if !ok {
+		state = &hostState{}
+		r.hosts[server] = state
+	}
+	if err == nil {
+		if state.failures > 0 {
+			slog.Info("reconciler: host recovered",
+				slog.String("server", server))
+		}
+		state.failures = 0
+		state.next = time.Time{}
+		return
+	}
+
+	state.failures++
+	wait := r.interval << min(state.failures-1, 16)
+	if wait > maxBackoff || wait <= 0 {
+		wait = max(maxBackoff, r.interval)
+	}
+	state.next = time.Now().Add(wait)
+	slog.Warn("reconciler: failed to check host",
+		slog.String("server", server),
+		slog.Int("failures", state.failures),
+		slog.Duration("retry_in", wait),
+		slog.String("error", err.Error()))
+}
+
+// * 回傳包含本次在內連續查無容器的次數；found 為 true 時歸零
+func (r *Reconciler) countMissing(uid string, found bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if found {
+		delete(r.missing, uid)
+		return 0
+	}
+	r.missing[uid]++
+	return r.missing[uid]
+}
+
+func (r *Reconciler) dial(env *utils.Podrun) (*transport.Client, error) {
+	cred, err := env.Credential.Credential()
+	if err != nil {
+		return nil, err
+	}
+	store := transport.NewHostKeyStore(transport.DefaultKnownHostsPath())
+	// * 背景執行無法確認未知主機，需先以 podrun hosts trust 登錄
+	callback, err := store.Callback(nil)
+	if err != nil {
+		return nil, err
+	}
+	return transport.Dial(&transport.Config{
+		Host:              env.Server,
+		User:              env.Username,
+		Password:          cred.Password,
+		IdentityFile:      cred.IdentityFile,
+		Agent:             cred.Agent,
+		HostKeyCallback:   callback,
+		HostKeyAlgorithms: store.Algorithms(env.Server),
+	})
+}
@@ -0,0 +1,6 @@
+//go:build cgo
+
+package registry
+
+// * go-sqlite3 需要 cgo
+const cgoEnabled = true
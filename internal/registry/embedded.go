@@ -0,0 +1,230 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pardnchiu/go-podrun/internal/database"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+const defaultRecordLimit = 50
+
+// * 直接開啟 DB_PATH，不需要另外啟動 podrun-api
+type Embedded struct {
+	path string
+
+	once sync.Once
+	db   *database.SQLite
+	err  error
+}
+
+func NewEmbedded(path string) *Embedded {
+	return &Embedded{path: path}
+}
+
+func (r *Embedded) String() string {
+	return fmt.Sprintf("%s (%s)", BackendEmbedded, r.path)
+}
+
+// * 第一次使用時才開檔；與 podrun-api 共用同一個檔案時等待鎖釋放
+func (r *Embedded) open() (*database.SQLite, error) {
+	r.once.Do(func() {
+		if !cgoEnabled {
+			r.err = ErrRequiresCgo
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+			r.err = fmt.Errorf("%w: %w", ErrUnavailable, err)
+			return
+		}
+		db, err := database.NewSQLite(r.path + "?_busy_timeout=5000")
+		if err != nil {
+			r.err = fmt.Errorf("%w: open %s: %w", ErrUnavailable, r.path, err)
+			return
+		}
+		r.db = db
+	})
+	return r.db, r.err
+}
+
+// * 啟動時先開檔，未啟用 cgo 時立即回傳 ErrRequiresCgo
+func (r *Embedded) Check() error {
+	_, err := r.open()
+	return err
+}
+
+func (r *Embedded) ListPods(ctx context.Context, q PodQuery) ([]model.Pod, error) {
+	db, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	pods, err := db.ListPods(ctx, database.PodFilter{Owner: q.Owner, Server: q.Server})
+	return pods, wrapSQLite(err)
+}
+
+func (r *Embedded) GetPod(ctx context.Context, uid string) (*model.Pod, error) {
+	db, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	pod, err := db.PodInfo(ctx, uid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: pod %s", ErrNotFound, uid)
+	}
+	return pod, wrapSQLite(err)
+}
+
+func (r *Embedded) UpsertPod(ctx context.Context, d *model.Pod) error {
+	db, err := r.open()
+	if err != nil {
+		return err
+	}
+	return wrapSQLite(db.UpsertPod(ctx, d))
+}
+
+func (r *Embedded) UpdatePod(ctx context.Context, uid string, d *model.Pod) error {
+	db, err := r.open()
+	if err != nil {
+		return err
+	}
+	pod := *d
+	pod.UID = uid
+	return wrapSQLite(db.UpdatePod(ctx, &pod))
+}
+
+func (r *Embedded) InsertRecord(ctx context.Context, rec *model.Record) error {
+	db, err := r.open()
+	if err != nil {
+		return err
+	}
+	return wrapSQLite(db.InsertRecord(ctx, rec))
+}
+
+func (r *Embedded) ListRecords(ctx context.Context, uid string, q RecordQuery) ([]model.Record, int, error) {
+	db, err := r.open()
+	if err != nil {
+		return nil, 0, err
+	}
+	filter := database.RecordFilter{
+		Since:  q.Since,
+		Until:  q.Until,
+		Limit:  q.Limit,
+		Offset: q.Offset,
+		User:   q.User,
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultRecordLimit
+	}
+	records, total, err := db.ListPodRecords(ctx, uid, filter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, fmt.Errorf("%w: pod %s", ErrNotFound, uid)
+	}
+	return records, total, wrapSQLite(err)
+}
+
+func (r *Embedded) ListPorts(ctx context.Context, uid string) ([]model.Port, error) {
+	db, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	ports, err := db.ListPorts(ctx, uid)
+	return ports, wrapSQLite(err)
+}
+
+// * 與 API 相同，使用 PORT_RANGE 的範圍
+func (r *Embedded) AllocatePorts(ctx context.Context, uid string, reqs []model.Port) ([]model.Port, error) {
+	min, max, err := database.ParsePortRange(os.Getenv("PORT_RANGE"))
+	if err != nil {
+		return nil, err
+	}
+	db, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	ports, err := db.AllocatePorts(ctx, uid, reqs, min, max)
+	return ports, wrapSQLite(err)
+}
+
+func (r *Embedded) ReleasePorts(ctx context.Context, uid string) error {
+	db, err := r.open()
+	if err != nil {
+		return err
+	}
+	return wrapSQLite(db.ReleasePorts(ctx, uid))
+}
+
+func (r *Embedded) ListServers(ctx context.Context) ([]model.Server, error) {
+	db, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	servers, err := db.ListServers(ctx)
+	return servers, wrapSQLite(err)
+}
+
+func (r *Embedded) UpsertServer(ctx context.Context, d *model.Server) error {
+	db, err := r.open()
+	if err != nil {
+		return err
+	}
+	return wrapSQLite(db.UpsertServer(ctx, d))
+}
+
+func (r *Embedded) ListDomains(ctx context.Context, uid string) ([]model.Domain, error) {
+	db, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	domains, err := db.ListDomains(ctx, uid)
+	return domains, wrapSQLite(err)
+}
+
+func (r *Embedded) UpsertDomain(ctx context.Context, d *model.Domain) error {
+	db, err := r.open()
+	if err != nil {
+		return err
+	}
+	err = db.UpsertDomain(ctx, d)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: pod %s", ErrNotFound, d.UID)
+	}
+	return wrapSQLite(err)
+}
+
+func (r *Embedded) RemoveDomain(ctx context.Context, d *model.Domain) error {
+	db, err := r.open()
+	if err != nil {
+		return err
+	}
+	removed, err := db.RemoveDomain(ctx, d)
+	if err != nil {
+		return wrapSQLite(err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("%w: domain %s", ErrNotFound, d.Domain)
+	}
+	return nil
+}
+
+// * 等待逾時仍被鎖住視為暫時無法寫入；狀態轉換與 domain 衝突對應 ErrConflict
+// * 以訊息判斷，CGO_ENABLED=0 時 go-sqlite3 不提供 sqlite3.Error
+func wrapSQLite(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, database.ErrInvalidTransition) || errors.Is(err, database.ErrDomainTaken) {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	msg := err.Error()
+	if strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked") {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
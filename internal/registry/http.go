@@ -0,0 +1,137 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+
+	"github.com/pardnchiu/go-podrun/internal/client"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+type HTTP struct {
+	client *client.Client
+}
+
+func NewHTTP(c *client.Client) *HTTP {
+	return &HTTP{client: c}
+}
+
+func (r *HTTP) String() string {
+	return fmt.Sprintf("%s (%s)", BackendHTTP, r.client.BaseURL())
+}
+
+func (r *HTTP) ListPods(ctx context.Context, q PodQuery) ([]model.Pod, error) {
+	pods, err := r.client.ListPods(ctx, q)
+	return pods, wrapHTTP(err)
+}
+
+func (r *HTTP) GetPod(ctx context.Context, uid string) (*model.Pod, error) {
+	pod, err := r.client.GetPod(ctx, uid)
+	return pod, wrapHTTP(err)
+}
+
+func (r *HTTP) UpsertPod(ctx context.Context, d *model.Pod) error {
+	return wrapHTTP(r.client.UpsertPod(ctx, d))
+}
+
+func (r *HTTP) UpdatePod(ctx context.Context, uid string, d *model.Pod) error {
+	return wrapHTTP(r.client.UpdatePod(ctx, uid, d))
+}
+
+func (r *HTTP) InsertRecord(ctx context.Context, rec *model.Record) error {
+	return wrapHTTP(r.client.InsertRecord(ctx, rec))
+}
+
+func (r *HTTP) ListRecords(ctx context.Context, uid string, q RecordQuery) ([]model.Record, int, error) {
+	records, total, err := r.client.ListRecords(ctx, uid, q)
+	return records, total, wrapHTTP(err)
+}
+
+func (r *HTTP) ListPorts(ctx context.Context, uid string) ([]model.Port, error) {
+	ports, err := r.client.ListPorts(ctx, uid)
+	return ports, wrapHTTP(err)
+}
+
+func (r *HTTP) AllocatePorts(ctx context.Context, uid string, reqs []model.Port) ([]model.Port, error) {
+	ports, err := r.client.AllocatePorts(ctx, uid, reqs)
+	return ports, wrapHTTP(err)
+}
+
+func (r *HTTP) ReleasePorts(ctx context.Context, uid string) error {
+	return wrapHTTP(r.client.ReleasePorts(ctx, uid))
+}
+
+func (r *HTTP) ListServers(ctx context.Context) ([]model.Server, error) {
+	servers, err := r.client.ListServers(ctx)
+	return servers, wrapHTTP(err)
+}
+
+func (r *HTTP) UpsertServer(ctx context.Context, d *model.Server) error {
+	return wrapHTTP(r.client.UpsertServer(ctx, d))
+}
+
+func (r *HTTP) ListDomains(ctx context.Context, uid string) ([]model.Domain, error) {
+	domains, err := r.client.ListDomains(ctx, uid)
+	return domains, wrapHTTP(err)
+}
+
+func (r *HTTP) UpsertDomain(ctx context.Context, d *model.Domain) error {
+	return wrapHTTP(r.client.UpsertDomain(ctx, d))
+}
+
+func (r *HTTP) RemoveDomain(ctx context.Context, d *model.Domain) error {
+	return wrapHTTP(r.client.RemoveDomain(ctx, d))
+}
+
+func (r *HTTP) Me(ctx context.Context) (*model.User, error) {
+	user, err := r.client.Me(ctx)
+	return user, wrapHTTP(err)
+}
+
+// * 404 對應 ErrNotFound；401 / 403 對應 ErrUnauthorized；連線失敗、逾時與 5xx 對應 ErrUnavailable
+// * 其餘錯誤（例如回應無法解析）原樣回傳，重送也不會成功
+func wrapHTTP(err error) error {
+	if err == nil {
+		return nil
+	}
+	var statusErr *client.StatusError
+	if !errors.As(err, &statusErr) {
+		if transportError(err) {
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return err
+	}
+	switch code := statusErr.StatusCode; {
+	case code == http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	case code == http.StatusConflict:
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case code >= http.StatusInternalServerError:
+		// * 例如 SQLITE_BUSY 造成的 500，稍後重送即可
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// * 連線、逾時、連線中斷或 context 取消；請求本身沒有問題
+func transportError(err error) bool {
+	// * url.Parse 的錯誤也是 *url.Error，屬於設定錯誤而非連線問題
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Op != "parse"
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
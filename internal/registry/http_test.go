@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pardnchiu/go-podrun/internal/client"
+)
+
+// * 以固定的狀態碼與內容回應的 API server
+func newTestHTTP(t *testing.T, status int, body string) *HTTP {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewHTTP(client.New(server.URL, client.WithRetries(0, 0)))
+}
+
+// * 只有連線層的錯誤可以稍後重送，無法解析的回應原樣回傳
+func TestWrapHTTPTransport(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	offline := NewHTTP(client.New("http://"+addr, client.WithRetries(0, 0)))
+	if _, err := offline.GetPod(context.Background(), "a"); !IsUnavailable(err) {
+		t.Fatalf("connection refused: got %v, want ErrUnavailable", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := newTestHTTP(t, http.StatusOK, "{}").GetPod(ctx, "a"); !IsUnavailable(err) {
+		t.Fatalf("canceled: got %v, want ErrUnavailable", err)
+	}
+
+	_, err = newTestHTTP(t, http.StatusOK, "not json").GetPod(context.Background(), "a")
+	if err == nil || IsUnavailable(err) {
+		t.Fatalf("decode error: got %v, want a non-retryable error", err)
+	}
+
+	bad := NewHTTP(client.New("http://[::1", client.WithRetries(0, 0)))
+	if _, err := bad.GetPod(context.Background(), "a"); err == nil || IsUnavailable(err) {
+		t.Fatalf("invalid URL: got %v, want a non-retryable error", err)
+	}
+}
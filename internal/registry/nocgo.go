@@ -0,0 +1,5 @@
+//go:build !cgo
+
+package registry
+
+const cgoEnabled = false
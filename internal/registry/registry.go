@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/pardnchiu/go-podrun/internal/client"
+	"github.com/pardnchiu/go-podrun/internal/database"
+	"github.com/pardnchiu/go-podrun/internal/model"
+)
+
+const (
+	BackendEmbedded = "embedded"
+	BackendHTTP     = "http"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	// * 暫時無法寫入（API 離線、資料庫被鎖），journal 會保留待重送
+	ErrUnavailable = errors.New("registry unavailable")
+	// * token 缺少、無效或已撤銷；更新 token 後可重送
+	ErrUnauthorized = errors.New("registry rejected the token")
+	// * 不合法的狀態轉換或 domain 已被使用，重送也不會成功
+	ErrConflict = errors.New("registry rejected the change")
+	// * CGO_ENABLED=0 建置時無法開啟 SQLite，重試也不會成功
+	ErrRequiresCgo = errors.New("embedded registry requires cgo; set PODRUN_API")
+)
+
+type PodQuery = client.PodQuery
+
+type RecordQuery = client.RecordQuery
+
+// * CLI 讀寫部署紀錄的介面，可直接開 SQLite 或透過 HTTP API
+type Registry interface {
+	ListPods(ctx context.Context, q PodQuery) ([]model.Pod, error)
+	GetPod(ctx context.Context, uid string) (*model.Pod, error)
+	UpsertPod(ctx context.Context, d *model.Pod) error
+	UpdatePod(ctx context.Context, uid string, d *model.Pod) error
+
+	InsertRecord(ctx context.Context, r *model.Record) error
+	ListRecords(ctx context.Context, uid string, q RecordQuery) ([]model.Record, int, error)
+
+	ListPorts(ctx context.Context, uid string) ([]model.Port, error)
+	AllocatePorts(ctx context.Context, uid string, reqs []model.Port) ([]model.Port, error)
+	ReleasePorts(ctx context.Context, uid string) error
+
+	ListServers(ctx context.Context) ([]model.Server, error)
+	UpsertServer(ctx context.Context, d *model.Server) error
+
+	ListDomains(ctx context.Context, uid string) ([]model.Domain, error)
+	UpsertDomain(ctx context.Context, d *model.Domain) error
+	RemoveDomain(ctx context.Context, d *model.Domain) error
+
+	// * 顯示用，例如 embedded (~/.podrun/database.db)
+	String() string
+}
+
+// * PODRUN_REGISTRY=embedded|http
+// * 未指定時：有設定 PODRUN_API 時走 HTTP，否則直接開本機 SQLite
+// * 未啟用 cgo 時不改走 HTTP（預設指向 localhost，寫入只會堆在 journal），由 Embedded.open 回傳 ErrRequiresCgo
+func FromEnv() Registry {
+	backend := os.Getenv("PODRUN_REGISTRY")
+	if backend == "" {
+		backend = BackendEmbedded
+		if os.Getenv("PODRUN_API") != "" {
+			backend = BackendHTTP
+		}
+	}
+
+	if backend == BackendHTTP || backend == "api" {
+		return NewHTTP(client.FromEnv())
+	}
+
+	path := os.Getenv("DB_PATH")
+	if path == "" {
+		path = database.DefaultPath()
+	}
+	return NewEmbedded(path)
+}
+
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrUnavailable)
+}
+
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+func IsRequiresCgo(err error) bool {
+	return errors.Is(err, ErrRequiresCgo)
+}
@@ -0,0 +1,74 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func (c *Config) authMethods() ([]ssh.AuthMethod, net.Conn, error) {
+	var methods []ssh.AuthMethod
+	var agentConn net.Conn
+
+	// * ssh-agent 優先，其次私鑰，最後才是密碼
+	if c.Agent {
+		if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+			conn, err := net.Dial("unix", sock)
+			if err == nil {
+				agentConn = conn
+				methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+			}
+		}
+	}
+
+	if c.IdentityFile != "" {
+		signer, err := c.identitySigner()
+		if err != nil {
+			if agentConn != nil {
+				agentConn.Close()
+			}
+			return nil, nil, err
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if c.Password != "" {
+		password := c.Password
+		methods = append(methods,
+			ssh.Password(password),
+			ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range questions {
+					answers[i] = password
+				}
+				return answers, nil
+			}),
+		)
+	}
+
+	if len(methods) == 0 {
+		return nil, nil, fmt.Errorf("no ssh auth method available (password, identity file or ssh-agent)")
+	}
+	return methods, agentConn, nil
+}
+
+func (c *Config) identitySigner() (ssh.Signer, error) {
+	key, err := os.ReadFile(c.IdentityFile)
+	if err != nil {
+		return nil, fmt.Errorf("read identity file: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(key)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) && c.Password != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(c.Password))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse identity file %s: %w", c.IdentityFile, err)
+	}
+	return signer, nil
+}
@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const defaultTimeout = 10 * time.Second
+
+type Config struct {
+	Host         string
+	Port         int
+	User         string
+	Password     string
+	IdentityFile string
+	Agent        bool
+	Timeout      time.Duration
+
+	HostKeyCallback   ssh.HostKeyCallback
+	HostKeyAlgorithms []string
+}
+
+type Client struct {
+	conn  *ssh.Client
+	agent net.Conn
+}
+
+func Dial(cfg *Config) (*Client, error) {
+	if cfg.HostKeyCallback == nil {
+		return nil, fmt.Errorf("host key callback is required")
+	}
+
+	auths, agentConn, err := cfg.authMethods()
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	conn, err := ssh.Dial("tcp", cfg.Addr(), &ssh.ClientConfig{
+		User:              cfg.User,
+		Auth:              auths,
+		HostKeyCallback:   cfg.HostKeyCallback,
+		HostKeyAlgorithms: cfg.HostKeyAlgorithms,
+		Timeout:           timeout,
+	})
+	if err != nil {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+		return nil, fmt.Errorf("ssh dial %s: %w", cfg.Addr(), err)
+	}
+
+	return &Client{
+		conn:  conn,
+		agent: agentConn,
+	}, nil
+}
+
+func (c *Config) Addr() string {
+	// * PODRUN_SERVER 可直接帶 port，例如 host:2222
+	if host, port, err := net.SplitHostPort(c.Host); err == nil {
+		return net.JoinHostPort(host, port)
+	}
+	port := c.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+func (c *Client) Close() error {
+	if c.agent != nil {
+		c.agent.Close()
+	}
+	return c.conn.Close()
+}
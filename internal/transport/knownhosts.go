@@ -0,0 +1,216 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var ErrHostKeyChanged = errors.New("remote host key has changed")
+
+// * 首次連線時詢問使用者是否信任，回傳 false 即拒絕連線
+type ConfirmFunc func(host string, key ssh.PublicKey) bool
+
+type HostKeyStore struct {
+	path string
+}
+
+type HostKey struct {
+	Hosts       []string
+	Type        string
+	Fingerprint string
+}
+
+func DefaultKnownHostsPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".podrun", "known_hosts")
+}
+
+func NewHostKeyStore(path string) *HostKeyStore {
+	return &HostKeyStore{path: path}
+}
+
+func Fingerprint(key ssh.PublicKey) string {
+	return ssh.FingerprintSHA256(key)
+}
+
+func (s *HostKeyStore) ensure() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (s *HostKeyStore) Callback(confirm ConfirmFunc) (ssh.HostKeyCallback, error) {
+	if err := s.ensure(); err != nil {
+		return nil, err
+	}
+	check, err := knownhosts.New(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("read known_hosts: %w", err)
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := check(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+
+		// * 已記錄但不相符：一律拒絕，不給覆蓋的機會
+		if len(keyErr.Want) > 0 {
+			want := make([]string, len(keyErr.Want))
+			for i, e := range keyErr.Want {
+				want[i] = fmt.Sprintf("%s %s (%s:%d)", e.Key.Type(), Fingerprint(e.Key), e.Filename, e.Line)
+			}
+			return fmt.Errorf("%w for %s\n    got:      %s %s\n    expected: %s\n    run `podrun hosts forget %s` only if the change is expected",
+				ErrHostKeyChanged, knownhosts.Normalize(hostname),
+				key.Type(), Fingerprint(key),
+				strings.Join(want, "\n              "),
+				hostname)
+		}
+
+		if confirm == nil || !confirm(knownhosts.Normalize(hostname), key) {
+			return fmt.Errorf("host key for %s is not trusted (%s %s), run `podrun hosts trust` first",
+				knownhosts.Normalize(hostname), key.Type(), Fingerprint(key))
+		}
+		return s.Trust(hostname, key)
+	}, nil
+}
+
+// * 依已記錄的金鑰類型指定協商演算法，避免伺服器換用其他類型時被誤判為金鑰變更
+func (s *HostKeyStore) Algorithms(hostname string) []string {
+	keys, err := s.List()
+	if err != nil {
+		return nil
+	}
+
+	target := knownhosts.Normalize(hostname)
+	var algos []string
+	for _, k := range keys {
+		for _, h := range k.Hosts {
+			if h != target {
+				continue
+			}
+			if k.Type == ssh.KeyAlgoRSA {
+				algos = append(algos, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256)
+			}
+			algos = append(algos, k.Type)
+		}
+	}
+	return algos
+}
+
+func (s *HostKeyStore) Trust(hostname string, key ssh.PublicKey) error {
+	if err := s.ensure(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key))
+	return err
+}
+
+func (s *HostKeyStore) Forget(hostname string) (int, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	target := knownhosts.Normalize(hostname)
+	var kept bytes.Buffer
+	removed := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && !strings.HasPrefix(fields[0], "#") {
+			hosts := strings.Split(fields[0], ",")
+			if slices.Contains(hosts, target) {
+				removed++
+				continue
+			}
+		}
+		kept.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, os.WriteFile(s.path, kept.Bytes(), 0600)
+}
+
+func (s *HostKeyStore) List() ([]HostKey, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var keys []HostKey
+	for len(data) > 0 {
+		_, hosts, key, _, rest, err := ssh.ParseKnownHosts(data)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("parse known_hosts: %w", err)
+		}
+		keys = append(keys, HostKey{
+			Hosts:       hosts,
+			Type:        key.Type(),
+			Fingerprint: Fingerprint(key),
+		})
+		data = rest
+	}
+	return keys, nil
+}
+
+// * 只做 handshake 取得 host key，不進行驗證
+func FetchHostKey(host string, port int) (ssh.PublicKey, string, error) {
+	cfg := &Config{Host: host, Port: port}
+	addr := cfg.Addr()
+
+	var hostKey ssh.PublicKey
+	errCaptured := errors.New("host key captured")
+	_, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errCaptured
+		},
+		Timeout: defaultTimeout,
+	})
+	if hostKey == nil {
+		return nil, addr, fmt.Errorf("ssh handshake %s: %w", addr, err)
+	}
+	return hostKey, addr, nil
+}
@@ -0,0 +1,195 @@
+package transport
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newStore(t *testing.T) *HostKeyStore {
+	t.Helper()
+
+	return NewHostKeyStore(filepath.Join(t.TempDir(), ".podrun", "known_hosts"))
+}
+
+// * 以 store 的 callback 連線至測試 server
+func dialStore(t *testing.T, s *testServer, store *HostKeyStore, confirm ConfirmFunc) error {
+	t.Helper()
+
+	callback, err := store.Callback(confirm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := Dial(&Config{
+		Host:              s.addr,
+		User:              testUser,
+		Password:          testPassword,
+		HostKeyCallback:   callback,
+		HostKeyAlgorithms: store.Algorithms(s.addr),
+	})
+	if err != nil {
+		return err
+	}
+	client.Close()
+	return nil
+}
+
+func listKeys(t *testing.T, store *HostKeyStore) []HostKey {
+	t.Helper()
+
+	keys, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// * 首次連線經確認後寫入 known_hosts，之後不再詢問
+func TestHostKeyTrustOnFirstUse(t *testing.T) {
+	s := newTestServer(t, nil)
+	store := newStore(t)
+
+	asked := 0
+	if err := dialStore(t, s, store, func(host string, key ssh.PublicKey) bool {
+		asked++
+		return Fingerprint(key) == Fingerprint(s.hostKey)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if asked != 1 {
+		t.Fatalf("confirm called %d times, want 1", asked)
+	}
+
+	keys := listKeys(t, store)
+	if len(keys) != 1 || keys[0].Fingerprint != Fingerprint(s.hostKey) {
+		t.Fatalf("known_hosts = %+v", keys)
+	}
+	if info, err := os.Stat(store.path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("known_hosts mode = %v, %v", info.Mode(), err)
+	}
+
+	// * 已記錄的主機不需要確認，沒有提示時也能連線
+	if err := dialStore(t, s, store, nil); err != nil {
+		t.Fatalf("dial trusted host: %v", err)
+	}
+}
+
+// * 沒有提示（reconciler）或使用者拒絕時不連線，也不寫入
+func TestHostKeyUnknownRejected(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	tests := map[string]ConfirmFunc{
+		"no prompt": nil,
+		"declined":  func(string, ssh.PublicKey) bool { return false },
+	}
+	for name, confirm := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			err := dialStore(t, s, store, confirm)
+			if err == nil || !strings.Contains(err.Error(), "not trusted") {
+				t.Fatalf("got %v, want an untrusted host error", err)
+			}
+			if keys := listKeys(t, store); len(keys) != 0 {
+				t.Fatalf("known_hosts = %+v, want empty", keys)
+			}
+		})
+	}
+}
+
+// * 金鑰變更時一律失敗，不詢問是否覆蓋
+func TestHostKeyChanged(t *testing.T) {
+	s := newTestServer(t, nil)
+	store := newStore(t)
+	_, other := newKey(t)
+	if err := store.Trust(s.addr, other.PublicKey()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := dialStore(t, s, store, func(string, ssh.PublicKey) bool {
+		t.Error("confirm called for a changed key")
+		return true
+	})
+	if !errors.Is(err, ErrHostKeyChanged) {
+		t.Fatalf("got %v, want ErrHostKeyChanged", err)
+	}
+	if !strings.Contains(err.Error(), Fingerprint(other.PublicKey())) {
+		t.Fatalf("error does not show the expected key: %v", err)
+	}
+	keys := listKeys(t, store)
+	if len(keys) != 1 || keys[0].Fingerprint != Fingerprint(other.PublicKey()) {
+		t.Fatalf("known_hosts = %+v, want the original key only", keys)
+	}
+}
+
+func TestHostKeyForget(t *testing.T) {
+	store := newStore(t)
+	_, a := newKey(t)
+	_, b := newKey(t)
+	for host, key := range map[string]ssh.PublicKey{
+		"10.0.0.1":      a.PublicKey(),
+		"10.0.0.2:2222": b.PublicKey(),
+	} {
+		if err := store.Trust(host, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := store.Forget("10.0.0.2:2222"); err != nil || n != 1 {
+		t.Fatalf("Forget = %d, %v", n, err)
+	}
+	keys := listKeys(t, store)
+	if len(keys) != 1 || keys[0].Fingerprint != Fingerprint(a.PublicKey()) {
+		t.Fatalf("known_hosts = %+v", keys)
+	}
+
+	// * 同一主機的其他 port 不受影響
+	if n, err := store.Forget("10.0.0.1:2222"); err != nil || n != 0 {
+		t.Fatalf("Forget other port = %d, %v", n, err)
+	}
+	if n, err := newStore(t).Forget("10.0.0.1"); err != nil || n != 0 {
+		t.Fatalf("Forget without known_hosts = %d, %v", n, err)
+	}
+}
+
+// * 依記錄順序列出，RSA 金鑰先協商 SHA-2 簽章
+func TestHostKeyAlgorithms(t *testing.T) {
+	store := newStore(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPub, err := ssh.NewPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ed := newKey(t)
+	_, other := newKey(t)
+
+	for _, e := range []struct {
+		host string
+		key  ssh.PublicKey
+	}{
+		{"10.0.0.1", rsaPub},
+		{"10.0.0.2", other.PublicKey()},
+		{"10.0.0.1", ed.PublicKey()},
+	} {
+		if err := store.Trust(e.host, e.key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA, ssh.KeyAlgoED25519}
+	if got := store.Algorithms("10.0.0.1:22"); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("Algorithms = %v, want %v", got, want)
+	}
+	if got := store.Algorithms("10.0.0.3"); got != nil {
+		t.Fatalf("Algorithms for unknown host = %v, want nil", got)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package transport
+
+import "golang.org/x/crypto/ssh"
+
+func watchResize(_ *ssh.Session, _ int) func() {
+	return func() {}
+}
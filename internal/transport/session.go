@@ -0,0 +1,121 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+)
+
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+type RunOptions struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// * 互動指令需要 PTY（對應 ssh -tt），Ctrl+C 會直接送到遠端
+	PTY bool
+}
+
+func (c *Client) Output(command string) (*Result, error) {
+	session, err := c.conn.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("ssh session: %w", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	code, err := exitCode(session.Run(command))
+	return &Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: code,
+	}, err
+}
+
+func (c *Client) Run(command string, opt *RunOptions) (int, error) {
+	if opt == nil {
+		opt = &RunOptions{}
+	}
+
+	session, err := c.conn.NewSession()
+	if err != nil {
+		return -1, fmt.Errorf("ssh session: %w", err)
+	}
+	defer session.Close()
+
+	session.Stdin = opt.Stdin
+	session.Stdout = opt.Stdout
+	session.Stderr = opt.Stderr
+
+	if opt.PTY {
+		restore, err := requestPTY(session)
+		if err != nil {
+			return -1, err
+		}
+		defer restore()
+	}
+
+	return exitCode(session.Run(command))
+}
+
+func requestPTY(session *ssh.Session) (func(), error) {
+	fd := int(os.Stdin.Fd())
+	width, height := 80, 24
+	restore := func() {}
+
+	if term.IsTerminal(fd) {
+		if w, h, err := term.GetSize(fd); err == nil {
+			width, height = w, h
+		}
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return nil, fmt.Errorf("set terminal raw mode: %w", err)
+		}
+		stopResize := watchResize(session, fd)
+		restore = func() {
+			stopResize()
+			term.Restore(fd, state)
+		}
+	}
+
+	termType := os.Getenv("TERM")
+	if termType == "" {
+		termType = "xterm-256color"
+	}
+	if err := session.RequestPty(termType, height, width, ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}); err != nil {
+		restore()
+		return nil, fmt.Errorf("request pty: %w", err)
+	}
+	return restore, nil
+}
+
+func exitCode(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	var missingErr *ssh.ExitMissingError
+	if errors.As(err, &missingErr) {
+		return -1, fmt.Errorf("remote command exited without status")
+	}
+	return -1, err
+}
@@ -0,0 +1,394 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	testUser     = "podrun"
+	testPassword = "secret"
+)
+
+// * 測試用 SSH server：exec 依指令回應，tty 回報是否收到 pty-req
+type testServer struct {
+	addr    string
+	hostKey ssh.PublicKey
+	// * 允許的公鑰，用於私鑰與 agent 驗證
+	authorized ssh.PublicKey
+}
+
+func newTestServer(t *testing.T, authorized ssh.PublicKey) *testServer {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if c.User() == testUser && string(password) == testPassword {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("password rejected")
+		},
+		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if authorized != nil && c.User() == testUser && bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("public key rejected")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, config)
+		}
+	}()
+
+	return &testServer{
+		addr:       listener.Addr().String(),
+		hostKey:    hostSigner.PublicKey(),
+		authorized: authorized,
+	}
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go serveSession(channel, requests)
+	}
+}
+
+func serveSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	pty := false
+	for req := range requests {
+		switch req.Type {
+		case "pty-req":
+			pty = true
+			req.Reply(true, nil)
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				req.Reply(false, nil)
+				return
+			}
+			req.Reply(true, nil)
+
+			status := execute(channel, payload.Command, pty)
+			code := make([]byte, 4)
+			binary.BigEndian.PutUint32(code, status)
+			channel.SendRequest("exit-status", false, code)
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func execute(channel ssh.Channel, command string, pty bool) uint32 {
+	switch {
+	case command == "tty":
+		if pty {
+			fmt.Fprint(channel, "pty")
+		} else {
+			fmt.Fprint(channel, "no-pty")
+		}
+		return 0
+	case strings.HasPrefix(command, "echo "):
+		fmt.Fprintln(channel, strings.TrimPrefix(command, "echo "))
+		return 0
+	case strings.HasPrefix(command, "fail "):
+		fmt.Fprintln(channel.Stderr(), "failed")
+		var code uint32
+		fmt.Sscan(strings.TrimPrefix(command, "fail "), &code)
+		return code
+	}
+	fmt.Fprintf(channel.Stderr(), "unknown command: %s\n", command)
+	return 127
+}
+
+func newKey(t *testing.T) (ed25519.PrivateKey, ssh.Signer) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, signer
+}
+
+func writeKey(t *testing.T, priv ed25519.PrivateKey, passphrase string) string {
+	t.Helper()
+
+	var block *pem.Block
+	var err error
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(priv, "")
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte(passphrase))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func startAgent(t *testing.T, priv ed25519.PrivateKey) {
+	t.Helper()
+
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+		t.Fatal(err)
+	}
+
+	// * unix socket 路徑有長度限制，不使用 t.TempDir
+	dir, err := os.MkdirTemp("", "podrun-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	sock := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix socket unavailable: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+}
+
+func dial(t *testing.T, s *testServer, cfg Config) *Client {
+	t.Helper()
+
+	cfg.Host = s.addr
+	cfg.User = testUser
+	cfg.HostKeyCallback = ssh.FixedHostKey(s.hostKey)
+	client, err := Dial(&cfg)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestDialAuth(t *testing.T) {
+	priv, signer := newKey(t)
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) Config
+	}{
+		{
+			name: "password",
+			setup: func(t *testing.T) Config {
+				return Config{Password: testPassword}
+			},
+		},
+		{
+			name: "identity file",
+			setup: func(t *testing.T) Config {
+				return Config{IdentityFile: writeKey(t, priv, "")}
+			},
+		},
+		{
+			name: "identity file with passphrase",
+			setup: func(t *testing.T) Config {
+				return Config{IdentityFile: writeKey(t, priv, "phrase"), Password: "phrase"}
+			},
+		},
+		{
+			name: "agent",
+			setup: func(t *testing.T) Config {
+				startAgent(t, priv)
+				return Config{Agent: true}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, signer.PublicKey())
+			client := dial(t, s, tt.setup(t))
+
+			result, err := client.Output("echo hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Stdout != "hello\n" || result.ExitCode != 0 {
+				t.Fatalf("got %q (exit %d)", result.Stdout, result.ExitCode)
+			}
+		})
+	}
+}
+
+func TestDialRejected(t *testing.T) {
+	_, signer := newKey(t)
+	s := newTestServer(t, signer.PublicKey())
+
+	_, err := Dial(&Config{
+		Host:            s.addr,
+		User:            testUser,
+		Password:        "wrong",
+		HostKeyCallback: ssh.FixedHostKey(s.hostKey),
+	})
+	if err == nil {
+		t.Fatal("expected wrong password to be rejected")
+	}
+
+	// * 其他主機的金鑰視為不符
+	_, other := newKey(t)
+	_, err = Dial(&Config{
+		Host:            s.addr,
+		User:            testUser,
+		Password:        testPassword,
+		HostKeyCallback: ssh.FixedHostKey(other.PublicKey()),
+	})
+	if err == nil {
+		t.Fatal("expected mismatched host key to be rejected")
+	}
+}
+
+func TestDialNoAuthMethod(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	_, err := Dial(&Config{
+		Host:            "127.0.0.1:1",
+		User:            testUser,
+		Agent:           true,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err == nil || !strings.Contains(err.Error(), "no ssh auth method") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestOutputExitStatus(t *testing.T) {
+	s := newTestServer(t, nil)
+	client := dial(t, s, Config{Password: testPassword})
+
+	result, err := client.Output("fail 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 3 {
+		t.Fatalf("exit code = %d, want 3", result.ExitCode)
+	}
+	if result.Stderr != "failed\n" {
+		t.Fatalf("stderr = %q", result.Stderr)
+	}
+}
+
+func TestRunExitStatus(t *testing.T) {
+	s := newTestServer(t, nil)
+	client := dial(t, s, Config{Password: testPassword})
+
+	var stdout, stderr bytes.Buffer
+	code, err := client.Run("fail 42", &RunOptions{Stdout: &stdout, Stderr: &stderr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 42 || stderr.String() != "failed\n" {
+		t.Fatalf("got exit %d, stderr %q", code, stderr.String())
+	}
+
+	code, err = client.Run("echo ok", &RunOptions{Stdout: &stdout})
+	if err != nil || code != 0 {
+		t.Fatalf("got exit %d, err %v", code, err)
+	}
+}
+
+func TestRunPTY(t *testing.T) {
+	s := newTestServer(t, nil)
+	client := dial(t, s, Config{Password: testPassword})
+
+	for _, pty := range []bool{false, true} {
+		var stdout bytes.Buffer
+		code, err := client.Run("tty", &RunOptions{Stdout: &stdout, PTY: pty})
+		if err != nil || code != 0 {
+			t.Fatalf("pty=%v: exit %d, err %v", pty, code, err)
+		}
+		want := "no-pty"
+		if pty {
+			want = "pty"
+		}
+		if stdout.String() != want {
+			t.Fatalf("pty=%v: got %q, want %q", pty, stdout.String(), want)
+		}
+	}
+}
+
+func TestExitCodeMissingStatus(t *testing.T) {
+	_, err := exitCode(&ssh.ExitMissingError{})
+	if err == nil {
+		t.Fatal("expected an error when the remote sends no exit status")
+	}
+	if _, err := exitCode(errors.New("boom")); err == nil {
+		t.Fatal("expected transport errors to be returned")
+	}
+}
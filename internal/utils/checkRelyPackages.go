@@ -8,7 +8,7 @@ import (
 )
 
 func CheckRelyPackages() error {
-	relyPackages := []string{"sshpass", "rsync", "ssh", "curl", "unzip"}
+	relyPackages := []string{"curl", "unzip"}
 	var missPackages []string
 
 	for _, e := range relyPackages {
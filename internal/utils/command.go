@@ -1,12 +1,53 @@
 package utils
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/pardnchiu/go-podrun/internal/credential"
+	"github.com/pardnchiu/go-podrun/internal/transport"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	sshClient *transport.Client
+	sshMu     sync.Mutex
+
+	// * 未知主機的確認方式，rsh 模式下 stdin 為 rsync 協定，必須設為 nil
+	HostKeyConfirm transport.ConfirmFunc = confirmHostKey
 )
 
+// * 遠端指令以非 0 狀態結束
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("remote exited with status %d", e.Code)
+}
+
+// * 本機或遠端指令的結束狀態，無法判斷時為 1
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var remoteErr *ExitError
+	if errors.As(err, &remoteErr) {
+		return remoteErr.Code
+	}
+	var localErr *exec.ExitError
+	if errors.As(err, &localErr) && localErr.ExitCode() > 0 {
+		return localErr.ExitCode()
+	}
+	return 1
+}
+
 func CMDRun(command string, args ...string) error {
 	cmd := exec.Command(command, args...)
 	cmd.Stdout = os.Stdout
@@ -26,65 +67,238 @@ func CMDOutput(command string, args ...string) (string, error) {
 	return string(out), nil
 }
 
-func SSHTest() error {
+// * 同一個 CLI 執行期間共用一條 SSH 連線
+func SSHClient() (*transport.Client, error) {
+	sshMu.Lock()
+	defer sshMu.Unlock()
+
+	if sshClient != nil {
+		return sshClient, nil
+	}
+
 	env, err := CheckENV()
 	if err != nil {
-		return err
+		return nil, Classify(ExitUsage, err)
 	}
 
-	cmdArgs := []string{
-		"-p", env.Password,
-		"ssh",
-		"-o", "ConnectTimeout=3",
-		"-o", "StrictHostKeyChecking=no",
-		"-q", env.Remote,
-		"exit",
+	cred, err := env.Credential.Credential()
+	if err != nil {
+		return nil, Classify(ExitAuth, err)
+	}
+
+	// * 無法詢問時未知主機直接拒絕，需先加入 known_hosts
+	confirm := HostKeyConfirm
+	if !Interactive() {
+		confirm = nil
+	}
+	store := transport.NewHostKeyStore(transport.DefaultKnownHostsPath())
+	callback, err := store.Callback(confirm)
+	if err != nil {
+		return nil, Classify(ExitAuth, err)
+	}
+
+	client, err := transport.Dial(&transport.Config{
+		Host:              env.Server,
+		User:              env.Username,
+		Password:          cred.Password,
+		IdentityFile:      cred.IdentityFile,
+		Agent:             cred.Agent,
+		Timeout:           3 * time.Second,
+		HostKeyCallback:   callback,
+		HostKeyAlgorithms: store.Algorithms(env.Server),
+	})
+	if err != nil {
+		return nil, Classify(ExitAuth, err)
 	}
-	if _, err := exec.Command("sshpass", cmdArgs...).Output(); err != nil {
+	sshClient = client
+	return sshClient, nil
+}
+
+func SSHClose() {
+	sshMu.Lock()
+	defer sshMu.Unlock()
+
+	if sshClient != nil {
+		sshClient.Close()
+		sshClient = nil
+	}
+}
+
+func SSHTest() error {
+	client, err := SSHClient()
+	if err != nil {
+		return err
+	}
+
+	result, err := client.Output("exit")
+	if err != nil {
 		return err
 	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("remote exited with status %d", result.ExitCode)
+	}
 	return nil
 }
 
+// * 不接 stdin：x/crypto/ssh 複製 stdin 的 goroutine 在指令結束後仍停在 Read，會與之後的確認提示搶輸入
 func SSHRun(args ...string) error {
-	env, err := CheckENV()
+	return sshRun(strings.Join(args, " "), nil)
+}
+
+// * 跟隨 log、exec 等互動指令才接上 stdin；可互動時配置 PTY（對應 ssh -tt），CI 等非互動環境不配置
+func SSHInteractive(args ...string) error {
+	return sshRun(strings.Join(args, " "), &transport.RunOptions{
+		Stdin: os.Stdin,
+		PTY:   Interactive(),
+	})
+}
+
+func sshRun(command string, opt *transport.RunOptions) error {
+	client, err := SSHClient()
+	if err != nil {
+		return err
+	}
+
+	if opt == nil {
+		opt = &transport.RunOptions{}
+	}
+	opt.Stdout = os.Stdout
+	opt.Stderr = os.Stderr
+	code, err := client.Run(command, opt)
 	if err != nil {
 		return err
 	}
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
+
+func SSEOutput(args ...string) (string, error) {
+	client, err := SSHClient()
+	if err != nil {
+		return "", err
+	}
 
 	command := strings.Join(args, " ")
-	cmdArgs := []string{
-		"-p", env.Password,
-		"ssh",
-		"-tt",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "LogLevel=QUIET",
-		env.Remote,
-		command,
-	}
-	cmd := exec.Command("sshpass", cmdArgs...)
+	result, err := client.Output(command)
+	if err != nil {
+		return "", err
+	}
+	if result.ExitCode != 0 {
+		return result.Stdout, fmt.Errorf("remote exited with status %d: %s",
+			result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+	return result.Stdout, nil
+}
+
+func SSHWrite(path string, data []byte) error {
+	client, err := SSHClient()
+	if err != nil {
+		return err
+	}
+
+	var stderr strings.Builder
+	code, err := client.Run(
+		fmt.Sprintf("cat > '%s'", strings.ReplaceAll(path, "'", `'\''`)),
+		&transport.RunOptions{
+			Stdin:  bytes.NewReader(data),
+			Stderr: &stderr,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("write %s: remote exited with status %d: %s",
+			path, code, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func confirmHostKey(host string, key ssh.PublicKey) bool {
+	fmt.Printf("[!] host: %s\n", host)
+	fmt.Printf("    %s key fingerprint is %s\n", key.Type(), transport.Fingerprint(key))
+	return Confirm("trust this host?")
+}
+
+// * 提供給 rsync -e 使用，取代 sshpass + ssh
+func RshCommand() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("'%s' __rsh", strings.ReplaceAll(exe, "'", `'\''`)), nil
+}
+
+// * 與 CMDRun 相同，另將本次已輸入或取得的密碼以暫存檔帶給 __rsh 子程序，rsync 結束後移除
+func RsyncRun(args ...string) error {
+	env, cleanup := credential.Export()
+	defer cleanup()
+
+	cmd := exec.Command("rsync", args...)
+	cmd.Env = append(os.Environ(), env...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("exec rsync: %w", err)
+	}
+	return nil
+}
+
+func RsyncOutput(args ...string) (string, error) {
+	env, cleanup := credential.Export()
+	defer cleanup()
 
-	return cmd.Run()
+	cmd := exec.Command("rsync", args...)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("exec rsync: %w", err)
+	}
+	return string(out), nil
 }
 
-func SSEOutput(args ...string) (string, error) {
-	env, err := CheckENV()
+// * rsync 呼叫格式：<rsh> [-l user] host command...
+func RshRun(args []string) (int, error) {
+	HostKeyConfirm = nil
+	credential.Import()
+	if user := rshUser(args); user != "" {
+		os.Setenv("PODRUN_USERNAME", user)
+	}
+
+	var command []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-l" && i+1 < len(args) {
+			i++
+			continue
+		}
+		command = args[i+1:]
+		break
+	}
+	if len(command) == 0 {
+		return -1, fmt.Errorf("rsh: missing remote command")
+	}
+
+	client, err := SSHClient()
 	if err != nil {
-		return "", err
+		return -1, err
 	}
+	defer SSHClose()
 
-	command := strings.Join(args, " ")
-	cmdArgs := []string{
-		"-p", env.Password,
-		"ssh",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "LogLevel=QUIET",
-		env.Remote,
-		command,
-	}
-	out, err := CMDOutput("sshpass", cmdArgs...)
-	return string(out), err
+	return client.Run(strings.Join(command, " "), &transport.RunOptions{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	})
+}
+
+func rshUser(args []string) string {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "-l" {
+			return args[i+1]
+		}
+	}
+	return ""
 }
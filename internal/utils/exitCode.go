@@ -0,0 +1,57 @@
+package utils
+
+import "errors"
+
+// * CLI 的結束代碼依失敗類別固定，供 CI 判斷
+const (
+	ExitFailure = 1
+	// * 參數、設定或缺少必要的環境
+	ExitUsage = 2
+	// * SSH 連線、主機金鑰或憑證
+	ExitAuth = 3
+	// * 檔案同步
+	ExitSync = 4
+	// * 遠端的 podman compose / kubectl
+	ExitCompose = 5
+	// * registry 讀寫
+	ExitRegistry = 6
+	// * 遠端有異動但未確認覆寫（拒絕或無法詢問）
+	ExitCancelled = 7
+)
+
+type ClassError struct {
+	Code int
+	Err  error
+}
+
+func (e *ClassError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ClassError) Unwrap() error {
+	return e.Err
+}
+
+// * 已分類的錯誤保留最先判定的類別
+func Classify(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	var class *ClassError
+	if errors.As(err, &class) {
+		return err
+	}
+	return &ClassError{Code: code, Err: err}
+}
+
+// * 未分類的錯誤使用 fallback
+func ExitStatus(err error, fallback int) int {
+	if err == nil {
+		return 0
+	}
+	var class *ClassError
+	if errors.As(err, &class) {
+		return class.Code
+	}
+	return fallback
+}
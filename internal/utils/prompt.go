@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+)
+
+// * PODRUN_NO_INPUT（--no-input）或 stdin 不是終端機時不詢問，需要確認的操作直接失敗
+func Interactive() bool {
+	if noInput, _ := strconv.ParseBool(os.Getenv("PODRUN_NO_INPUT")); noInput {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+func Confirm(question string) bool {
+	fmt.Printf("[!] %s (y/N): ", question)
+	var confirm string
+	fmt.Scanln(&confirm)
+	return confirm == "y" || confirm == "Y"
+}
+
+// * 遠端有異動時是否覆寫：prompt（預設，詢問或 --yes）、allow（不詢問）、deny（只接受互動確認）
+func OverwritePolicy(flag string) (string, error) {
+	policy := flag
+	if policy == "" {
+		policy = os.Getenv("PODRUN_OVERWRITE")
+	}
+	switch policy {
+	case "":
+		return "prompt", nil
+	case "prompt", "allow", "deny":
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid overwrite policy: %s (prompt, allow or deny)", policy)
+}
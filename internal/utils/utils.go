@@ -1,10 +1,16 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
+	"os/exec"
+	"os/user"
 	"strings"
+
+	"github.com/pardnchiu/go-podrun/internal/credential"
+	"github.com/pardnchiu/go-podrun/internal/inventory"
 )
 
 func IsDir(path string) bool {
@@ -31,37 +37,179 @@ func GetMAC() (string, error) {
 }
 
 type Podrun struct {
-	Server   string
-	Username string
-	Password string
-	Remote   string
+	// * inventory 中的名稱；未使用 inventory 時為 PODRUN_SERVER 的值
+	Name       string
+	Server     string
+	Username   string
+	Credential credential.Provider
+	Host       string
+	Remote     string
+	BaseDir    string
+}
+
+var ErrNoServer = errors.New("no server configured")
+
+// * 由 --server 或 registry 記錄的部署位置決定，優先於 PODRUN_SERVER
+var selected *inventory.Server
+
+// * 同時寫入 PODRUN_SERVER，讓 rsync 呼叫的 __rsh 子程序連到同一台主機
+func UseServer(s *inventory.Server) {
+	SSHClose()
+	selected = s
+	os.Setenv("PODRUN_SERVER", s.Name)
+}
+
+// * name 為空時使用 PODRUN_SERVER，再來是 inventory 的 default
+// * 不在 inventory 中的名稱視為主機位址，憑證沿用 PODRUN_* 環境變數
+func ResolveServer(name string) (*inventory.Server, error) {
+	inv, err := inventory.Load(inventory.DefaultPath())
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = os.Getenv("PODRUN_SERVER")
+	}
+	if name == "" {
+		name = inv.Default
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%w: set PODRUN_SERVER, pass --server or add a default to %s", ErrNoServer, inv.Path())
+	}
+	if s, ok := inv.Get(name); ok {
+		return s, nil
+	}
+	return &inventory.Server{Name: name, Host: name}, nil
 }
 
 func CheckENV() (*Podrun, error) {
-	server := os.Getenv("PODRUN_SERVER")
-	username := os.Getenv("PODRUN_USERNAME")
-	password := os.Getenv("PODRUN_PASSWORD")
-	if server == "" || username == "" || password == "" {
+	s := selected
+	if s == nil {
+		var err error
+		if s, err = ResolveServer(""); err != nil {
+			return nil, err
+		}
+	}
+	return ServerEnv(s)
+}
+
+// * inventory 未設定的欄位沿用 PODRUN_USERNAME，驗證方式見 serverCredential
+func ServerEnv(s *inventory.Server) (*Podrun, error) {
+	username := s.User
+	if username == "" {
+		username = os.Getenv("PODRUN_USERNAME")
+	}
+	// * rsync 的遠端位置不能帶 port，port 由 SSH transport 處理
+	host := s.Hostname()
+	provider, err := serverCredential(s, fmt.Sprintf("%s@%s", username, host))
+	if err != nil {
+		return nil, err
+	}
+	if s.Host == "" || username == "" || provider == nil {
 		var missing []string
-		if server == "" {
+		if s.Host == "" {
 			missing = append(missing, "PODRUN_SERVER")
 		}
 		if username == "" {
 			missing = append(missing, "PODRUN_USERNAME")
 		}
-		if password == "" {
-			missing = append(missing, "PODRUN_PASSWORD")
+		if provider == nil {
+			missing = append(missing, "PODRUN_CREDENTIAL or PODRUN_PASSWORD or PODRUN_IDENTITY or SSH_AUTH_SOCK")
 		}
-		return nil, fmt.Errorf("missing required environment: %s", strings.Join(missing, ", "))
+		return nil, fmt.Errorf("missing required environment for server %s: %s", s.Name, strings.Join(missing, ", "))
 	}
 	return &Podrun{
-		Server:   server,
-		Username: username,
-		Password: password,
-		Remote:   fmt.Sprintf("%s@%s", username, server),
+		Name:       s.Name,
+		Server:     s.Addr(),
+		Username:   username,
+		Credential: provider,
+		Host:       host,
+		Remote:     fmt.Sprintf("%s@%s", username, host),
+		BaseDir:    s.RemoteBase(),
 	}, nil
 }
 
+// * 依序使用 inventory 的 credential、PODRUN_CREDENTIAL，
+// * 最後合併舊有的 ssh-agent、identity_file / PODRUN_IDENTITY、password_env / PODRUN_PASSWORD；皆未設定時回傳 nil
+func serverCredential(s *inventory.Server, target string) (credential.Provider, error) {
+	name := s.Credential
+	if name == "" {
+		name = os.Getenv("PODRUN_CREDENTIAL")
+	}
+	if name != "" {
+		inv, err := inventory.Load(inventory.DefaultPath())
+		if err != nil {
+			return nil, err
+		}
+		return inv.Provider(name, target)
+	}
+
+	var chain credential.Chain
+	if os.Getenv("SSH_AUTH_SOCK") != "" {
+		p, _ := credential.New(credential.TypeAgent, &credential.Spec{Type: credential.TypeAgent}, target, nil)
+		chain = append(chain, p)
+	}
+	identityFile := s.IdentityFile
+	if identityFile == "" {
+		identityFile = os.Getenv("PODRUN_IDENTITY")
+	}
+	passwordEnv := s.PasswordEnv
+	if passwordEnv == "" {
+		passwordEnv = "PODRUN_PASSWORD"
+	}
+	password, err := credential.New(passwordEnv, &credential.Spec{Type: credential.TypeEnv, Variable: passwordEnv}, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if identityFile != "" {
+		spec := &credential.Spec{Type: credential.TypeIdentity, Path: identityFile}
+		p, err := credential.New("identity_file", spec, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, p)
+	}
+	// * 有密碼時也作為私鑰的 passphrase
+	if os.Getenv(passwordEnv) != "" {
+		chain = append(chain, password)
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+// * PODRUN_SYNC=rsync|native，未設定時本機有 rsync 才使用 rsync
+func SyncMode(flag string) string {
+	mode := flag
+	if mode == "" {
+		mode = os.Getenv("PODRUN_SYNC")
+	}
+	switch mode {
+	case "rsync", "native":
+		return mode
+	}
+	if _, err := exec.LookPath("rsync"); err == nil {
+		return "rsync"
+	}
+	return "native"
+}
+
+// * PODRUN_USER 未設定時使用本機登入的使用者名稱
+// * API 啟用驗證時由 server 以 token 的使用者取代
+func GetUserName() string {
+	if name := os.Getenv("PODRUN_USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		// * Windows 為 DOMAIN\name
+		if _, name, ok := strings.Cut(u.Username, `\`); ok {
+			return name
+		}
+		return u.Username
+	}
+	return "unknown"
+}
+
 func GetHostName() string {
 	if host, err := os.Hostname(); err == nil {
 		return host
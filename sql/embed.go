@@ -0,0 +1,9 @@
+// * 編入 binary，不依賴執行時的工作目錄
+package sql
+
+import "embed"
+
+// * 檔名格式 <version>_<name>.sql，依 version 遞增套用
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,16 @@
+-- host port 只需在同一台主機上不重複；server_id 為 0 代表未記錄主機
+-- seq 區分同一個 container port 的多個對應（例如 "80" 與 "8080:80"），各自分配 host port
+CREATE TABLE IF NOT EXISTS ports (
+   id INTEGER PRIMARY KEY AUTOINCREMENT,
+   uid TEXT NOT NULL,
+   server_id INTEGER NOT NULL DEFAULT 0,
+   service TEXT NOT NULL,
+   container_port INTEGER NOT NULL,
+   protocol TEXT DEFAULT 'tcp',
+   seq INTEGER NOT NULL DEFAULT 0,
+   host_port INTEGER NOT NULL,
+   created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+   updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+   UNIQUE (uid, service, container_port, protocol, seq),
+   UNIQUE (server_id, host_port)
+);
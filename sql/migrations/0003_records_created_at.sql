@@ -0,0 +1,2 @@
+-- ALTER TABLE 不接受 CURRENT_TIMESTAMP 預設值，由 InsertRecord 寫入
+ALTER TABLE records ADD COLUMN created_at DATETIME;
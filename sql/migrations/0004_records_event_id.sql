@@ -0,0 +1,3 @@
+ALTER TABLE records ADD COLUMN event_id TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS records_event_id ON records (event_id);
@@ -0,0 +1,6 @@
+ALTER TABLE records ADD COLUMN command TEXT DEFAULT '';
+ALTER TABLE records ADD COLUMN exit_code INTEGER DEFAULT 0;
+ALTER TABLE records ADD COLUMN error TEXT DEFAULT '';
+ALTER TABLE records ADD COLUMN duration_ms INTEGER DEFAULT 0;
+ALTER TABLE records ADD COLUMN git_commit TEXT DEFAULT '';
+ALTER TABLE records ADD COLUMN payload TEXT DEFAULT '';
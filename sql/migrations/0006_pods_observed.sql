@@ -0,0 +1,2 @@
+ALTER TABLE pods ADD COLUMN restarts INTEGER DEFAULT 0;
+ALTER TABLE pods ADD COLUMN last_seen_at DATETIME;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS users (
+   id INTEGER PRIMARY KEY AUTOINCREMENT,
+   name TEXT UNIQUE NOT NULL,
+   email TEXT DEFAULT '',
+   created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+   dismiss INTEGER DEFAULT 0
+);
+
+-- 只保存 SHA-256，明文 token 僅在建立時顯示一次
+CREATE TABLE IF NOT EXISTS tokens (
+   id INTEGER PRIMARY KEY AUTOINCREMENT,
+   user_id INTEGER NOT NULL,
+   name TEXT DEFAULT '',
+   prefix TEXT NOT NULL,
+   hash TEXT UNIQUE NOT NULL,
+   created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+   last_used_at DATETIME,
+   revoked_at DATETIME,
+   FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+-- 部署的建立者與每筆紀錄的執行者
+ALTER TABLE pods ADD COLUMN user_id INTEGER REFERENCES users(id);
+ALTER TABLE records ADD COLUMN user_id INTEGER REFERENCES users(id);
+CREATE INDEX IF NOT EXISTS idx_pods_user_id ON pods(user_id);
+CREATE INDEX IF NOT EXISTS idx_records_user_id ON records(user_id);
@@ -0,0 +1,14 @@
+-- 部署目標主機，憑證只保存在 CLI 端的 inventory
+CREATE TABLE IF NOT EXISTS servers (
+   id INTEGER PRIMARY KEY AUTOINCREMENT,
+   name TEXT UNIQUE NOT NULL,
+   host TEXT DEFAULT '',
+   port INTEGER DEFAULT 0,
+   user TEXT DEFAULT '',
+   base_dir TEXT DEFAULT '',
+   created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+   updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE pods ADD COLUMN server_id INTEGER REFERENCES servers(id);
+CREATE INDEX IF NOT EXISTS idx_pods_server_id ON pods(server_id);
@@ -0,0 +1,2 @@
+-- podman-compose 的 project 名稱（compose 頂層 name:，未設定時為遠端資料夾名稱）
+ALTER TABLE pods ADD COLUMN project TEXT DEFAULT '';